@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jrswab/lsq/carry"
+	"github.com/jrswab/lsq/system"
+)
+
+func runCarry(args []string) error {
+	fs, dir := newFlagSet("carry")
+	days := fs.Int("days", 7, "Number of previous days of journals to scan.")
+	refs := fs.Bool("ref", false, "Add block references to the original tasks instead of copies.")
+	list := fs.Bool("list", false, "Only list the unfinished tasks without carrying them over.")
+	fs.Parse(args)
+
+	if *days < 1 {
+		return fmt.Errorf("-days must be at least 1")
+	}
+
+	cfg, err := loadConfig(*dir)
+	if err != nil {
+		return err
+	}
+
+	opts := carry.Options{Days: *days, Refs: *refs, Now: time.Now()}
+
+	if *list {
+		tasks, err := carry.Find(cfg, opts)
+		if err != nil {
+			return fmt.Errorf("error finding unfinished tasks: %v", err)
+		}
+		for _, task := range tasks {
+			fmt.Printf("%s#%d: %s\n", task.File.Path, task.Block.Line+1, task.Block.Content())
+		}
+		return nil
+	}
+
+	journalPath, err := system.GetJournal(cfg, cfg.JournalsDir, "")
+	if err != nil {
+		return fmt.Errorf("error setting journal path: %v", err)
+	}
+
+	count, err := carry.Run(cfg, journalPath, opts)
+	if err != nil {
+		return fmt.Errorf("error carrying over tasks: %v", err)
+	}
+
+	fmt.Printf("Carried over %d unfinished task(s).\n", count)
+	return nil
+}
@@ -0,0 +1,265 @@
+// Package carry moves unfinished tasks from previous journals into today's journal.
+package carry
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jrswab/lsq/config"
+	"github.com/jrswab/lsq/graph"
+	"github.com/jrswab/lsq/org"
+	"github.com/jrswab/lsq/outline"
+	"github.com/jrswab/lsq/store"
+	"github.com/jrswab/lsq/todo"
+)
+
+// Heading is the content of the block carried over tasks are placed under.
+const Heading = "Carried over"
+
+// Property marks an original task once it has been carried over so
+// that it is not picked up again on the next run.
+const Property = "carried-over"
+
+// Options controls which journals are scanned and how tasks are carried over.
+type Options struct {
+	// Days is the number of days before Now whose journals are scanned.
+	Days int
+	// Refs places block references in today's journal instead of copies.
+	Refs bool
+	Now  time.Time
+}
+
+// Task is an open task found in a previous journal.
+type Task struct {
+	File  graph.File
+	Block *outline.Block
+}
+
+// journal is a parsed journal with the open tasks it contains.
+type journal struct {
+	file graph.File
+	// data is the content the page was parsed from.
+	data  []byte
+	page  *outline.Page
+	tasks []*outline.Block
+}
+
+// Find lists the open tasks of the journals within the range of opts.
+// Tasks nested below another open task are carried over with their parent.
+func Find(cfg *config.Config, opts Options) ([]Task, error) {
+	journals, err := scan(cfg, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var tasks []Task
+	for _, j := range journals {
+		for _, b := range j.tasks {
+			tasks = append(tasks, Task{File: j.file, Block: b})
+		}
+	}
+	return tasks, nil
+}
+
+// Run carries the open tasks into the journal at todayPath and marks the originals.
+// Today's journal is written first, so a failure never leaves tasks marked
+// as carried without a copy. With references, the ids they point at are
+// written to the originals before that, so today's journal never refers to
+// a block without one. It returns the number of tasks carried over.
+func Run(cfg *config.Config, todayPath string, opts Options) (int, error) {
+	journals, err := scan(cfg, opts)
+	if err != nil {
+		return 0, err
+	}
+
+	toOrg := outline.IsOrg(todayPath)
+
+	var carried []string
+	count := 0
+	for _, j := range journals {
+		for _, b := range j.tasks {
+			if opts.Refs {
+				carried = append(carried, outline.Bullet(toOrg, 1, fmt.Sprintf("((%s))", b.EnsureID())))
+			} else {
+				carried = append(carried, copyBlock(b, toOrg)...)
+			}
+			count++
+		}
+	}
+
+	if count == 0 {
+		return 0, nil
+	}
+
+	if opts.Refs {
+		for i := range journals {
+			j := &journals[i]
+			withIDs := []byte(j.page.String())
+			if len(j.tasks) == 0 || bytes.Equal(withIDs, j.data) {
+				continue
+			}
+			if err := store.Replace(j.file.Path, j.data, withIDs); err != nil {
+				return 0, fmt.Errorf("error updating %s: %w", j.file.Path, err)
+			}
+			j.data = withIDs
+		}
+	}
+
+	if err := addToJournal(todayPath, carried); err != nil {
+		return 0, err
+	}
+
+	for _, j := range journals {
+		if len(j.tasks) == 0 {
+			continue
+		}
+		for _, b := range j.tasks {
+			b.SetProperty(Property, opts.Now.Format("2006-01-02"))
+		}
+		if err := store.Replace(j.file.Path, j.data, []byte(j.page.String())); err != nil {
+			return count, fmt.Errorf("error updating %s: %w", j.file.Path, err)
+		}
+	}
+	return count, nil
+}
+
+// scan parses the journals within the range of opts and collects their open tasks.
+func scan(cfg *config.Config, opts Options) ([]journal, error) {
+	files, err := graph.Journals(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	today := time.Date(opts.Now.Year(), opts.Now.Month(), opts.Now.Day(), 0, 0, 0, 0, time.Local)
+	first := today.AddDate(0, 0, -opts.Days)
+
+	var journals []journal
+	for _, f := range files {
+		if f.Date.IsZero() || f.Date.Before(first) || !f.Date.Before(today) {
+			continue
+		}
+
+		data, err := os.ReadFile(f.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		j := journal{file: f, data: data, page: outline.ParseFile(f.Path, string(data))}
+		j.page.Walk(func(b *outline.Block) bool {
+			if _, done := b.Property(Property); done {
+				return false
+			}
+			if todo.IsOpen(b.Content()) {
+				j.tasks = append(j.tasks, b)
+				return false
+			}
+			return true
+		})
+
+		journals = append(journals, j)
+	}
+
+	return journals, nil
+}
+
+// copyBlock re-indents a task and its children below the heading block,
+// converting it when the task and today's journal use different formats.
+// Block ids are dropped since Logseq requires them to be unique.
+func copyBlock(b *outline.Block, toOrg bool) []string {
+	var lines []string
+	for _, line := range b.Subtree() {
+		if b.Org() {
+			// Move the heading to the top level by removing the stars of its parents.
+			if stars := len(line) - len(strings.TrimLeft(line, "*")); stars > 0 && strings.HasPrefix(line[stars:], " ") {
+				line = line[b.Level():]
+			}
+		} else {
+			line = strings.TrimPrefix(line, b.Indent)
+		}
+		lines = append(lines, line)
+	}
+
+	text := strings.Join(lines, "\n") + "\n"
+	switch {
+	case toOrg && !b.Org():
+		text = org.ToOrg(text)
+	case !toOrg && b.Org():
+		text = org.ToMarkdown(text)
+	}
+
+	lines = nil
+	for _, line := range strings.Split(strings.TrimSuffix(text, "\n"), "\n") {
+		if isID(line) {
+			continue
+		}
+		if toOrg {
+			if strings.HasPrefix(line, "*") {
+				line = "*" + line
+			}
+		} else {
+			line = "\t" + line
+		}
+		lines = append(lines, line)
+	}
+	return dropEmptyDrawers(lines)
+}
+
+// isID reports whether line is an "id::" property or an Org ":id:" drawer property.
+func isID(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	if key, _, ok := outline.ParseProperty(trimmed); ok {
+		return strings.EqualFold(key, "id")
+	}
+	return len(trimmed) > 4 && strings.EqualFold(trimmed[:4], ":id:")
+}
+
+// dropEmptyDrawers removes Org property drawers left empty by removing ids.
+func dropEmptyDrawers(lines []string) []string {
+	out := make([]string, 0, len(lines))
+	for i := 0; i < len(lines); i++ {
+		if i+1 < len(lines) && strings.TrimSpace(lines[i]) == ":PROPERTIES:" && strings.TrimSpace(lines[i+1]) == ":END:" {
+			i++
+			continue
+		}
+		out = append(out, lines[i])
+	}
+	return out
+}
+
+// addToJournal places lines below the heading block of the journal,
+// creating the heading when the journal does not have one yet.
+func addToJournal(path string, lines []string) error {
+	return store.Update(path, func(data []byte) ([]byte, error) {
+		return []byte(placeLines(path, string(data), lines)), nil
+	})
+}
+
+// placeLines returns content with lines added below the heading block.
+func placeLines(path, content string, lines []string) string {
+	page := outline.ParseFile(path, content)
+
+	var heading *outline.Block
+	for _, b := range page.Blocks {
+		if b.Content() == Heading {
+			heading = b
+		}
+	}
+
+	if heading == nil {
+		heading = &outline.Block{Lines: []string{outline.Bullet(outline.IsOrg(path), 0, Heading)}}
+		page.Blocks = append(page.Blocks, heading)
+	}
+
+	// Attach the lines to the last block of the heading's subtree so
+	// they are written out directly after the existing carried tasks.
+	last := heading
+	for len(last.Children) > 0 {
+		last = last.Children[len(last.Children)-1]
+	}
+	last.Lines = append(last.Lines, lines...)
+
+	return page.String()
+}
@@ -0,0 +1,191 @@
+package carry_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/jrswab/lsq/carry"
+	"github.com/jrswab/lsq/config"
+	"github.com/jrswab/lsq/store"
+)
+
+func setup(t *testing.T, journals map[string]string) *config.Config {
+	t.Helper()
+
+	dir := t.TempDir()
+	cfg := &config.Config{
+		FileType:    "Markdown",
+		FileFmt:     "yyyy_MM_dd",
+		DirPath:     dir,
+		JournalsDir: filepath.Join(dir, "journals"),
+		PagesDir:    filepath.Join(dir, "pages"),
+	}
+
+	if err := os.MkdirAll(cfg.JournalsDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, content := range journals {
+		if err := os.WriteFile(filepath.Join(cfg.JournalsDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return cfg
+}
+
+func read(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestRunCopies(t *testing.T) {
+	now := time.Date(2025, 1, 15, 9, 0, 0, 0, time.Local)
+	cfg := setup(t, map[string]string{
+		"2025_01_01.md": "- TODO too old\n",
+		"2025_01_14.md": "- DONE finished\n- TODO call bob\n  id:: 6789\n\t- LATER nested\n\t- notes\n- meeting\n\t- NOW follow up\n",
+		"2025_01_15.md": "- TODO already today\n",
+	})
+
+	today := filepath.Join(cfg.JournalsDir, "2025_01_15.md")
+	count, err := carry.Run(cfg, today, carry.Options{Days: 7, Now: now})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if count != 2 {
+		t.Errorf("Run() carried %d tasks, want 2", count)
+	}
+
+	want := "- TODO already today\n" +
+		"- Carried over\n" +
+		"\t- TODO call bob\n" +
+		"\t\t- LATER nested\n" +
+		"\t\t- notes\n" +
+		"\t- NOW follow up\n"
+	if got := read(t, today); got != want {
+		t.Errorf("today's journal =\n%q\nwant\n%q", got, want)
+	}
+
+	wantOriginal := "- DONE finished\n" +
+		"- TODO call bob\n  id:: 6789\n  carried-over:: 2025-01-15\n" +
+		"\t- LATER nested\n\t- notes\n" +
+		"- meeting\n" +
+		"\t- NOW follow up\n\t  carried-over:: 2025-01-15\n"
+	if got := read(t, filepath.Join(cfg.JournalsDir, "2025_01_14.md")); got != wantOriginal {
+		t.Errorf("original journal =\n%q\nwant\n%q", got, wantOriginal)
+	}
+
+	// Running again must not carry the same tasks twice.
+	count, err = carry.Run(cfg, today, carry.Options{Days: 7, Now: now})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Errorf("second Run() carried %d tasks, want 0", count)
+	}
+}
+
+func TestRunFailedToday(t *testing.T) {
+	now := time.Date(2025, 1, 15, 9, 0, 0, 0, time.Local)
+	original := "- TODO call bob\n"
+	cfg := setup(t, map[string]string{"2025_01_14.md": original})
+
+	// Today's journal cannot be written below a regular file.
+	blocker := filepath.Join(cfg.DirPath, "blocker")
+	if err := os.WriteFile(blocker, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	today := filepath.Join(blocker, "2025_01_15.md")
+
+	if _, err := carry.Run(cfg, today, carry.Options{Days: 7, Now: now}); err == nil {
+		t.Fatal("Run() succeeded, want an error")
+	}
+	if got := read(t, filepath.Join(cfg.JournalsDir, "2025_01_14.md")); got != original {
+		t.Errorf("original journal = %q, want it unchanged %q", got, original)
+	}
+}
+
+func TestRunRefs(t *testing.T) {
+	now := time.Date(2025, 1, 15, 9, 0, 0, 0, time.Local)
+	cfg := setup(t, map[string]string{
+		"2025_01_13.md": "- TODO with id\n  id:: 1234\n",
+		"2025_01_14.md": "- DOING without id\n",
+	})
+
+	today := filepath.Join(cfg.JournalsDir, "2025_01_15.md")
+	if _, err := carry.Run(cfg, today, carry.Options{Days: 3, Refs: true, Now: now}); err != nil {
+		t.Fatal(err)
+	}
+
+	got := read(t, today)
+	want := regexp.MustCompile(`^- Carried over\n\t- \(\(1234\)\)\n\t- \(\(([0-9a-f-]{36})\)\)\n$`)
+	m := want.FindStringSubmatch(got)
+	if m == nil {
+		t.Fatalf("today's journal = %q", got)
+	}
+
+	original := read(t, filepath.Join(cfg.JournalsDir, "2025_01_14.md"))
+	if wantOriginal := "- DOING without id\n  id:: " + m[1] + "\n  carried-over:: 2025-01-15\n"; original != wantOriginal {
+		t.Errorf("original journal = %q, want %q", original, wantOriginal)
+	}
+}
+
+func TestRunRefsChangedSource(t *testing.T) {
+	now := time.Date(2025, 1, 15, 9, 0, 0, 0, time.Local)
+	cfg := setup(t, map[string]string{
+		"2025_01_13.md": "- TODO first\n",
+	})
+
+	// The second journal points at the first, so it no longer holds what
+	// was read once the id of the first is written.
+	if err := os.Symlink("2025_01_13.md", filepath.Join(cfg.JournalsDir, "2025_01_14.md")); err != nil {
+		t.Skip("symlinks are not supported:", err)
+	}
+
+	today := filepath.Join(cfg.JournalsDir, "2025_01_15.md")
+	if _, err := carry.Run(cfg, today, carry.Options{Days: 3, Refs: true, Now: now}); !errors.Is(err, store.ErrChanged) {
+		t.Fatalf("Run() error = %v, want %v", err, store.ErrChanged)
+	}
+	if _, err := os.Stat(today); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("today's journal was written with references to blocks without ids: %q", read(t, today))
+	}
+}
+
+func TestRunOrg(t *testing.T) {
+	now := time.Date(2025, 1, 15, 9, 0, 0, 0, time.Local)
+	cfg := setup(t, map[string]string{
+		"2025_01_13.md":  "- TODO from markdown\n  id:: 1234\n",
+		"2025_01_14.org": "* meeting\n** TODO call bob\n:PROPERTIES:\n:id: 5678\n:END:\n*** notes\n",
+	})
+
+	today := filepath.Join(cfg.JournalsDir, "2025_01_15.org")
+	count, err := carry.Run(cfg, today, carry.Options{Days: 3, Now: now})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Errorf("Run() carried %d tasks, want 2", count)
+	}
+
+	want := "* Carried over\n" +
+		"** TODO from markdown\n" +
+		"** TODO call bob\n" +
+		"*** notes\n"
+	if got := read(t, today); got != want {
+		t.Errorf("today's journal = %q, want %q", got, want)
+	}
+
+	wantOriginal := "* meeting\n** TODO call bob\n:PROPERTIES:\n:id: 5678\n:carried-over: 2025-01-15\n:END:\n*** notes\n"
+	if got := read(t, filepath.Join(cfg.JournalsDir, "2025_01_14.org")); got != wantOriginal {
+		t.Errorf("original journal = %q, want %q", got, wantOriginal)
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jrswab/lsq/clock"
+	"github.com/jrswab/lsq/graph"
+	"github.com/jrswab/lsq/todo"
+)
+
+func runClock(args []string) error {
+	if len(args) == 0 || args[0] != "report" {
+		return fmt.Errorf("usage: lsq clock report [-from yyyy-MM-dd] [-to yyyy-MM-dd] [-by task|page|tag]")
+	}
+
+	fs, dir := newFlagSet("clock report")
+	today := time.Now().Format("2006-01-02")
+	from := fs.String("from", time.Now().AddDate(0, 0, -6).Format("2006-01-02"), "First day of the report. Use yyyy-MM-dd.")
+	to := fs.String("to", today, "Last day of the report. Use yyyy-MM-dd.")
+	by := fs.String("by", "", "Only group by task, page or tag.")
+	fs.Parse(args[1:])
+
+	fromDate, err := time.ParseInLocation("2006-01-02", *from, time.Local)
+	if err != nil {
+		return fmt.Errorf("error parsing -from date: %v", err)
+	}
+
+	toDate, err := time.ParseInLocation("2006-01-02", *to, time.Local)
+	if err != nil {
+		return fmt.Errorf("error parsing -to date: %v", err)
+	}
+
+	cfg, err := loadConfig(*dir)
+	if err != nil {
+		return err
+	}
+
+	files, err := graph.All(cfg)
+	if err != nil {
+		return fmt.Errorf("error listing graph files: %v", err)
+	}
+
+	var entries []clock.Entry
+	for _, f := range files {
+		fileEntries, err := clock.Entries(f)
+		if err != nil {
+			return fmt.Errorf("error reading %s: %v", f.Path, err)
+		}
+		entries = append(entries, fileEntries...)
+	}
+
+	report := clock.NewReport(entries, fromDate, toDate)
+	fmt.Printf("Clock report %s to %s\n", *from, *to)
+
+	sections := []struct {
+		name   string
+		totals []clock.Total
+	}{
+		{"task", report.Tasks},
+		{"page", report.Pages},
+		{"tag", report.Tags},
+	}
+
+	for _, section := range sections {
+		if *by != "" && *by != section.name {
+			continue
+		}
+
+		fmt.Printf("\nBy %s:\n", section.name)
+		for _, total := range section.totals {
+			fmt.Printf("  %s  %s\n", todo.FormatDuration(total.Duration), total.Name)
+		}
+	}
+
+	fmt.Printf("\nTotal: %s\n", todo.FormatDuration(report.Total))
+	return nil
+}
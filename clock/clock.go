@@ -0,0 +1,161 @@
+// Package clock summarises the time tracked in the
+// LOGBOOK CLOCK entries of journals and pages.
+package clock
+
+import (
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jrswab/lsq/graph"
+	"github.com/jrswab/lsq/outline"
+	"github.com/jrswab/lsq/todo"
+)
+
+// Entry is a closed CLOCK entry and the task it belongs to.
+type Entry struct {
+	Task  string
+	Page  string
+	Tags  []string
+	Clock todo.Clock
+}
+
+// Entries returns the closed CLOCK entries found in a file.
+func Entries(f graph.File) ([]Entry, error) {
+	data, err := os.ReadFile(f.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	page := outline.ParseFile(f.Path, string(data))
+
+	var pageTags []string
+	for _, line := range page.Preamble {
+		if key, value, ok := outline.ParsePageProperty(outline.IsOrg(f.Path), line); ok && strings.EqualFold(key, "tags") {
+			pageTags = append(pageTags, outline.PropertyValues(value)...)
+		}
+	}
+
+	var entries []Entry
+	page.Walk(func(b *outline.Block) bool {
+		clocks := todo.Clocks(b.Lines)
+		if len(clocks) == 0 {
+			return true
+		}
+
+		tags := append(outline.Tags(b.Content()), pageTags...)
+		if value, ok := b.Property("tags"); ok {
+			tags = append(tags, outline.PropertyValues(value)...)
+		}
+
+		for _, c := range clocks {
+			if c.Running() {
+				continue
+			}
+			entries = append(entries, Entry{
+				Task:  TaskName(b.Content()),
+				Page:  f.Name,
+				Tags:  tags,
+				Clock: c,
+			})
+		}
+		return true
+	})
+
+	return entries, nil
+}
+
+// TaskName strips the TODO state and priority from the content of a block.
+func TaskName(content string) string {
+	for _, state := range todo.States {
+		if strings.HasPrefix(content, state+" ") {
+			content = strings.TrimPrefix(content, state+" ")
+			break
+		}
+	}
+	for _, priority := range todo.Priorities {
+		if strings.HasPrefix(content, priority+" ") {
+			content = strings.TrimPrefix(content, priority+" ")
+			break
+		}
+	}
+	return strings.TrimSpace(content)
+}
+
+// Total is the time tracked for a single task, page or tag.
+type Total struct {
+	Name     string
+	Duration time.Duration
+}
+
+// Report sums the tracked time of the entries started between From and To.
+type Report struct {
+	From  time.Time
+	To    time.Time
+	Tasks []Total
+	Pages []Total
+	Tags  []Total
+	Total time.Duration
+}
+
+// NewReport builds a report of the entries started on or
+// after the day of from and before the end of the day of to.
+func NewReport(entries []Entry, from, to time.Time) Report {
+	r := Report{From: startOfDay(from), To: startOfDay(to)}
+	end := r.To.AddDate(0, 0, 1)
+
+	var (
+		tasks = map[string]time.Duration{}
+		pages = map[string]time.Duration{}
+		tags  = map[string]time.Duration{}
+	)
+
+	for _, e := range entries {
+		if e.Clock.Start.Before(r.From) || !e.Clock.Start.Before(end) {
+			continue
+		}
+
+		d := e.Clock.Duration()
+		r.Total += d
+		tasks[e.Task] += d
+		pages[e.Page] += d
+
+		seen := map[string]bool{}
+		for _, tag := range e.Tags {
+			key := strings.ToLower(tag)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			tags[key] += d
+		}
+	}
+
+	r.Tasks = totals(tasks)
+	r.Pages = totals(pages)
+	r.Tags = totals(tags)
+
+	return r
+}
+
+// totals sorts the durations from longest to shortest.
+func totals(m map[string]time.Duration) []Total {
+	out := make([]Total, 0, len(m))
+	for name, d := range m {
+		out = append(out, Total{Name: name, Duration: d})
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Duration != out[j].Duration {
+			return out[i].Duration > out[j].Duration
+		}
+		return out[i].Name < out[j].Name
+	})
+
+	return out
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
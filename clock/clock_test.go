@@ -0,0 +1,83 @@
+package clock_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jrswab/lsq/clock"
+	"github.com/jrswab/lsq/graph"
+)
+
+func TestReport(t *testing.T) {
+	dir := t.TempDir()
+
+	pages := map[string]string{
+		"2025_01_14.md": "- DONE write report #work\n" +
+			"  :LOGBOOK:\n" +
+			"  CLOCK: [2025-01-14 Tue 09:00:00]--[2025-01-14 Tue 10:30:00] =>  01:30:00\n" +
+			"  CLOCK: [2025-01-14 Tue 13:00:00]--[2025-01-14 Tue 13:30:00] =>  00:30:00\n" +
+			"  :END:\n",
+		"2025_01_15.md": "tags:: client\n\n" +
+			"- DOING [#A] review\n" +
+			"  tags:: work\n" +
+			"  :LOGBOOK:\n" +
+			"  CLOCK: [2025-01-15 Wed 08:00:00]--[2025-01-15 Wed 09:00:00] =>  01:00:00\n" +
+			"  CLOCK: [2025-01-15 Wed 11:00:00]\n" +
+			"  :END:\n" +
+			"- DONE old task\n" +
+			"  :LOGBOOK:\n" +
+			"  CLOCK: [2024-12-01 Sun 08:00:00]--[2024-12-01 Sun 09:00:00] =>  01:00:00\n" +
+			"  :END:\n",
+		"org task.org": "#+tags: client\n\n" +
+			"* DONE org task\n" +
+			":LOGBOOK:\n" +
+			"CLOCK: [2025-01-14 Tue 14:00:00]--[2025-01-14 Tue 14:30:00] =>  00:30:00\n" +
+			":END:\n",
+	}
+
+	var entries []clock.Entry
+	for file, content := range pages {
+		path := filepath.Join(dir, file)
+		name := strings.TrimSuffix(file, filepath.Ext(file))
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		fileEntries, err := clock.Entries(graph.File{Path: path, Name: name, Journal: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, fileEntries...)
+	}
+
+	if len(entries) != 5 {
+		t.Fatalf("Entries() found %d closed clocks, want 5", len(entries))
+	}
+
+	report := clock.NewReport(entries,
+		time.Date(2025, 1, 14, 12, 0, 0, 0, time.Local),
+		time.Date(2025, 1, 15, 0, 0, 0, 0, time.Local))
+
+	if report.Total != 3*time.Hour+30*time.Minute {
+		t.Errorf("Total = %v, want 3h30m", report.Total)
+	}
+
+	wantTasks := []clock.Total{{"write report #work", 2 * time.Hour}, {"review", time.Hour}, {"org task", 30 * time.Minute}}
+	if !reflect.DeepEqual(report.Tasks, wantTasks) {
+		t.Errorf("Tasks = %v, want %v", report.Tasks, wantTasks)
+	}
+
+	wantPages := []clock.Total{{"2025_01_14", 2 * time.Hour}, {"2025_01_15", time.Hour}, {"org task", 30 * time.Minute}}
+	if !reflect.DeepEqual(report.Pages, wantPages) {
+		t.Errorf("Pages = %v, want %v", report.Pages, wantPages)
+	}
+
+	wantTags := []clock.Total{{"work", 3 * time.Hour}, {"client", time.Hour + 30*time.Minute}}
+	if !reflect.DeepEqual(report.Tags, wantTags) {
+		t.Errorf("Tags = %v, want %v", report.Tags, wantTags)
+	}
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jrswab/lsq/config"
+	"github.com/jrswab/lsq/git"
+	"github.com/jrswab/lsq/hooks"
+	"github.com/jrswab/lsq/store"
+	"github.com/jrswab/lsq/system"
+)
+
+// autoCommit is set by loadConfig when :git/auto-commit is enabled.
+var autoCommit bool
+
+// Hooks are run for the changes of this run.
+var (
+	// operation names what this run does, such as "append" or the name of
+	// the subcommand.
+	operation string
+	// edited is the file opened in the editor, which the post-write hooks
+	// see even when lsq did not write it.
+	edited    string
+	preHooks  []string
+	postHooks []string
+)
+
+// command is invoked as "lsq <name> [flags]".
+type command struct {
+	summary string
+	run     func(args []string) error
+}
+
+var commands = map[string]command{
+	"carry":   {summary: "Carry unfinished tasks from previous journals into today's journal.", run: runCarry},
+	"ref":     {summary: "Print a reference to a block or check for dangling references.", run: runRef},
+	"export":  {summary: "Export pages and journals to other formats.", run: runExport},
+	"clock":   {summary: "Report time tracked in LOGBOOK clock entries.", run: runClock},
+	"convert": {summary: "Convert pages and journals between Markdown and Org.", run: runConvert},
+	"lint":    {summary: "Report broken links, orphan pages and format problems in the graph.", run: runLint},
+	"import":  {summary: "Import Markdown notes, such as an Obsidian vault, as pages and journals.", run: runImport},
+	"history": {summary: "List the recent changes lsq made to the graph.", run: runHistory},
+	"undo":    {summary: "Revert the last changes lsq made to the graph.", run: runUndo},
+	"log":     {summary: "List the git commits that changed a page or journal.", run: runLog},
+	"diff":    {summary: "Show the git changes to the graph between two dates.", run: runDiff},
+	"pick":    {summary: "Choose a page, alias or search hit in an interactive fuzzy finder.", run: runPick},
+	"search":  {summary: "Search the journals and pages by regex with context lines, counts and date filters.", run: runSearch},
+	"serve":   {summary: "Serve a local JSON API over the graph.", run: runServe},
+	"daemon":  {summary: "Watch the graph and answer -f and -r searches from an index kept in memory.", run: runDaemon},
+	"mcp":     {summary: "Serve the graph to assistants over the Model Context Protocol on stdio.", run: runMCP},
+}
+
+// runCommand runs the subcommand named by the first argument.
+// It reports false when the arguments do not name a subcommand.
+func runCommand(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		return false
+	}
+
+	operation = args[0]
+	err := cmd.run(args[1:])
+	// Changes made before a failure are committed too.
+	afterChanges()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	return true
+}
+
+// printCommands lists the subcommands below the flag usage.
+func printCommands() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintf(flag.CommandLine.Output(), "\nCommands:\n")
+	for _, name := range names {
+		fmt.Fprintf(flag.CommandLine.Output(), "  %s\n    \t%s\n", name, commands[name].summary)
+	}
+}
+
+// newFlagSet creates the flags for a subcommand including the
+// "-d" directory override shared with the main command.
+func newFlagSet(name string) (*flag.FlagSet, *string) {
+	fs := flag.NewFlagSet("lsq "+name, flag.ExitOnError)
+	dir := fs.String("d", "", "The path to the Logseq directory to use.")
+	return fs, dir
+}
+
+// loadConfig reads the configuration file and applies the directory override.
+func loadConfig(dir string) (*config.Config, error) {
+	cfg, err := config.Load()
+	if err != nil && !os.IsNotExist(err) {
+		// The user has a config file but we couldn't read it.
+		// Report the error instead of ignoring their configuration.
+		return nil, fmt.Errorf("error loading configuration: %v", err)
+	}
+
+	// When the flag is used override the config.
+	if dir != "" {
+		expanded, err := config.ExpandPath(dir)
+		if err != nil {
+			return nil, fmt.Errorf("error expanding directory path: %v", err)
+		}
+		cfg.DirPath = expanded
+		cfg.JournalsDir = filepath.Join(cfg.DirPath, "journals")
+		cfg.PagesDir = filepath.Join(cfg.DirPath, "pages")
+	}
+
+	// Back up the files this run changes so "lsq undo" can revert them.
+	store.Record(cfg.DirPath, store.Command(os.Args[1:]))
+	autoCommit = cfg.GitCommit
+	preHooks, postHooks = cfg.PreWrite, cfg.PostWrite
+
+	return cfg, nil
+}
+
+// journalPath returns the path of the journal for a yyyy-MM-dd date without creating it.
+func journalPath(cfg *config.Config, date string) (string, error) {
+	parsed, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return "", fmt.Errorf("error parsing date: %v", err)
+	}
+
+	name := parsed.Format(config.ConvertDateFormat(cfg.FileFmt))
+	return system.CreateFilePath(cfg, cfg.JournalsDir, name), nil
+}
+
+// afterChanges runs the post-write hooks for the files changed by this run
+// and commits them when :git/auto-commit is enabled.
+func afterChanges() {
+	root, command, changes := store.Recorded()
+
+	var files []git.File
+	for _, c := range changes {
+		// A file created and removed again was never committed.
+		if c.Backup != "" || c.Hash != "" {
+			files = append(files, git.File{Path: c.Path, Action: c.Action()})
+		}
+	}
+
+	// Files changed in the editor are not written through the store, so
+	// git tells whether the editor changed them.
+	hookFiles := files
+	if edited != "" {
+		rel, err := filepath.Rel(root, edited)
+		if err != nil {
+			rel = edited
+		}
+		if !slices.ContainsFunc(files, func(f git.File) bool { return f.Path == rel }) {
+			f := git.File{Path: rel}
+			if autoCommit && git.IsRepo(root) {
+				if f.Action, err = git.Status(root, rel); err != nil {
+					fmt.Fprintf(os.Stderr, "Warning: error checking %s: %v\n", rel, err)
+				}
+			}
+			if f.Action != "" {
+				files = append(files, f)
+				hookFiles = files
+			} else {
+				hookFiles = append(slices.Clip(files), f)
+			}
+		}
+	}
+	runHooks(root, hookFiles)
+	commitFiles(root, command, files)
+}
+
+// editFile opens path in the editor and runs the post-write hooks for it
+// once lsq is done.
+func editFile(cfg *config.Config, editorName, path string, line int) error {
+	operation, edited = "edit", path
+	return system.LoadEditor(cfg, editorName, path, line)
+}
+
+// appendText runs the pre-write hooks on text before it is appended to path.
+func appendText(cfg *config.Config, path, text string, indent int) error {
+	text, err := hooks.Pre(preHooks, hooks.Event{Operation: "append", Path: path, Dir: cfg.DirPath}, text)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(text) == "" {
+		return fmt.Errorf("nothing left to append after the pre-write hooks")
+	}
+
+	operation = "append"
+	return system.AppendToFile(path, text, indent)
+}
+
+// runHooks runs the post-write hooks for files of the graph at root. The
+// files are already written, so failing hooks are only reported.
+func runHooks(root string, files []git.File) {
+	for _, f := range files {
+		path := f.Path
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(root, path)
+		}
+
+		e := hooks.Event{Operation: operation, Path: path, Action: f.Action, Dir: root}
+		if err := hooks.Post(postHooks, e); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+}
+
+// commitFiles commits files to the git repository of the graph at root.
+// The files are already written, so failing to commit is only reported.
+func commitFiles(root, command string, files []git.File) {
+	if !autoCommit || len(files) == 0 {
+		return
+	}
+
+	if !git.IsRepo(root) {
+		fmt.Fprintf(os.Stderr, "Warning: :git/auto-commit is set but %s is not a git repository\n", root)
+		return
+	}
+
+	paths := make([]string, len(files))
+	for i, f := range files {
+		paths[i] = f.Path
+	}
+
+	if err := git.CommitFiles(root, git.Message(command, files), paths); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: error committing changes: %v\n", err)
+	}
+}
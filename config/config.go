@@ -25,6 +25,29 @@ type Config struct {
 	DirPath     string `edn:"directory"`
 	JournalsDir string `edn:"journals/directory"`
 	PagesDir    string `edn:"pages/directory"`
+
+	// Rendering with -R
+	Hyperlinks     bool     `edn:"render/hyperlinks"`
+	HideProperties bool     `edn:"render/hide-properties"`
+	Bullets        []string `edn:"render/bullets"`
+
+	// Editor is the editor command used when -e is omitted, before $EDITOR.
+	Editor string `edn:"editor/command"`
+	// EditorArgs is the argument template given to the editor after the
+	// arguments of the command. "{file}" and "{line}" are replaced in each
+	// argument.
+	EditorArgs []string `edn:"editor/args"`
+	// EditorLineArgs overrides the arguments used to open a file at a line,
+	// by editor name. "{file}" and "{line}" are replaced in each argument.
+	EditorLineArgs map[string][]string `edn:"editor/line-args"`
+
+	// PreWrite hooks transform or reject text before it is appended.
+	PreWrite []string `edn:"hooks/pre-write"`
+	// PostWrite hooks run after a journal or page changed.
+	PostWrite []string `edn:"hooks/post-write"`
+
+	// GitCommit commits every change lsq makes when the graph is a git repository.
+	GitCommit bool `edn:"git/auto-commit"`
 }
 
 func Load() (*Config, error) {
@@ -223,6 +223,93 @@ func TestConfigLoad(t *testing.T) {
 			},
 			expectError: false,
 		},
+		{
+			name: "Render options",
+			setupFiles: map[string][]byte{
+				cfgRelPath: []byte(`{:directory "/custom/path"
+                              :render/hyperlinks true
+                              :render/hide-properties true
+                              :render/bullets ["*" "-"]}`),
+			},
+			expectedCfg: config.Config{
+				Version:        1,
+				FileFmt:        "yyyy_MM_dd",
+				FileType:       "Markdown",
+				DirPath:        "/custom/path",
+				JournalsDir:    "/custom/path/journals",
+				PagesDir:       "/custom/path/pages",
+				Hyperlinks:     true,
+				HideProperties: true,
+				Bullets:        []string{"*", "-"},
+			},
+			expectError: false,
+		},
+		{
+			name: "Git auto-commit",
+			setupFiles: map[string][]byte{
+				cfgRelPath: []byte(`{:directory "/custom/path" :git/auto-commit true}`),
+			},
+			expectedCfg: config.Config{
+				Version:     1,
+				FileFmt:     "yyyy_MM_dd",
+				FileType:    "Markdown",
+				DirPath:     "/custom/path",
+				JournalsDir: "/custom/path/journals",
+				PagesDir:    "/custom/path/pages",
+				GitCommit:   true,
+			},
+			expectError: false,
+		},
+		{
+			name: "Editor line arguments",
+			setupFiles: map[string][]byte{
+				cfgRelPath: []byte(`{:directory "/custom/path" :editor/line-args {"kak" ["{file}" "+{line}"]}}`),
+			},
+			expectedCfg: config.Config{
+				Version:        1,
+				FileFmt:        "yyyy_MM_dd",
+				FileType:       "Markdown",
+				DirPath:        "/custom/path",
+				JournalsDir:    "/custom/path/journals",
+				PagesDir:       "/custom/path/pages",
+				EditorLineArgs: map[string][]string{"kak": {"{file}", "+{line}"}},
+			},
+			expectError: false,
+		},
+		{
+			name: "Editor command",
+			setupFiles: map[string][]byte{
+				cfgRelPath: []byte(`{:directory "/custom/path" :editor/command "emacsclient -c" :editor/args ["+{line}" "{file}"]}`),
+			},
+			expectedCfg: config.Config{
+				Version:     1,
+				FileFmt:     "yyyy_MM_dd",
+				FileType:    "Markdown",
+				DirPath:     "/custom/path",
+				JournalsDir: "/custom/path/journals",
+				PagesDir:    "/custom/path/pages",
+				Editor:      "emacsclient -c",
+				EditorArgs:  []string{"+{line}", "{file}"},
+			},
+			expectError: false,
+		},
+		{
+			name: "Hooks",
+			setupFiles: map[string][]byte{
+				cfgRelPath: []byte(`{:directory "/custom/path" :hooks/pre-write ["redact"] :hooks/post-write ["lsq lint" "notify-send \"$LSQ_FILE\""]}`),
+			},
+			expectedCfg: config.Config{
+				Version:     1,
+				FileFmt:     "yyyy_MM_dd",
+				FileType:    "Markdown",
+				DirPath:     "/custom/path",
+				JournalsDir: "/custom/path/journals",
+				PagesDir:    "/custom/path/pages",
+				PreWrite:    []string{"redact"},
+				PostWrite:   []string{"lsq lint", `notify-send "$LSQ_FILE"`},
+			},
+			expectError: false,
+		},
 		{
 			name: "Invalid EDN in lsq config",
 			setupFiles: map[string][]byte{
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/jrswab/lsq/graph"
+	"github.com/jrswab/lsq/org"
+)
+
+const convertUsage = "usage: lsq convert -to md|org [-p page | -s yyyy-MM-dd] [-dry-run]"
+
+func runConvert(args []string) error {
+	fs, dir := newFlagSet("convert")
+	to := fs.String("to", "", "Format to convert to: \"md\" or \"org\".")
+	page := fs.String("p", "", "Only convert this page. Must be a file name with extension.")
+	specDate := fs.String("s", "", "Only convert this journal. Use yyyy-MM-dd after the flag.")
+	dryRun := fs.Bool("dry-run", false, "Print the files that would be converted without changing anything.")
+	fs.Parse(args)
+
+	var fileType string
+	switch strings.ToLower(*to) {
+	case "md", "markdown":
+		fileType = org.Markdown
+	case "org":
+		fileType = org.Org
+	default:
+		return fmt.Errorf(convertUsage)
+	}
+
+	cfg, err := loadConfig(*dir)
+	if err != nil {
+		return err
+	}
+
+	files, err := graph.All(cfg)
+	if err != nil {
+		return fmt.Errorf("error listing graph files: %v", err)
+	}
+
+	if *page != "" || *specDate != "" {
+		path := filepath.Join(cfg.PagesDir, *page)
+		if *page == "" {
+			date, err := journalPath(cfg, *specDate)
+			if err != nil {
+				return err
+			}
+			// The journal may be stored in either format.
+			path = strings.TrimSuffix(date, filepath.Ext(date))
+		}
+
+		var selected []graph.File
+		for _, f := range files {
+			if f.Path == path || strings.TrimSuffix(f.Path, filepath.Ext(f.Path)) == path {
+				selected = append(selected, f)
+			}
+		}
+		if len(selected) == 0 {
+			return fmt.Errorf("could not find %s", path)
+		}
+		files = selected
+	}
+
+	results, err := org.Convert(files, org.Options{To: fileType, DryRun: *dryRun})
+	converted := 0
+	for _, r := range results {
+		if r.Skipped != "" {
+			fmt.Printf("%s -> %s (skipped: %s)\n", r.Source, r.Target, r.Skipped)
+			continue
+		}
+		fmt.Printf("%s -> %s\n", r.Source, r.Target)
+		converted++
+	}
+	if err != nil {
+		return fmt.Errorf("error converting files: %v", err)
+	}
+
+	verb := "Converted"
+	if *dryRun {
+		verb = "Would convert"
+	}
+	fmt.Printf("%s %d file(s) to %s.\n", verb, converted, fileType)
+
+	if !strings.EqualFold(cfg.FileType, fileType) && *page == "" && *specDate == "" && !*dryRun {
+		fmt.Printf("New files are still created as %s; set :file/type to %q in config.edn.\n", cfg.FileType, fileType)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/jrswab/lsq/daemon"
+)
+
+func runDaemon(args []string) error {
+	fs, dir := newFlagSet("daemon")
+	status := fs.Bool("status", false, "Report whether a daemon is running for the graph.")
+	stop := fs.Bool("stop", false, "Stop the daemon of the graph.")
+	fs.Parse(args)
+
+	cfg, err := loadConfig(*dir)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case *status:
+		resp, err := daemon.Status(cfg.DirPath)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Indexing %d files of %s since %s (pid %d)\n",
+			resp.Files, resp.Dir, resp.Since.Format("2006-01-02 15:04:05"), resp.PID)
+		tasks, err := daemon.Tasks(cfg.DirPath, nil)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%d open task(s)\n", len(tasks))
+		return nil
+	case *stop:
+		if err := daemon.Stop(cfg.DirPath); err != nil {
+			return err
+		}
+		fmt.Println("Stopped the daemon of", cfg.DirPath)
+		return nil
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	return daemon.Run(ctx, cfg, log.New(os.Stderr, "", log.LstdFlags))
+}
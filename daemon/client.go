@@ -0,0 +1,72 @@
+package daemon
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/jrswab/lsq/index"
+)
+
+// Search returns the file names of the pages whose name or alias starts
+// with prefix, like "lsq -f".
+func Search(dir, prefix string) ([]string, error) {
+	resp, err := query(dir, Request{Op: "search", Query: prefix})
+	return resp.Names, err
+}
+
+// Grep returns the lines of the journals and pages matching pattern.
+func Grep(dir, pattern string) ([]index.Hit, error) {
+	resp, err := query(dir, Request{Op: "grep", Pattern: pattern})
+	return resp.Hits, err
+}
+
+// Tasks lists the tasks in the given states, the open states when there
+// are none.
+func Tasks(dir string, states []string) ([]index.Task, error) {
+	resp, err := query(dir, Request{Op: "tasks", States: states})
+	return resp.Tasks, err
+}
+
+// Status reports the graph, size and start time of the running daemon.
+func Status(dir string) (Response, error) {
+	return query(dir, Request{Op: "status"})
+}
+
+// Stop asks the daemon to shut down.
+func Stop(dir string) error {
+	_, err := query(dir, Request{Op: "stop"})
+	return err
+}
+
+// query asks the daemon of the graph at dir. It fails with ErrNotRunning
+// straight away when there is no daemon, so callers can read the files
+// themselves instead.
+func query(dir string, req Request) (Response, error) {
+	socket, err := SocketPath(dir)
+	if err != nil {
+		return Response{}, err
+	}
+
+	conn, err := net.DialTimeout("unix", socket, 200*time.Millisecond)
+	if err != nil {
+		return Response{}, ErrNotRunning
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(30 * time.Second))
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return Response{}, fmt.Errorf("error sending query to daemon: %v", err)
+	}
+
+	var resp Response
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return Response{}, fmt.Errorf("error reading answer of daemon: %v", err)
+	}
+	if resp.Error != "" {
+		return resp, errors.New(resp.Error)
+	}
+	return resp, nil
+}
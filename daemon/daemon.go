@@ -0,0 +1,241 @@
+// Package daemon keeps an index of a graph up to date as its files change
+// and answers the searches of the CLI over a Unix socket, so they do not
+// read every file of the graph each time.
+package daemon
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/jrswab/lsq/config"
+	"github.com/jrswab/lsq/index"
+	"github.com/jrswab/lsq/search"
+)
+
+// ErrNotRunning is returned by the client functions when no daemon serves
+// the graph.
+var ErrNotRunning = errors.New("daemon is not running")
+
+// Request is a query sent to the daemon, one per connection.
+type Request struct {
+	// Op is "search", "grep", "tasks", "status" or "stop".
+	Op      string   `json:"op"`
+	Query   string   `json:"query,omitempty"`
+	Pattern string   `json:"pattern,omitempty"`
+	States  []string `json:"states,omitempty"`
+}
+
+// Response is the answer to a Request.
+type Response struct {
+	Error string       `json:"error,omitempty"`
+	Names []string     `json:"names,omitempty"`
+	Hits  []index.Hit  `json:"hits,omitempty"`
+	Tasks []index.Task `json:"tasks,omitempty"`
+	// Status
+	Dir   string    `json:"dir,omitempty"`
+	Files int       `json:"files,omitempty"`
+	Since time.Time `json:"since,omitempty"`
+	PID   int       `json:"pid,omitempty"`
+}
+
+// SocketPath returns the socket of the daemon for the graph at dir. It lives
+// in $XDG_RUNTIME_DIR, or a directory of the user in the temporary directory.
+func SocketPath(dir string) (string, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	runtime := os.Getenv("XDG_RUNTIME_DIR")
+	if runtime == "" {
+		runtime = filepath.Join(os.TempDir(), "lsq-"+strconv.Itoa(os.Getuid()))
+		if err := os.MkdirAll(runtime, 0700); err != nil {
+			return "", err
+		}
+	}
+
+	sum := sha256.Sum256([]byte(abs))
+	return filepath.Join(runtime, "lsq-"+hex.EncodeToString(sum[:8])+".sock"), nil
+}
+
+// Run indexes the graph of cfg, keeps the index up to date and answers
+// queries on the socket of the graph until ctx is done.
+func Run(ctx context.Context, cfg *config.Config, logger *log.Logger) error {
+	ctx, stop := context.WithCancel(ctx)
+	defer stop()
+
+	socket, err := SocketPath(cfg.DirPath)
+	if err != nil {
+		return err
+	}
+	if _, err := Status(cfg.DirPath); err == nil {
+		return fmt.Errorf("a daemon is already running for %s", cfg.DirPath)
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("error starting file watcher: %v", err)
+	}
+	defer watcher.Close()
+	for _, dir := range []string{cfg.JournalsDir, cfg.PagesDir} {
+		if err := watchTree(watcher, dir, nil); err != nil {
+			return fmt.Errorf("error watching %s: %v", dir, err)
+		}
+	}
+
+	// Index after watching so no change is missed in between.
+	x, err := index.Build(cfg)
+	if err != nil {
+		return fmt.Errorf("error indexing graph: %v", err)
+	}
+	since := time.Now()
+
+	os.Remove(socket)
+	ln, err := net.Listen("unix", socket)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(socket)
+	defer ln.Close()
+	if err := os.Chmod(socket, 0600); err != nil {
+		return err
+	}
+
+	logger.Printf("Indexed %d files of %s, listening on %s", x.Len(), cfg.DirPath, socket)
+
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+	}()
+	go watch(ctx, watcher, x, logger)
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		go serve(conn, x, cfg, since, stop)
+	}
+}
+
+// watchTree watches dir and the directories below it that hold journals or
+// pages, calling found, when set, with every file it comes across.
+func watchTree(watcher *fsnotify.Watcher, dir string, found func(path string)) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			if found != nil {
+				found(path)
+			}
+			return nil
+		}
+		if path != dir && search.SkipDir(path) {
+			return filepath.SkipDir
+		}
+		return watcher.Add(path)
+	})
+}
+
+// watch applies the changes to the journals and pages to the index.
+func watch(ctx context.Context, watcher *fsnotify.Watcher, x *index.Index, logger *log.Logger) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+			// A new directory is watched too and the files already in it
+			// are indexed. One that is gone takes its files with it.
+			if event.Has(fsnotify.Create) {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					if search.SkipDir(event.Name) {
+						continue
+					}
+					err := watchTree(watcher, event.Name, func(path string) {
+						if err := x.Update(path); err != nil {
+							logger.Printf("Error indexing %s: %v", path, err)
+						}
+					})
+					if err != nil {
+						logger.Printf("Error watching %s: %v", event.Name, err)
+					}
+					continue
+				}
+			}
+			if event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename) {
+				x.Remove(event.Name)
+			}
+			// Update forgets files that are gone after a remove or rename.
+			if err := x.Update(event.Name); err != nil {
+				logger.Printf("Error indexing %s: %v", event.Name, err)
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			logger.Printf("Error watching graph: %v", err)
+			// Events were lost, so read everything again.
+			if errors.Is(err, fsnotify.ErrEventOverflow) {
+				if err := x.Reload(); err != nil {
+					logger.Printf("Error indexing graph: %v", err)
+				}
+			}
+		}
+	}
+}
+
+func serve(conn net.Conn, x *index.Index, cfg *config.Config, since time.Time, stop func()) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(30 * time.Second))
+
+	var req Request
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		json.NewEncoder(conn).Encode(Response{Error: "error decoding request: " + err.Error()})
+		return
+	}
+
+	var resp Response
+	switch req.Op {
+	case "search":
+		resp.Names = x.Search(req.Query)
+	case "grep":
+		re, err := regexp.Compile(req.Pattern)
+		if err != nil {
+			resp.Error = "error compiling regex pattern: " + err.Error()
+			break
+		}
+		resp.Hits = x.Grep(re)
+	case "tasks":
+		resp.Tasks = x.Tasks(req.States)
+	case "status":
+		resp.Dir, resp.Files, resp.Since, resp.PID = cfg.DirPath, x.Len(), since, os.Getpid()
+	case "stop":
+		defer stop()
+	default:
+		resp.Error = "unknown op " + strconv.Quote(req.Op)
+	}
+	json.NewEncoder(conn).Encode(resp)
+}
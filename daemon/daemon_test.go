@@ -0,0 +1,114 @@
+package daemon_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/jrswab/lsq/config"
+	"github.com/jrswab/lsq/daemon"
+)
+
+func TestDaemon(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+	cfg := &config.Config{
+		FileType:    "Markdown",
+		FileFmt:     "yyyy_MM_dd",
+		DirPath:     dir,
+		JournalsDir: filepath.Join(dir, "journals"),
+		PagesDir:    filepath.Join(dir, "pages"),
+	}
+	for _, sub := range []string{cfg.JournalsDir, cfg.PagesDir} {
+		if err := os.MkdirAll(sub, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(cfg.PagesDir, "alpha.md"), []byte("- TODO first"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := daemon.Search(dir, "alpha"); !errors.Is(err, daemon.ErrNotRunning) {
+		t.Fatalf("Search() before Run error = %v, want ErrNotRunning", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- daemon.Run(context.Background(), cfg, log.New(io.Discard, "", 0))
+	}()
+	waitFor(t, func() bool {
+		_, err := daemon.Status(dir)
+		return err == nil
+	})
+
+	if err := daemon.Run(context.Background(), cfg, log.New(io.Discard, "", 0)); err == nil {
+		t.Error("second Run() succeeded, want an error")
+	}
+
+	names, err := daemon.Search(dir, "alpha")
+	if err != nil || !slices.Equal(names, []string{"alpha.md"}) {
+		t.Errorf("Search() = %v, %v, want [alpha.md]", names, err)
+	}
+	tasks, err := daemon.Tasks(dir, nil)
+	if err != nil || len(tasks) != 1 || tasks[0].Content != "TODO first" {
+		t.Errorf("Tasks() = %v, %v, want the TODO of alpha", tasks, err)
+	}
+	if _, err := daemon.Grep(dir, "("); err == nil {
+		t.Error("Grep() with an invalid pattern succeeded, want an error")
+	}
+
+	// A new page is indexed once the watcher sees it.
+	if err := os.WriteFile(filepath.Join(cfg.PagesDir, "beta.md"), []byte("- needle"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool {
+		hits, err := daemon.Grep(dir, "needle")
+		return err == nil && len(hits) == 1
+	})
+
+	// So is a page in a new subdirectory, which is searched like "lsq -r"
+	// searches it without the daemon.
+	if err := os.MkdirAll(filepath.Join(cfg.PagesDir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(cfg.PagesDir, "sub", "gamma.md"), []byte("- needle"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, func() bool {
+		hits, err := daemon.Grep(dir, "needle")
+		return err == nil && len(hits) == 2
+	})
+
+	if err := daemon.Stop(dir); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Run() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("daemon did not stop")
+	}
+	if _, err := daemon.Status(dir); !errors.Is(err, daemon.ErrNotRunning) {
+		t.Errorf("Status() after Stop error = %v, want ErrNotRunning", err)
+	}
+}
+
+// waitFor polls cond until it holds or five seconds have passed.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the daemon")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
@@ -1,3 +1,4 @@
+// Package editor edits journals and pages in a built-in terminal outliner.
 package editor
 
 import (
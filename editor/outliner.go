@@ -0,0 +1,244 @@
+package editor
+
+import (
+	"strings"
+	"time"
+
+	"github.com/jrswab/lsq/outline"
+	"github.com/jrswab/lsq/store"
+	"github.com/jrswab/lsq/todo"
+)
+
+// Document is a page being edited in the outliner. Its blocks are kept in
+// document order with their depth, which makes moving a block together with
+// its children a matter of changing the depth of a range of blocks.
+type Document struct {
+	path     string
+	org      bool
+	preamble []string
+	blocks   []*outline.Block
+	levels   []int
+
+	// saved is the content of the file when it was read or last saved.
+	saved string
+}
+
+// Open reads the page at path. A missing file is an empty page.
+func Open(path string) (*Document, error) {
+	data, err := store.Read(path)
+	if err != nil {
+		return nil, err
+	}
+	return parse(path, string(data)), nil
+}
+
+func parse(path, content string) *Document {
+	page := outline.ParseFile(path, content)
+	d := &Document{path: path, org: outline.IsOrg(path), preamble: page.Preamble, saved: content}
+	page.Walk(func(b *outline.Block) bool {
+		d.blocks = append(d.blocks, b)
+		d.levels = append(d.levels, b.Level())
+		return true
+	})
+	return d
+}
+
+// Len returns the number of blocks.
+func (d *Document) Len() int {
+	return len(d.blocks)
+}
+
+// Level returns the depth of block i, starting at zero for top level blocks.
+func (d *Document) Level(i int) int {
+	return d.levels[i]
+}
+
+// Content returns the text of block i without its bullet.
+func (d *Document) Content(i int) string {
+	return d.blocks[i].Content()
+}
+
+// Body returns the lines below the bullet of block i.
+func (d *Document) Body(i int) []string {
+	return d.blocks[i].Body()
+}
+
+// SetContent replaces the text of block i.
+func (d *Document) SetContent(i int, content string) {
+	d.blocks[i].SetContent(content)
+}
+
+// end returns the index after the last descendant of block i.
+func (d *Document) end(i int) int {
+	j := i + 1
+	for j < len(d.blocks) && d.levels[j] > d.levels[i] {
+		j++
+	}
+	return j
+}
+
+// HasChildren reports whether block i has nested blocks.
+func (d *Document) HasChildren(i int) bool {
+	return d.end(i) > i+1
+}
+
+// BlockAt returns the block holding the one-based line of the file, or the
+// first block when the line is above the blocks.
+func (d *Document) BlockAt(line int) int {
+	n := len(d.preamble)
+	for i, b := range d.blocks {
+		n += len(b.Lines)
+		if line <= n {
+			return i
+		}
+	}
+	return max(len(d.blocks)-1, 0)
+}
+
+// Collapsed reports whether the children of block i are hidden.
+func (d *Document) Collapsed(i int) bool {
+	value, ok := d.blocks[i].Property("collapsed")
+	return ok && strings.EqualFold(value, "true")
+}
+
+// Toggle collapses or expands the children of block i the way Logseq
+// does, with a "collapsed:: true" property.
+func (d *Document) Toggle(i int) {
+	switch {
+	case d.Collapsed(i):
+		d.blocks[i].RemoveProperty("collapsed")
+	case d.HasChildren(i):
+		d.blocks[i].SetProperty("collapsed", "true")
+	}
+}
+
+// Visible returns the indexes of the blocks not hidden by a collapsed parent.
+func (d *Document) Visible() []int {
+	var visible []int
+	for i := 0; i < len(d.blocks); i++ {
+		visible = append(visible, i)
+		if d.Collapsed(i) {
+			i = d.end(i) - 1
+		}
+	}
+	return visible
+}
+
+// Indent nests block i and its children under the block above it. It
+// reports false when there is no block above to nest under.
+func (d *Document) Indent(i int) bool {
+	if i == 0 || d.levels[i-1] < d.levels[i] {
+		return false
+	}
+	d.shift(i, 1)
+	return true
+}
+
+// Outdent moves block i and its children one level up.
+func (d *Document) Outdent(i int) bool {
+	if d.levels[i] == 0 {
+		return false
+	}
+	d.shift(i, -1)
+	return true
+}
+
+// shift changes the depth of block i and its descendants by delta.
+func (d *Document) shift(i, delta int) {
+	end := d.end(i)
+	for j := i; j < end; j++ {
+		d.levels[j] += delta
+		d.setLevel(d.blocks[j], d.levels[j])
+	}
+}
+
+// setLevel rewrites the indentation of a block for the given depth.
+// Markdown bullets are indented with one tab per level and Org headings
+// get one star per level.
+func (d *Document) setLevel(b *outline.Block, level int) {
+	if d.org {
+		b.Lines[0] = strings.Repeat("*", level+1) + strings.TrimLeft(b.Lines[0], "*")
+		return
+	}
+
+	indent := strings.Repeat("\t", level)
+	for i, line := range b.Lines {
+		if line != "" && strings.HasPrefix(line, b.Indent) {
+			b.Lines[i] = indent + strings.TrimPrefix(line, b.Indent)
+		}
+	}
+	b.Indent = indent
+}
+
+// Insert adds an empty block at index i with the given depth and returns i.
+func (d *Document) Insert(i, level int) int {
+	b := outline.ParseFile(d.path, outline.Bullet(d.org, level, "")).Blocks[0]
+	d.blocks = append(d.blocks[:i], append([]*outline.Block{b}, d.blocks[i:]...)...)
+	d.levels = append(d.levels[:i], append([]int{level}, d.levels[i:]...)...)
+	return i
+}
+
+// InsertAfter adds an empty sibling below block i and its children.
+func (d *Document) InsertAfter(i int) int {
+	if len(d.blocks) == 0 {
+		return d.Insert(0, 0)
+	}
+	return d.Insert(d.end(i), d.levels[i])
+}
+
+// Delete removes block i together with its children.
+func (d *Document) Delete(i int) {
+	end := d.end(i)
+	d.blocks = append(d.blocks[:i], d.blocks[end:]...)
+	d.levels = append(d.levels[:i], d.levels[end:]...)
+}
+
+// CycleState moves block i to its next TODO state, clocking time and
+// advancing repeating tasks like "lsq -t" does.
+func (d *Document) CycleState(i int, now time.Time) {
+	d.blocks[i].Lines = todo.CycleBlock(d.blocks[i].Lines, now)
+}
+
+// CyclePriority moves the task of block i to its next priority.
+func (d *Document) CyclePriority(i int) {
+	d.blocks[i].Lines[0] = todo.CyclePriority(d.blocks[i].Lines[0])
+}
+
+// String renders the page in Logseq format.
+func (d *Document) String() string {
+	lines := append([]string(nil), d.preamble...)
+	for _, b := range d.blocks {
+		lines = append(lines, b.Lines...)
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+
+	out := strings.Join(lines, "\n")
+	// Keep a file without a final newline the way it was.
+	if d.saved == "" || strings.HasSuffix(d.saved, "\n") {
+		out += "\n"
+	}
+	return out
+}
+
+// Modified reports whether the page differs from the file.
+func (d *Document) Modified() bool {
+	return d.String() != d.saved
+}
+
+// Save writes the page back to its file. It fails with store.ErrChanged
+// when the file was changed by another program since it was read.
+func (d *Document) Save() error {
+	content := d.String()
+	if content == d.saved {
+		return nil
+	}
+
+	// A missing file matches empty content, so new journals are created.
+	if err := store.Replace(d.path, []byte(d.saved), []byte(content)); err != nil {
+		return err
+	}
+	d.saved = content
+	return nil
+}
@@ -0,0 +1,243 @@
+package editor
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/jrswab/lsq/store"
+)
+
+func TestDocument(t *testing.T) {
+	now := time.Date(2025, 1, 6, 9, 30, 0, 0, time.Local)
+
+	tests := map[string]struct {
+		file     string
+		input    string
+		edit     func(d *Document)
+		expected string
+	}{
+		"indent with children": {
+			file:     "page.md",
+			input:    "- a\n- b\n  text\n\t- c\n",
+			edit:     func(d *Document) { d.Indent(1) },
+			expected: "- a\n\t- b\n\t  text\n\t\t- c\n",
+		},
+		"indent first block": {
+			file:     "page.md",
+			input:    "- a\n",
+			edit:     func(d *Document) { d.Indent(0) },
+			expected: "- a\n",
+		},
+		"outdent with children": {
+			file:     "page.md",
+			input:    "- a\n    - b\n        - c\n",
+			edit:     func(d *Document) { d.Outdent(1) },
+			expected: "- a\n- b\n\t- c\n",
+		},
+		"indent org heading": {
+			file:     "page.org",
+			input:    "* a\n* b\ntext\n** c\n",
+			edit:     func(d *Document) { d.Indent(1) },
+			expected: "* a\n** b\ntext\n*** c\n",
+		},
+		"collapse": {
+			file:     "page.md",
+			input:    "- a\n\t- b\n",
+			edit:     func(d *Document) { d.Toggle(0) },
+			expected: "- a\n  collapsed:: true\n\t- b\n",
+		},
+		"expand": {
+			file:     "page.md",
+			input:    "- a\n  collapsed:: true\n\t- b\n",
+			edit:     func(d *Document) { d.Toggle(0) },
+			expected: "- a\n\t- b\n",
+		},
+		"collapse without children": {
+			file:     "page.md",
+			input:    "- a\n",
+			edit:     func(d *Document) { d.Toggle(0) },
+			expected: "- a\n",
+		},
+		"insert after children": {
+			file:  "page.md",
+			input: "- a\n\t- b\n- c\n",
+			edit: func(d *Document) {
+				d.SetContent(d.InsertAfter(0), "new")
+			},
+			expected: "- a\n\t- b\n- new\n- c\n",
+		},
+		"insert org heading": {
+			file:  "page.org",
+			input: "* a\n** b\n",
+			edit: func(d *Document) {
+				d.SetContent(d.InsertAfter(1), "new")
+			},
+			expected: "* a\n** b\n** new\n",
+		},
+		"delete with children": {
+			file:     "page.md",
+			input:    "- a\n\t- b\n- c\n",
+			edit:     func(d *Document) { d.Delete(0) },
+			expected: "- c\n",
+		},
+		"cycle state and priority": {
+			file:  "page.md",
+			input: "title:: x\n\n- task\n",
+			edit: func(d *Document) {
+				d.CycleState(0, now)
+				d.CyclePriority(0)
+			},
+			expected: "title:: x\n\n- TODO [#A] task\n",
+		},
+		"keep missing final newline": {
+			file:     "page.md",
+			input:    "- a",
+			edit:     func(d *Document) { d.SetContent(0, "b") },
+			expected: "- b",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			d := parse(tt.file, tt.input)
+			tt.edit(d)
+
+			if got := d.String(); got != tt.expected {
+				t.Errorf("String() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestVisible(t *testing.T) {
+	d := parse("page.md", "- a\n  collapsed:: true\n\t- b\n\t\t- c\n- d\n\t- e\n")
+
+	if got, want := d.Visible(), []int{0, 3, 4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Visible() = %v, want %v", got, want)
+	}
+}
+
+func TestBlockAt(t *testing.T) {
+	d := parse("page.md", "title:: Page\n\n- a\n  text\n\t- b\n- c\n")
+
+	tests := map[int]int{1: 0, 3: 0, 4: 0, 5: 1, 6: 2, 9: 2}
+	for line, want := range tests {
+		if got := d.BlockAt(line); got != want {
+			t.Errorf("BlockAt(%d) = %d, want %d", line, got, want)
+		}
+	}
+}
+
+func TestSave(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "2025_01_06.md")
+
+	// A new journal is created on save.
+	d, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.SetContent(d.InsertAfter(0), "first")
+	if err := d.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if d.Modified() {
+		t.Error("Modified() = true after Save()")
+	}
+
+	// Another program appends while the page is open.
+	if err := os.WriteFile(path, []byte("- first\n- synced\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	d.SetContent(0, "changed")
+	if err := d.Save(); !errors.Is(err, store.ErrChanged) {
+		t.Errorf("Save() error = %v, want ErrChanged", err)
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "- first\n- synced\n" {
+		t.Errorf("content = %q, want the other change kept", got)
+	}
+}
+
+func TestModelKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "page.md")
+	if err := os.WriteFile(path, []byte("- a\n- b\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var m tea.Model = newModel(d)
+	press := func(keys ...string) {
+		for _, key := range keys {
+			var msg tea.KeyMsg
+			switch key {
+			case "enter":
+				msg = tea.KeyMsg{Type: tea.KeyEnter}
+			case "esc":
+				msg = tea.KeyMsg{Type: tea.KeyEsc}
+			case "tab":
+				msg = tea.KeyMsg{Type: tea.KeyTab}
+			default:
+				msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+			}
+			m, _ = m.Update(msg)
+		}
+	}
+
+	// Move to b, add a child below it, type into it, add a new block, leave
+	// it empty and save.
+	press("j", "o", "tab", "c", "enter", "esc", "t", "w")
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "- a\n- b\n\t- TODO c\n"; string(got) != want {
+		t.Errorf("saved = %q, want %q", got, want)
+	}
+
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")})
+	if cmd == nil {
+		t.Error("q did not quit after saving")
+	}
+
+	// Deleting the only block of a page, or the block holding all others,
+	// is refused.
+	for name, content := range map[string]string{
+		"only block":           "- only\n",
+		"parent of all others": "- parent\n\t- child\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "page.md")
+			if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			d, err := Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var m tea.Model = newModel(d)
+			m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("d")})
+			if !strings.Contains(m.View(), "Cannot delete") {
+				t.Errorf("View() after d = %q, want the refusal in the status", m.View())
+			}
+			if got := d.String(); got != content {
+				t.Errorf("page after d = %q, want %q", got, content)
+			}
+		})
+	}
+}
@@ -0,0 +1,391 @@
+package editor
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/jrswab/lsq/outline"
+)
+
+// Builtin is the editor name that opens the built-in outliner instead of
+// an external program.
+const Builtin = "lsq"
+
+const help = "j/k move  enter edit  o/O new  tab/S-tab indent  space fold  t todo  p priority  d delete  w save  q quit"
+
+var (
+	cursorStyle = lipgloss.NewStyle().Reverse(true)
+	dimStyle    = lipgloss.NewStyle().Faint(true)
+	titleStyle  = lipgloss.NewStyle().Bold(true)
+)
+
+// Run edits the journal or page at path in the outliner until the user quits.
+// The cursor starts on the block at the one-based line, or the first block
+// when line is zero.
+func Run(path string, line int) error {
+	doc, err := Open(path)
+	if err != nil {
+		return err
+	}
+
+	m := newModel(doc)
+	if line > 0 && !m.editing {
+		m.cursor = m.shown(doc.BlockAt(line))
+	}
+	_, err = tea.NewProgram(m, tea.WithAltScreen()).Run()
+	return err
+}
+
+type model struct {
+	doc    *Document
+	cursor int
+	// offset is the first visible block drawn at the top of the screen.
+	offset int
+
+	input   textinput.Model
+	editing bool
+	// fresh is set while editing a block created by o, O or enter.
+	fresh bool
+
+	status string
+	// quitting is set after q was pressed with unsaved changes.
+	quitting bool
+
+	width, height int
+	now           func() time.Time
+}
+
+func newModel(doc *Document) model {
+	input := textinput.New()
+	input.Prompt = ""
+
+	m := model{doc: doc, input: input, now: time.Now, height: 24, width: 80}
+	if doc.Len() == 0 {
+		// Start typing straight away in a new journal.
+		m.cursor = doc.InsertAfter(0)
+		m = m.edit(true)
+	}
+	return m
+}
+
+func (m model) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.input.Width = max(msg.Width-2, 1)
+		return m, nil
+	case tea.KeyMsg:
+		if m.editing {
+			return m.updateEditing(msg)
+		}
+		return m.updateNavigating(msg)
+	}
+	return m, nil
+}
+
+// updateEditing handles the keys typed into a block.
+func (m model) updateEditing(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter":
+		// Like Logseq, enter starts the next block.
+		m = m.commit()
+		m.cursor = m.doc.InsertAfter(m.cursor)
+		return m.edit(true), nil
+	case "esc":
+		return m.leave(), nil
+	case "tab":
+		m.doc.Indent(m.cursor)
+		return m, nil
+	case "shift+tab":
+		m.doc.Outdent(m.cursor)
+		return m, nil
+	case "ctrl+s":
+		m = m.commit()
+		return m.save(), nil
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+// updateNavigating handles the keys that move between and change blocks.
+func (m model) updateNavigating(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	key := msg.String()
+	if key != "q" && key != "ctrl+c" {
+		m.quitting = false
+	}
+	m.status = ""
+
+	visible := m.doc.Visible()
+	pos := indexOf(visible, m.cursor)
+
+	switch key {
+	case "q", "ctrl+c":
+		if m.doc.Modified() && !m.quitting {
+			m.quitting = true
+			m.status = "Unsaved changes: press w to save or q again to quit without saving."
+			return m, nil
+		}
+		return m, tea.Quit
+	case "up", "k":
+		if pos > 0 {
+			m.cursor = visible[pos-1]
+		}
+	case "down", "j":
+		if pos >= 0 && pos < len(visible)-1 {
+			m.cursor = visible[pos+1]
+		}
+	case "g", "home":
+		if len(visible) > 0 {
+			m.cursor = visible[0]
+		}
+	case "G", "end":
+		if len(visible) > 0 {
+			m.cursor = visible[len(visible)-1]
+		}
+	case "enter", "i":
+		if m.doc.Len() > 0 {
+			return m.edit(false), nil
+		}
+	case "o":
+		m.cursor = m.doc.InsertAfter(m.cursor)
+		return m.edit(true), nil
+	case "O":
+		level := 0
+		if m.doc.Len() > 0 {
+			level = m.doc.Level(m.cursor)
+		}
+		m.cursor = m.doc.Insert(m.cursor, level)
+		return m.edit(true), nil
+	}
+
+	if m.doc.Len() == 0 {
+		return m, nil
+	}
+
+	switch key {
+	case "tab":
+		if !m.doc.Indent(m.cursor) {
+			m.status = "Nothing above to indent under."
+		}
+	case "shift+tab":
+		m.doc.Outdent(m.cursor)
+	case " ", "z":
+		m.doc.Toggle(m.cursor)
+	case "t":
+		m.doc.CycleState(m.cursor, m.now())
+	case "p":
+		m.doc.CyclePriority(m.cursor)
+	case "d":
+		// A page keeps at least one block, like leaving an empty new block.
+		if m.cursor == 0 && m.doc.end(m.cursor) == m.doc.Len() {
+			m.status = "Cannot delete the only block of the page."
+			break
+		}
+		m.doc.Delete(m.cursor)
+		m.cursor = m.nearest(pos)
+	case "w", "ctrl+s":
+		return m.save(), nil
+	}
+	return m, nil
+}
+
+// shown returns block i, or the collapsed block hiding it.
+func (m model) shown(i int) int {
+	visible := m.doc.Visible()
+	for pos := len(visible) - 1; pos >= 0; pos-- {
+		if visible[pos] <= i {
+			return visible[pos]
+		}
+	}
+	return 0
+}
+
+// nearest returns the block shown at position pos of the visible blocks
+// after a change, or the last one when pos is past the end.
+func (m model) nearest(pos int) int {
+	visible := m.doc.Visible()
+	if len(visible) == 0 {
+		return 0
+	}
+	return visible[min(max(pos, 0), len(visible)-1)]
+}
+
+// edit starts editing the block under the cursor.
+func (m model) edit(fresh bool) model {
+	m.editing = true
+	m.fresh = fresh
+	m.input.SetValue(m.doc.Content(m.cursor))
+	m.input.CursorEnd()
+	m.input.Focus()
+	return m
+}
+
+// commit writes the text being edited to the block.
+func (m model) commit() model {
+	if m.input.Value() != m.doc.Content(m.cursor) {
+		m.doc.SetContent(m.cursor, m.input.Value())
+	}
+	return m
+}
+
+// leave stops editing. A new block left empty is dropped again.
+func (m model) leave() model {
+	m = m.commit()
+	m.editing = false
+	m.input.Blur()
+
+	if m.fresh && strings.TrimSpace(m.input.Value()) == "" && !m.doc.HasChildren(m.cursor) && m.doc.Len() > 1 {
+		pos := indexOf(m.doc.Visible(), m.cursor)
+		m.doc.Delete(m.cursor)
+		m.cursor = m.nearest(pos - 1)
+	}
+	m.fresh = false
+	return m
+}
+
+func (m model) save() model {
+	if err := m.doc.Save(); err != nil {
+		m.status = fmt.Sprintf("Error saving: %v", err)
+		return m
+	}
+	m.quitting = false
+	m.status = "Saved " + filepath.Base(m.doc.path)
+	return m
+}
+
+func (m model) View() string {
+	title := filepath.Base(m.doc.path)
+	if m.doc.Modified() {
+		title += " [+]"
+	}
+
+	// Two lines are taken by the title and the status line.
+	height := max(m.height-2, 1)
+	rows := m.rows(height)
+
+	var sb strings.Builder
+	sb.WriteString(titleStyle.Render(title) + "\n")
+	for _, row := range rows {
+		sb.WriteString(row + "\n")
+	}
+	for i := len(rows); i < height; i++ {
+		sb.WriteString("\n")
+	}
+
+	status := m.status
+	if status == "" {
+		status = help
+	}
+	sb.WriteString(dimStyle.Render(truncate(status, m.width)))
+	return sb.String()
+}
+
+// rows renders the visible blocks that fit in height lines, scrolling so
+// that the cursor stays on screen.
+func (m *model) rows(height int) []string {
+	visible := m.doc.Visible()
+	if len(visible) == 0 {
+		return nil
+	}
+	pos := max(indexOf(visible, m.cursor), 0)
+
+	if pos < m.offset {
+		m.offset = pos
+	}
+	for {
+		lines := 0
+		for _, i := range visible[m.offset : pos+1] {
+			lines += len(m.block(i))
+		}
+		if lines <= height || m.offset == pos {
+			break
+		}
+		m.offset++
+	}
+
+	var rows []string
+	for _, i := range visible[min(m.offset, len(visible)):] {
+		for _, row := range m.block(i) {
+			if len(rows) == height {
+				return rows
+			}
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+// block renders block i: its bullet line followed by the text below it,
+// leaving out properties and drawers the way Logseq does.
+func (m *model) block(i int) []string {
+	indent := strings.Repeat("  ", m.doc.Level(i))
+
+	bullet := "•"
+	if m.doc.Collapsed(i) {
+		bullet = "▸"
+	}
+
+	text := m.doc.Content(i)
+	if m.editing && i == m.cursor {
+		return []string{indent + bullet + " " + m.input.View()}
+	}
+
+	line := truncate(indent+bullet+" "+text, m.width)
+	if i == m.cursor {
+		line = cursorStyle.Render(line)
+	}
+
+	rows := []string{line}
+	for _, body := range bodyText(m.doc.Body(i)) {
+		rows = append(rows, dimStyle.Render(truncate(indent+"  "+body, m.width)))
+	}
+	return rows
+}
+
+// bodyText drops the property lines and drawers of a block body.
+func bodyText(body []string) []string {
+	var (
+		text   []string
+		drawer bool
+	)
+	for _, line := range body {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case drawer:
+			drawer = !strings.EqualFold(trimmed, ":END:")
+		case strings.HasPrefix(trimmed, ":") && strings.HasSuffix(trimmed, ":") && len(trimmed) > 1:
+			drawer = true
+		case outline.IsProperty(trimmed), trimmed == "":
+		default:
+			text = append(text, trimmed)
+		}
+	}
+	return text
+}
+
+func truncate(s string, width int) string {
+	if width <= 0 {
+		return s
+	}
+	return lipgloss.NewStyle().MaxWidth(width).Render(s)
+}
+
+func indexOf(visible []int, i int) int {
+	for pos, v := range visible {
+		if v == i {
+			return pos
+		}
+	}
+	return -1
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/jrswab/lsq/export"
+	"github.com/jrswab/lsq/graph"
+	"github.com/jrswab/lsq/render"
+)
+
+const exportUsage = "usage: lsq export html|md [-o path] [-p page | -s yyyy-MM-dd] [-namespace ns] [-tag tag] [-property key=value]"
+
+func runExport(args []string) error {
+	if len(args) == 0 || args[0] != "html" && args[0] != "md" {
+		return fmt.Errorf(exportUsage)
+	}
+	format := args[0]
+
+	fs, dir := newFlagSet("export " + format)
+	out := fs.String("o", "", "Where to write the export. Markdown of a single page is printed to STDOUT when omitted.")
+	page := fs.String("p", "", "Only export this page. Must be a file name with extension.")
+	specDate := fs.String("s", "", "Only export this journal. Use yyyy-MM-dd after the flag.")
+	namespace := fs.String("namespace", "", "Only export pages within this namespace.")
+	tag := fs.String("tag", "", "Only export pages tagged with this tag.")
+	property := fs.String("property", "", "Only export pages with this page property. Use key=value.")
+	depth := fs.Int("depth", 2, "Outline levels flattened into headings and paragraphs. Markdown only.")
+	links := fs.String("links", export.LinkText, "Rewrite [[links]] as \"text\" or \"relative\" Markdown links. Markdown only.")
+	fs.Parse(args[1:])
+
+	single := *page != "" || *specDate != ""
+	if *out == "" && (format == "html" || !single) {
+		return fmt.Errorf(exportUsage)
+	}
+
+	if *links != export.LinkText && *links != export.LinkRelative {
+		return fmt.Errorf("-links must be %q or %q", export.LinkText, export.LinkRelative)
+	}
+
+	cfg, err := loadConfig(*dir)
+	if err != nil {
+		return err
+	}
+
+	files, err := graph.All(cfg)
+	if err != nil {
+		return fmt.Errorf("error listing graph files: %v", err)
+	}
+
+	idx, err := render.NewIndex(files)
+	if err != nil {
+		return fmt.Errorf("error indexing graph: %v", err)
+	}
+
+	selected := export.Select(files, idx, export.Filter{
+		Namespace: *namespace,
+		Tag:       *tag,
+		Property:  *property,
+	})
+
+	if single {
+		path := filepath.Join(cfg.PagesDir, *page)
+		if *page == "" {
+			if path, err = journalPath(cfg, *specDate); err != nil {
+				return err
+			}
+		}
+
+		selected = nil
+		for _, f := range files {
+			if f.Path == path {
+				selected = append(selected, f)
+			}
+		}
+		if len(selected) == 0 {
+			return fmt.Errorf("could not find %s", path)
+		}
+	}
+
+	opts := export.MarkdownOptions{Depth: *depth, Links: *links}
+
+	switch {
+	case format == "html":
+		err = export.HTML(selected, idx, *out)
+	case single && *out == "":
+		_, err = os.Stdout.WriteString(export.Markdown(selected[0], idx, opts))
+		return err
+	case single:
+		err = os.WriteFile(*out, []byte(export.Markdown(selected[0], idx, opts)), 0644)
+	default:
+		err = export.WriteMarkdown(selected, idx, *out, opts)
+	}
+
+	if err != nil {
+		return fmt.Errorf("error exporting %s: %v", format, err)
+	}
+
+	fmt.Printf("Exported %d page(s) to %s\n", len(selected), *out)
+	return nil
+}
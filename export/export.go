@@ -0,0 +1,105 @@
+// Package export converts Logseq pages into documents for other tools.
+package export
+
+import (
+	"strings"
+
+	"github.com/jrswab/lsq/graph"
+	"github.com/jrswab/lsq/outline"
+	"github.com/jrswab/lsq/render"
+)
+
+// Filter selects the pages to export. Empty fields match every page.
+type Filter struct {
+	// Namespace matches the page itself and every page below it, e.g. "team" matches "team/onboarding".
+	Namespace string
+	// Tag matches pages listing the tag in their "tags::" page property.
+	Tag string
+	// Property matches pages whose page property has the value, given as "key=value".
+	Property string
+}
+
+// Select returns the files matching the filter. Journals are only
+// included when no namespace is given since they have no namespace.
+func Select(files []graph.File, idx *render.Index, filter Filter) []graph.File {
+	var selected []graph.File
+	for _, f := range files {
+		if filter.Namespace != "" {
+			ns := strings.ToLower(strings.Trim(filter.Namespace, "/"))
+			name := strings.ToLower(f.Name)
+			if f.Journal || name != ns && !strings.HasPrefix(name, ns+"/") {
+				continue
+			}
+		}
+
+		page, ok := idx.Page(f.Path)
+		if !ok {
+			continue
+		}
+
+		props := PageProperties(page, outline.IsOrg(f.Path))
+		if filter.Tag != "" && !hasValue(props, "tags", filter.Tag) {
+			continue
+		}
+
+		if filter.Property != "" {
+			key, value, _ := strings.Cut(filter.Property, "=")
+			if !hasValue(props, key, value) {
+				continue
+			}
+		}
+
+		selected = append(selected, f)
+	}
+	return selected
+}
+
+// PageProperties returns the "key:: value" pairs of the page preamble, or
+// its "#+key: value" lines in Org pages. Logseq also treats the properties
+// of a leading block as page properties when a page has no preamble, so
+// those are used as a fallback.
+func PageProperties(page *outline.Page, org bool) [][2]string {
+	var props [][2]string
+	for _, line := range page.Preamble {
+		if key, value, ok := outline.ParsePageProperty(org, line); ok {
+			props = append(props, [2]string{key, value})
+		}
+	}
+
+	if len(props) == 0 && len(page.Blocks) > 0 && outline.IsProperty(page.Blocks[0].Content()) {
+		first := page.Blocks[0]
+		if key, value, ok := outline.ParseProperty(first.Content()); ok {
+			props = append(props, [2]string{key, value})
+		}
+		props = append(props, first.Properties()...)
+	}
+
+	return props
+}
+
+// hasValue reports whether the property key lists value. A blank value matches any.
+func hasValue(props [][2]string, key, value string) bool {
+	for _, prop := range props {
+		if !strings.EqualFold(prop[0], key) {
+			continue
+		}
+		if value == "" {
+			return true
+		}
+		for _, v := range outline.PropertyValues(prop[1]) {
+			if strings.EqualFold(v, value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isHidden reports whether a property is internal to Logseq and should not be exported.
+func isHidden(key string) bool {
+	switch strings.ToLower(key) {
+	case "id", "collapsed", "heading":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,100 @@
+package export_test
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/jrswab/lsq/export"
+	"github.com/jrswab/lsq/graph"
+	"github.com/jrswab/lsq/render"
+)
+
+// setup writes the files into a temporary graph and indexes them.
+func setup(t *testing.T, files map[string]string) ([]graph.File, *render.Index) {
+	t.Helper()
+
+	dir := t.TempDir()
+	var out []graph.File
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		f := graph.File{Path: path, Name: graph.PageName(filepath.Base(name))}
+		if filepath.Dir(name) == "journals" {
+			f.Journal = true
+			f.Date, _ = time.Parse("2006_01_02", f.Name)
+		}
+		out = append(out, f)
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i].Path < out[j].Path })
+
+	idx, err := render.NewIndex(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out, idx
+}
+
+func TestSelect(t *testing.T) {
+	files, idx := setup(t, map[string]string{
+		"journals/2025_01_01.md":    "- day\n",
+		"pages/team.md":             "tags:: handbook\n\n- root\n",
+		"pages/team___onboard.md":   "tags:: [[handbook]], people\nstatus:: published\n\n- welcome\n",
+		"pages/teamwork.md":         "- not in namespace\n",
+		"pages/recipes.md":          "status:: draft\n\n- soup\n",
+		"pages/block props page.md": "- tags:: handbook\n  status:: draft\n",
+	})
+
+	tests := map[string]struct {
+		filter   export.Filter
+		expected []string
+	}{
+		"everything": {
+			expected: []string{"2025_01_01", "block props page", "recipes", "team", "team/onboard", "teamwork"},
+		},
+		"namespace": {
+			filter:   export.Filter{Namespace: "Team"},
+			expected: []string{"team", "team/onboard"},
+		},
+		"tag": {
+			filter:   export.Filter{Tag: "handbook"},
+			expected: []string{"block props page", "team", "team/onboard"},
+		},
+		"property value": {
+			filter:   export.Filter{Property: "status=draft"},
+			expected: []string{"block props page", "recipes"},
+		},
+		"property present": {
+			filter:   export.Filter{Property: "status"},
+			expected: []string{"block props page", "recipes", "team/onboard"},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var got []string
+			for _, f := range export.Select(files, idx, tt.filter) {
+				got = append(got, f.Name)
+			}
+			sort.Strings(got)
+
+			if len(got) != len(tt.expected) {
+				t.Fatalf("Select() = %q, want %q", got, tt.expected)
+			}
+			for i := range got {
+				if got[i] != tt.expected[i] {
+					t.Errorf("Select() = %q, want %q", got, tt.expected)
+				}
+			}
+		})
+	}
+}
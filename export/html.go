@@ -0,0 +1,424 @@
+package export
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jrswab/lsq/graph"
+	"github.com/jrswab/lsq/outline"
+	"github.com/jrswab/lsq/render"
+	"github.com/jrswab/lsq/todo"
+)
+
+var (
+	labelPageRe = regexp.MustCompile(`\[([^\[\]]+)\]\(\[\[([^\[\]]+)\]\]\)`)
+	mdLinkRe    = regexp.MustCompile(`\[([^\[\]]+)\]\(([^()\s]+)\)`)
+	tagPageRe   = regexp.MustCompile(`#\[\[([^\[\]]+)\]\]`)
+	pageRe      = regexp.MustCompile(`\[\[([^\[\]]+)\]\]`)
+	hashTagRe   = regexp.MustCompile(`(^|\s)#([^\s#\[\],!?;:"'&<>]+)`)
+	boldRe      = regexp.MustCompile(`\*\*([^*]+)\*\*`)
+	codeRe      = regexp.MustCompile("`([^`]+)`")
+	priorityRe  = regexp.MustCompile(`^\[#([ABC])\] `)
+	pageEmbedRe = regexp.MustCompile(`^\{\{embed \[\[([^\[\]]+)\]\]\}\}$`)
+)
+
+// maxEmbedDepth stops pages that embed each other from recursing forever.
+const maxEmbedDepth = 3
+
+// site holds what is needed to render links between the exported pages.
+type site struct {
+	// targets maps lower case page names and aliases to exported files.
+	targets   map[string]graph.File
+	pages     map[string]*outline.Page
+	backlinks map[string][]backlink
+	depth     int
+}
+
+type backlink struct {
+	Href  string
+	Title string
+	Text  template.HTML
+}
+
+type htmlPage struct {
+	Title      string
+	CSS        template.CSS
+	Properties template.HTML
+	Body       template.HTML
+	Backlinks  []backlink
+}
+
+type htmlIndex struct {
+	Title    string
+	CSS      template.CSS
+	Pages    []backlink
+	Journals []backlink
+}
+
+// HTML writes one HTML file per exported file, with links between
+// them, backlinks sections and an index.html into dir.
+func HTML(files []graph.File, idx *render.Index, dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	s := &site{
+		targets:   make(map[string]graph.File, len(files)),
+		pages:     make(map[string]*outline.Page, len(files)),
+		backlinks: make(map[string][]backlink),
+	}
+
+	for _, f := range files {
+		page, ok := idx.Page(f.Path)
+		if !ok {
+			continue
+		}
+
+		// Work on a copy so resolving references leaves the index untouched.
+		page = outline.ParseFile(f.Path, page.String())
+		render.ResolveRefs(page, idx, false)
+		s.pages[f.Path] = page
+
+		s.targets[strings.ToLower(f.Name)] = f
+		for _, prop := range PageProperties(page, outline.IsOrg(f.Path)) {
+			if strings.EqualFold(prop[0], "alias") {
+				for _, alias := range outline.PropertyValues(prop[1]) {
+					if _, exists := s.targets[strings.ToLower(alias)]; !exists {
+						s.targets[strings.ToLower(alias)] = f
+					}
+				}
+			}
+		}
+	}
+
+	for _, f := range files {
+		page, ok := s.pages[f.Path]
+		if !ok {
+			continue
+		}
+		s.collectBacklinks(f, page)
+	}
+
+	var index htmlIndex
+	index.Title = "Index"
+	index.CSS = template.CSS(stylesheet)
+
+	for _, f := range files {
+		page, ok := s.pages[f.Path]
+		if !ok {
+			continue
+		}
+
+		out := htmlPage{
+			Title:      f.Name,
+			CSS:        template.CSS(stylesheet),
+			Properties: s.properties(PageProperties(page, outline.IsOrg(f.Path))),
+			Body:       s.blocks(page.Blocks),
+			Backlinks:  s.backlinks[f.Path],
+		}
+
+		path := filepath.Join(dir, htmlName(f))
+		if err := writeTemplate(path, pageTemplate, out); err != nil {
+			return fmt.Errorf("error writing %s: %w", path, err)
+		}
+
+		entry := backlink{Href: htmlHref(f), Title: f.Name}
+		if f.Journal {
+			index.Journals = append(index.Journals, entry)
+		} else {
+			index.Pages = append(index.Pages, entry)
+		}
+	}
+
+	sort.Slice(index.Pages, func(i, j int) bool {
+		return strings.ToLower(index.Pages[i].Title) < strings.ToLower(index.Pages[j].Title)
+	})
+	sort.Slice(index.Journals, func(i, j int) bool {
+		return index.Journals[i].Title > index.Journals[j].Title
+	})
+
+	return writeTemplate(filepath.Join(dir, "index.html"), indexTemplate, index)
+}
+
+// collectBacklinks records every block of the page linking to another exported page.
+func (s *site) collectBacklinks(f graph.File, page *outline.Page) {
+	page.Walk(func(b *outline.Block) bool {
+		seen := map[string]bool{}
+		for _, link := range outline.Links(b.Content()) {
+			target, ok := s.targets[strings.ToLower(link)]
+			if !ok || target.Path == f.Path || seen[target.Path] {
+				continue
+			}
+			seen[target.Path] = true
+
+			s.backlinks[target.Path] = append(s.backlinks[target.Path], backlink{
+				Href:  htmlHref(f),
+				Title: f.Name,
+				Text:  s.inline(b.Content()),
+			})
+		}
+		return true
+	})
+}
+
+// blocks renders an outline level as a nested list.
+func (s *site) blocks(blocks []*outline.Block) template.HTML {
+	if len(blocks) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("<ul class=\"blocks\">\n")
+	for _, b := range blocks {
+		sb.WriteString("<li>")
+		if m := pageEmbedRe.FindStringSubmatch(b.Content()); m != nil {
+			sb.WriteString(string(s.embed(m[1])))
+		} else {
+			sb.WriteString(`<div class="block">` + string(s.content(b.Content())) + "</div>")
+		}
+
+		var (
+			props  [][2]string
+			drawer bool
+		)
+		for _, line := range b.Body() {
+			trimmed := strings.TrimSpace(line)
+			switch {
+			case drawer || isDrawer(trimmed):
+				drawer = trimmed != ":END:"
+			case outline.IsProperty(trimmed):
+				key, value, _ := outline.ParseProperty(trimmed)
+				props = append(props, [2]string{key, value})
+			case strings.HasPrefix(trimmed, "SCHEDULED:") || strings.HasPrefix(trimmed, "DEADLINE:"):
+				sb.WriteString(`<div class="planning">` + html.EscapeString(trimmed) + "</div>")
+			case trimmed != "":
+				sb.WriteString("<p>" + string(s.inline(trimmed)) + "</p>")
+			}
+		}
+
+		sb.WriteString(string(s.properties(props)))
+		sb.WriteString(string(s.blocks(b.Children)))
+		sb.WriteString("</li>\n")
+	}
+	sb.WriteString("</ul>\n")
+
+	return template.HTML(sb.String())
+}
+
+// embed renders the blocks of an embedded page below a link to it.
+func (s *site) embed(name string) template.HTML {
+	out := `<div class="block embed">` + string(s.inline("[["+name+"]]")) + "</div>"
+
+	f, ok := s.targets[strings.ToLower(name)]
+	if !ok || s.depth >= maxEmbedDepth {
+		return template.HTML(out)
+	}
+
+	s.depth++
+	defer func() { s.depth-- }()
+
+	return template.HTML(out) + s.blocks(s.pages[f.Path].Blocks)
+}
+
+// content renders the first line of a block including its task marker and priority.
+func (s *site) content(text string) template.HTML {
+	var prefix string
+	if marker := todo.Marker(text); marker != "" {
+		checked := ""
+		if marker == "DONE" {
+			checked = " checked"
+		}
+		prefix = fmt.Sprintf(`<input type="checkbox" disabled%s> <span class="marker %s">%s</span> `,
+			checked, strings.ToLower(marker), marker)
+		text = strings.TrimPrefix(strings.TrimPrefix(text, marker), " ")
+	}
+
+	if m := priorityRe.FindStringSubmatch(text); m != nil {
+		prefix += fmt.Sprintf(`<span class="priority">%s</span> `, m[1])
+		text = text[len(m[0]):]
+	}
+
+	return template.HTML(prefix) + s.inline(text)
+}
+
+// properties renders the visible properties as a definition list.
+func (s *site) properties(props [][2]string) template.HTML {
+	var sb strings.Builder
+	for _, prop := range props {
+		if isHidden(prop[0]) {
+			continue
+		}
+		sb.WriteString("<dt>" + html.EscapeString(prop[0]) + "</dt><dd>" + string(s.inline(propertyLinks(prop))) + "</dd>")
+	}
+
+	if sb.Len() == 0 {
+		return ""
+	}
+	return template.HTML(`<dl class="properties">` + sb.String() + "</dl>")
+}
+
+// propertyLinks turns the values of reference properties into page links.
+func propertyLinks(prop [2]string) string {
+	switch strings.ToLower(prop[0]) {
+	case "tags", "alias":
+		values := outline.PropertyValues(prop[1])
+		for i, v := range values {
+			values[i] = "[[" + v + "]]"
+		}
+		return strings.Join(values, ", ")
+	}
+	return prop[1]
+}
+
+// inline escapes text and renders links, tags, bold text and code spans.
+func (s *site) inline(text string) template.HTML {
+	text = html.EscapeString(text)
+
+	link := func(label, name string) string {
+		f, ok := s.targets[strings.ToLower(html.UnescapeString(name))]
+		if !ok {
+			return `<span class="page-ref">` + label + "</span>"
+		}
+		return `<a class="page-ref" href="` + htmlHref(f) + `">` + label + "</a>"
+	}
+
+	text = codeRe.ReplaceAllString(text, "<code>$1</code>")
+	text = labelPageRe.ReplaceAllStringFunc(text, func(match string) string {
+		m := labelPageRe.FindStringSubmatch(match)
+		return link(m[1], m[2])
+	})
+	text = mdLinkRe.ReplaceAllStringFunc(text, func(match string) string {
+		m := mdLinkRe.FindStringSubmatch(match)
+		if !safeURL(html.UnescapeString(m[2])) {
+			return m[1]
+		}
+		return `<a href="` + m[2] + `">` + m[1] + "</a>"
+	})
+	text = tagPageRe.ReplaceAllStringFunc(text, func(match string) string {
+		name := tagPageRe.FindStringSubmatch(match)[1]
+		return link("#"+name, name)
+	})
+	text = pageRe.ReplaceAllStringFunc(text, func(match string) string {
+		name := pageRe.FindStringSubmatch(match)[1]
+		return link(name, name)
+	})
+	text = hashTagRe.ReplaceAllStringFunc(text, func(match string) string {
+		m := hashTagRe.FindStringSubmatch(match)
+		return m[1] + link("#"+m[2], m[2])
+	})
+	text = boldRe.ReplaceAllString(text, "<strong>$1</strong>")
+
+	return template.HTML(text)
+}
+
+// safeURL reports whether a link may be published: relative URLs and the
+// http, https and mailto schemes. Others, such as javascript:, would run
+// in the reader's browser.
+func safeURL(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "", "http", "https", "mailto":
+		return true
+	}
+	return false
+}
+
+func isDrawer(line string) bool {
+	return len(line) > 2 && strings.HasPrefix(line, ":") && strings.HasSuffix(line, ":") &&
+		strings.ToUpper(line) == line && line != ":END:"
+}
+
+// htmlName is the file name of the exported page, based on the
+// Logseq file name so namespaces and encoded characters stay unique.
+func htmlName(f graph.File) string {
+	base := filepath.Base(f.Path)
+	return strings.TrimSuffix(base, filepath.Ext(base)) + ".html"
+}
+
+func htmlHref(f graph.File) string {
+	return url.PathEscape(htmlName(f))
+}
+
+func writeTemplate(path string, tmpl *template.Template, data any) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return tmpl.Execute(file, data)
+}
+
+const stylesheet = `body{font-family:system-ui,sans-serif;max-width:50rem;margin:2rem auto;padding:0 1rem;line-height:1.5;color:#222}
+a{color:#0b6e99}ul.blocks{padding-left:1.2rem}ul.blocks>li{margin:.2rem 0}
+.marker{font-size:.75em;font-weight:bold;padding:0 .3em;border-radius:3px;background:#eee}
+.marker.done{text-decoration:line-through;color:#777}.priority{font-size:.75em;color:#b00}
+.planning{font-size:.85em;color:#666}dl.properties{display:grid;grid-template-columns:max-content auto;gap:0 .8rem;font-size:.85em;color:#555}
+dl.properties dd{margin:0}.backlinks{margin-top:3rem;border-top:1px solid #ddd}code{background:#f4f4f4;padding:0 .2em}`
+
+var pageTemplate = template.Must(template.New("page").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>{{.CSS}}</style>
+</head>
+<body>
+<nav><a href="index.html">Index</a></nav>
+<h1>{{.Title}}</h1>
+{{.Properties}}
+{{.Body}}
+{{- if .Backlinks}}
+<section class="backlinks">
+<h2>Linked references</h2>
+<ul>
+{{- range .Backlinks}}
+<li><a href="{{.Href}}">{{.Title}}</a>: {{.Text}}</li>
+{{- end}}
+</ul>
+</section>
+{{- end}}
+</body>
+</html>
+`))
+
+var indexTemplate = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>{{.CSS}}</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{- if .Pages}}
+<h2>Pages</h2>
+<ul>
+{{- range .Pages}}
+<li><a href="{{.Href}}">{{.Title}}</a></li>
+{{- end}}
+</ul>
+{{- end}}
+{{- if .Journals}}
+<h2>Journals</h2>
+<ul>
+{{- range .Journals}}
+<li><a href="{{.Href}}">{{.Title}}</a></li>
+{{- end}}
+</ul>
+{{- end}}
+</body>
+</html>
+`))
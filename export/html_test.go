@@ -0,0 +1,112 @@
+package export_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jrswab/lsq/export"
+)
+
+func TestHTML(t *testing.T) {
+	files, idx := setup(t, map[string]string{
+		"journals/2025_01_02.md": "- DONE [#A] met with [[Team]] about <stuff>\n" +
+			"\t- TODO follow up #[[team/onboard]]\n",
+		"pages/Team.md":           "alias:: crew\n\n- **members** listed here\n  id:: 6500a1b2-0000-4000-8000-00000000000a\n",
+		"pages/team___onboard.md": "- see [[crew]] and ((6500a1b2-0000-4000-8000-00000000000a))\n- [[Missing]]\n",
+		"pages/links.md": "- [site](https://example.com) [mail](mailto:a@example.com) [file](notes.html)\n" +
+			"- [x](javascript:location='//evil') [y](JAVASCRIPT:void) [z](data:text/html,hi)\n",
+		"pages/OrgPage.org": "#+alias: orgish\n\n* TODO heading about [[Team]]\n:PROPERTIES:\n:id: 6500a1b2-0000-4000-8000-00000000000b\n:END:\n** nested *bold* child\n",
+	})
+
+	out := t.TempDir()
+	if err := export.HTML(files, idx, out); err != nil {
+		t.Fatal(err)
+	}
+
+	read := func(name string) string {
+		t.Helper()
+		data, err := os.ReadFile(filepath.Join(out, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	tests := map[string]struct {
+		file     string
+		contains []string
+		excludes []string
+	}{
+		"journal": {
+			file: "2025_01_02.html",
+			contains: []string{
+				`<input type="checkbox" disabled checked> <span class="marker done">DONE</span> <span class="priority">A</span>`,
+				`met with <a class="page-ref" href="Team.html">Team</a> about &lt;stuff&gt;`,
+				`<span class="marker todo">TODO</span> follow up <a class="page-ref" href="team___onboard.html">#team/onboard</a>`,
+			},
+		},
+		"page with alias and backlinks": {
+			file: "Team.html",
+			contains: []string{
+				`<dt>alias</dt><dd><a class="page-ref" href="Team.html">crew</a></dd>`,
+				`<strong>members</strong> listed here`,
+				`<h2>Linked references</h2>`,
+				`<a href="2025_01_02.html">2025_01_02</a>`,
+				`<a href="team___onboard.html">team/onboard</a>`,
+			},
+			excludes: []string{"id::", "6500a1b2"},
+		},
+		"resolved references and missing pages": {
+			file: "team___onboard.html",
+			contains: []string{
+				`see <a class="page-ref" href="Team.html">crew</a> and <strong>members</strong> listed here`,
+				`<span class="page-ref">Missing</span>`,
+			},
+		},
+		"links": {
+			file: "links.html",
+			contains: []string{
+				`<a href="https://example.com">site</a>`,
+				`<a href="mailto:a@example.com">mail</a>`,
+				`<a href="notes.html">file</a>`,
+			},
+			excludes: []string{"javascript:", "JAVASCRIPT:", "data:"},
+		},
+		"org page": {
+			file: "OrgPage.html",
+			contains: []string{
+				`<dt>alias</dt><dd><a class="page-ref" href="OrgPage.html">orgish</a></dd>`,
+				`<span class="marker todo">TODO</span> heading about <a class="page-ref" href="Team.html">Team</a>`,
+				`nested`,
+			},
+			excludes: []string{"* TODO", ":PROPERTIES:", "6500a1b2"},
+		},
+		"index": {
+			file: "index.html",
+			contains: []string{
+				`<h2>Pages</h2>`,
+				`<li><a href="Team.html">Team</a></li>`,
+				`<h2>Journals</h2>`,
+				`<li><a href="2025_01_02.html">2025_01_02</a></li>`,
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := read(tt.file)
+			for _, want := range tt.contains {
+				if !strings.Contains(got, want) {
+					t.Errorf("%s does not contain %q:\n%s", tt.file, want, got)
+				}
+			}
+			for _, unwanted := range tt.excludes {
+				if strings.Contains(got, unwanted) {
+					t.Errorf("%s contains %q", tt.file, unwanted)
+				}
+			}
+		})
+	}
+}
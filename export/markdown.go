@@ -0,0 +1,217 @@
+package export
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/jrswab/lsq/graph"
+	"github.com/jrswab/lsq/outline"
+	"github.com/jrswab/lsq/render"
+)
+
+// Link styles for MarkdownOptions.
+const (
+	// LinkText rewrites "[[page]]" into the plain page name.
+	LinkText = "text"
+	// LinkRelative rewrites "[[page]]" into a relative link to the exported page.
+	LinkRelative = "relative"
+)
+
+var (
+	headingRe    = regexp.MustCompile(`^#{1,6} `)
+	mdPageLinkRe = regexp.MustCompile(`#?\[\[([^\[\]]+)\]\]`)
+	mdLabelRe    = regexp.MustCompile(`\[([^\[\]]+)\]\(\[\[([^\[\]]+)\]\]\)`)
+	pageEmbedAny = regexp.MustCompile(`\{\{embed \[\[([^\[\]]+)\]\]\}\}`)
+	yamlPlainRe  = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9 _./-]*$`)
+)
+
+// MarkdownOptions controls how an outline is flattened into CommonMark.
+type MarkdownOptions struct {
+	// Depth is the number of outline levels turned into headings and
+	// paragraphs. Deeper blocks are written as nested lists.
+	Depth int
+	// Links is LinkText or LinkRelative.
+	Links string
+}
+
+// Markdown converts a page into a CommonMark document. Page properties become
+// YAML front matter, block references are replaced by the referenced text and
+// the outline is flattened into headings and paragraphs up to opts.Depth.
+func Markdown(f graph.File, idx *render.Index, opts MarkdownOptions) string {
+	page, ok := idx.Page(f.Path)
+	if !ok {
+		return ""
+	}
+
+	// Work on a copy so resolving references leaves the index untouched.
+	page = outline.ParseFile(f.Path, page.String())
+	render.ResolveRefs(page, idx, false)
+
+	w := mdWriter{idx: idx, opts: opts}
+	w.frontMatter(f, PageProperties(page, outline.IsOrg(f.Path)))
+	w.sb.WriteString("# " + f.Name + "\n")
+
+	for _, b := range page.Blocks {
+		w.block(b)
+	}
+
+	return w.sb.String()
+}
+
+// WriteMarkdown exports every file into dir using its Logseq file name.
+func WriteMarkdown(files []graph.File, idx *render.Index, dir string, opts MarkdownOptions) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	for _, f := range files {
+		path := filepath.Join(dir, mdName(f))
+		if err := os.WriteFile(path, []byte(Markdown(f, idx, opts)), 0644); err != nil {
+			return fmt.Errorf("error writing %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+type mdWriter struct {
+	sb   strings.Builder
+	idx  *render.Index
+	opts MarkdownOptions
+}
+
+func (w *mdWriter) frontMatter(f graph.File, props [][2]string) {
+	w.sb.WriteString("---\n")
+	w.sb.WriteString("title: " + yamlString(f.Name) + "\n")
+
+	for _, prop := range props {
+		key := strings.ToLower(prop[0])
+		if isHidden(key) || key == "title" {
+			continue
+		}
+
+		switch {
+		case key == "tags" || key == "alias" || strings.Contains(prop[1], "[["):
+			values := outline.PropertyValues(prop[1])
+			for i, v := range values {
+				values[i] = yamlString(v)
+			}
+			w.sb.WriteString(key + ": [" + strings.Join(values, ", ") + "]\n")
+		default:
+			w.sb.WriteString(key + ": " + yamlString(prop[1]) + "\n")
+		}
+	}
+
+	w.sb.WriteString("---\n\n")
+}
+
+// block writes b as a heading or paragraph when it is within the configured
+// depth and as a list item below that.
+func (w *mdWriter) block(b *outline.Block) {
+	level := b.Level()
+	if level >= w.opts.Depth {
+		w.list(b, 0)
+		return
+	}
+
+	content := w.inline(headingRe.ReplaceAllString(b.Content(), ""))
+	body := w.body(b)
+
+	if len(b.Children) > 0 {
+		w.sb.WriteString("\n" + strings.Repeat("#", min(level+2, 6)) + " " + content + "\n")
+		if len(body) > 0 {
+			w.sb.WriteString("\n" + strings.Join(body, "\n") + "\n")
+		}
+	} else {
+		w.sb.WriteString("\n" + strings.Join(append([]string{content}, body...), "\n") + "\n")
+	}
+
+	listed := false
+	for _, child := range b.Children {
+		if child.Level() >= w.opts.Depth && !listed {
+			w.sb.WriteString("\n")
+			listed = true
+		}
+		w.block(child)
+	}
+}
+
+// list writes b and its children as a nested list.
+func (w *mdWriter) list(b *outline.Block, depth int) {
+	indent := strings.Repeat("  ", depth)
+	w.sb.WriteString(indent + "- " + w.inline(b.Content()) + "\n")
+	for _, line := range w.body(b) {
+		w.sb.WriteString(indent + "  " + line + "\n")
+	}
+	for _, child := range b.Children {
+		w.list(child, depth+1)
+	}
+}
+
+// body returns the continuation lines of b without properties and drawers.
+func (w *mdWriter) body(b *outline.Block) []string {
+	var (
+		lines  []string
+		drawer bool
+	)
+	for _, line := range b.Body() {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case drawer || isDrawer(trimmed):
+			drawer = trimmed != ":END:"
+		case outline.IsProperty(trimmed), trimmed == "":
+		default:
+			lines = append(lines, w.inline(trimmed))
+		}
+	}
+	return lines
+}
+
+// inline rewrites Logseq links into plain text or relative Markdown links.
+func (w *mdWriter) inline(text string) string {
+	link := func(label, name string) string {
+		if w.opts.Links != LinkRelative {
+			return label
+		}
+		f, ok := w.idx.File(name)
+		if !ok {
+			return label
+		}
+		return "[" + label + "](" + url.PathEscape(mdName(f)) + ")"
+	}
+
+	text = pageEmbedAny.ReplaceAllString(text, "[[$1]]")
+	text = mdLabelRe.ReplaceAllStringFunc(text, func(match string) string {
+		m := mdLabelRe.FindStringSubmatch(match)
+		return link(m[1], m[2])
+	})
+	return mdPageLinkRe.ReplaceAllStringFunc(text, func(match string) string {
+		name := mdPageLinkRe.FindStringSubmatch(match)[1]
+		return link(name, name)
+	})
+}
+
+func mdName(f graph.File) string {
+	base := filepath.Base(f.Path)
+	return strings.TrimSuffix(base, filepath.Ext(base)) + ".md"
+}
+
+// yamlString quotes s unless it is safe to write as a plain YAML scalar.
+func yamlString(s string) string {
+	s = strings.TrimSpace(s)
+	if yamlPlainRe.MatchString(s) && !isYAMLKeyword(s) {
+		return s
+	}
+	return fmt.Sprintf("%q", s)
+}
+
+func isYAMLKeyword(s string) bool {
+	switch strings.ToLower(s) {
+	case "true", "false", "yes", "no", "on", "off", "null", "~":
+		return true
+	}
+	return false
+}
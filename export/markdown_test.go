@@ -0,0 +1,73 @@
+package export_test
+
+import (
+	"testing"
+
+	"github.com/jrswab/lsq/export"
+	"github.com/jrswab/lsq/graph"
+)
+
+func TestMarkdown(t *testing.T) {
+	files, idx := setup(t, map[string]string{
+		"pages/Meeting.md": "tags:: [[work]], notes\nstatus:: draft: v1\nid:: 1\n\n" +
+			"- ## Agenda\n" +
+			"\t- Review [[Team]] goals\n" +
+			"\t  owner:: me\n" +
+			"\t  second line\n" +
+			"\t\t- detail one\n" +
+			"\t\t\t- deeper\n" +
+			"\t- ((6500a1b2-0000-4000-8000-00000000000a))\n" +
+			"- Single paragraph with [label]([[Team]])\n",
+		"pages/Team.md":     "- Ship it\n  id:: 6500a1b2-0000-4000-8000-00000000000a\n",
+		"pages/OrgPage.org": "#+tags: work\n\n* Plan with [[Team]]\n:PROPERTIES:\n:owner: me\n:END:\nsecond line\n** step one\n",
+	})
+
+	pages := make(map[string]graph.File)
+	for _, f := range files {
+		pages[f.Name] = f
+	}
+
+	tests := map[string]struct {
+		page     string
+		opts     export.MarkdownOptions
+		expected string
+	}{
+		"depth two with plain links": {
+			page: "Meeting",
+			opts: export.MarkdownOptions{Depth: 2, Links: export.LinkText},
+			expected: "---\ntitle: Meeting\ntags: [work, notes]\nstatus: \"draft: v1\"\n---\n\n" +
+				"# Meeting\n" +
+				"\n## Agenda\n" +
+				"\n### Review Team goals\n\nsecond line\n" +
+				"\n- detail one\n  - deeper\n" +
+				"\nShip it\n" +
+				"\nSingle paragraph with label\n",
+		},
+		"depth one with relative links": {
+			page: "Meeting",
+			opts: export.MarkdownOptions{Depth: 1, Links: export.LinkRelative},
+			expected: "---\ntitle: Meeting\ntags: [work, notes]\nstatus: \"draft: v1\"\n---\n\n" +
+				"# Meeting\n" +
+				"\n## Agenda\n" +
+				"\n- Review [Team](Team.md) goals\n  second line\n  - detail one\n    - deeper\n" +
+				"- Ship it\n" +
+				"\nSingle paragraph with [label](Team.md)\n",
+		},
+		"org page": {
+			page: "OrgPage",
+			opts: export.MarkdownOptions{Depth: 1, Links: export.LinkText},
+			expected: "---\ntitle: OrgPage\ntags: [work]\n---\n\n" +
+				"# OrgPage\n" +
+				"\n## Plan with Team\n\nsecond line\n" +
+				"\n- step one\n",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := export.Markdown(pages[tt.page], idx, tt.opts); got != tt.expected {
+				t.Errorf("Markdown() =\n%s\nwant\n%s", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,183 @@
+// Package git versions a graph kept in a local git repository. It runs the
+// git command, so git has to be installed, but needs no remote.
+package git
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// emptyTree is the hash git uses for a tree without files.
+const emptyTree = "4b825dc642cb6eb9a060e54bf8d69288fbee4904"
+
+// Commit is a commit that changed a file.
+type Commit struct {
+	Hash    string
+	Time    time.Time
+	Author  string
+	Subject string
+}
+
+// IsRepo reports whether dir is within a git work tree.
+func IsRepo(dir string) bool {
+	out, err := run(dir, "rev-parse", "--is-inside-work-tree")
+	return err == nil && strings.TrimSpace(out) == "true"
+}
+
+// CommitFiles commits the given files, relative to dir, with message. Only
+// these files are committed; anything else the user staged is left alone.
+func CommitFiles(dir, message string, paths []string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+
+	args := append([]string{"add", "-A", "--"}, paths...)
+	if _, err := run(dir, args...); err != nil {
+		return err
+	}
+
+	args = append([]string{"commit", "--quiet", "--no-verify", "-m", message, "--only", "--"}, paths...)
+	_, err := run(dir, args...)
+	return err
+}
+
+// Status returns how path, relative to dir, differs from the last commit as
+// "created", "modified" or "deleted", or nothing when it is unchanged.
+func Status(dir, path string) (string, error) {
+	out, err := run(dir, "status", "--porcelain", "--untracked-files=all", "--", path)
+	if err != nil {
+		return "", err
+	}
+
+	code := strings.TrimSpace(out)
+	switch {
+	case code == "":
+		return "", nil
+	case strings.HasPrefix(code, "??"), strings.HasPrefix(code, "A"):
+		return "created", nil
+	case strings.HasPrefix(code, "D"), strings.HasPrefix(code, " D"):
+		return "deleted", nil
+	}
+	return "modified", nil
+}
+
+// Log lists the commits that changed path, newest first, following renames.
+func Log(dir, path string) ([]Commit, error) {
+	out, err := run(dir, "log", "--follow", "--format=%H%x00%aI%x00%an%x00%s", "--", path)
+	if err != nil {
+		return nil, err
+	}
+
+	var commits []Commit
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		fields := strings.Split(line, "\x00")
+		if len(fields) != 4 {
+			continue
+		}
+		when, err := time.Parse(time.RFC3339, fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("error parsing commit date: %w", err)
+		}
+		commits = append(commits, Commit{Hash: fields[0], Time: when, Author: fields[2], Subject: fields[3]})
+	}
+	return commits, nil
+}
+
+// Show returns the patch of a commit, limited to path when it is not empty.
+func Show(dir, hash, path string) (string, error) {
+	args := []string{"show", "--format=", hash}
+	if path != "" {
+		args = append(args, "--", path)
+	}
+	return run(dir, args...)
+}
+
+// Diff returns the changes between the last commits before from and before
+// to, limited to paths when any are given.
+func Diff(dir string, from, to time.Time, paths []string) (string, error) {
+	old, err := revBefore(dir, from)
+	if err != nil {
+		return "", err
+	}
+	if old == "" {
+		old = emptyTree
+	}
+
+	cur, err := revBefore(dir, to)
+	if err != nil {
+		return "", err
+	}
+	if cur == "" {
+		return "", fmt.Errorf("no commits before %s", to.Format("2006-01-02 15:04"))
+	}
+
+	args := append([]string{"diff", old, cur, "--"}, paths...)
+	return run(dir, args...)
+}
+
+// revBefore returns the last commit of HEAD made before t, or nothing when
+// there is none.
+func revBefore(dir string, t time.Time) (string, error) {
+	out, err := run(dir, "rev-list", "-1", "--before="+t.Format(time.RFC3339), "HEAD")
+	return strings.TrimSpace(out), err
+}
+
+// run runs git in dir and returns its output, turning failures into errors
+// carrying what git printed.
+func run(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", filepath.Clean(dir)}, args...)...)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if msg := strings.TrimSpace(stderr.String()); errors.As(err, &exitErr) && msg != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return stdout.String(), nil
+}
+
+// File is a file changed by lsq.
+type File struct {
+	Path string
+	// Action is "created", "modified" or "deleted".
+	Action string
+}
+
+// verbs turns the actions of files into commit subjects.
+var verbs = map[string]string{"created": "Create", "modified": "Update", "deleted": "Delete"}
+
+// Message describes the changes made by command for a commit: the subject
+// names the file, or the number of files, and the body lists the command
+// and every file.
+func Message(command string, files []File) string {
+	var subject string
+	if len(files) == 1 {
+		subject = verbs[files[0].Action] + " " + filepath.ToSlash(files[0].Path)
+	} else {
+		subject = fmt.Sprintf("Update %d files", len(files))
+	}
+
+	if runes := []rune(command); len(runes) > 72 {
+		command = string(runes[:71]) + "…"
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s\n\n%s\n", subject, command)
+	if len(files) > 1 {
+		sb.WriteString("\n")
+		for _, f := range files {
+			fmt.Fprintf(&sb, "%s (%s)\n", filepath.ToSlash(f.Path), f.Action)
+		}
+	}
+	return sb.String()
+}
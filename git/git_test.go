@@ -0,0 +1,175 @@
+package git_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jrswab/lsq/git"
+)
+
+func TestMessage(t *testing.T) {
+	tests := map[string]struct {
+		command string
+		files   []git.File
+		want    string
+	}{
+		"single file": {
+			command: "lsq -a hello",
+			files:   []git.File{{Path: "journals/2025_01_02.md", Action: "modified"}},
+			want:    "Update journals/2025_01_02.md\n\nlsq -a hello\n",
+		},
+		"created file": {
+			command: "lsq import notes",
+			files:   []git.File{{Path: "pages/a.md", Action: "created"}},
+			want:    "Create pages/a.md\n\nlsq import notes\n",
+		},
+		"several files": {
+			command: "lsq lint -fix",
+			files: []git.File{
+				{Path: "journals/2025_01_02.md", Action: "modified"},
+				{Path: "journals/2025-01-02.md", Action: "deleted"},
+			},
+			want: "Update 2 files\n\nlsq lint -fix\n\n" +
+				"journals/2025_01_02.md (modified)\njournals/2025-01-02.md (deleted)\n",
+		},
+		"long command": {
+			command: "lsq -a " + strings.Repeat("x", 80),
+			files:   []git.File{{Path: "pages/a.md", Action: "modified"}},
+			want:    "Update pages/a.md\n\nlsq -a " + strings.Repeat("x", 64) + "…\n",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := git.Message(tc.command, tc.files); got != tc.want {
+				t.Errorf("Message() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestCommitFiles(t *testing.T) {
+	dir := newRepo(t)
+	writeFile(t, dir, "pages/a.md", "- a\n")
+	writeFile(t, dir, "pages/other.md", "- not lsq's\n")
+
+	if git.IsRepo(t.TempDir()) {
+		t.Error("IsRepo() = true for a plain directory")
+	}
+	if !git.IsRepo(dir) {
+		t.Fatal("IsRepo() = false for a repository")
+	}
+
+	if err := git.CommitFiles(dir, "Create pages/a.md", []string{"pages/a.md"}); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, dir, "pages/a.md", "- a\n- b\n")
+	if err := git.CommitFiles(dir, "Update pages/a.md", []string{"pages/a.md"}); err != nil {
+		t.Fatal(err)
+	}
+
+	commits, err := git.Log(dir, "pages/a.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(commits) != 2 || commits[0].Subject != "Update pages/a.md" || commits[1].Subject != "Create pages/a.md" {
+		t.Fatalf("Log() = %+v, want both commits newest first", commits)
+	}
+
+	patch, err := git.Show(dir, commits[0].Hash, "pages/a.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(patch, "+- b") {
+		t.Errorf("Show() = %q, want the added line", patch)
+	}
+
+	// Files lsq did not change stay uncommitted.
+	other, err := git.Log(dir, "pages/other.md")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(other) != 0 {
+		t.Errorf("Log(other) = %+v, want no commits", other)
+	}
+
+	diff, err := git.Diff(dir, time.Now().AddDate(0, 0, -1), time.Now().Add(time.Minute), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(diff, "+++ b/pages/a.md") || strings.Contains(diff, "other.md") {
+		t.Errorf("Diff() = %q, want the committed page only", diff)
+	}
+}
+
+func TestStatus(t *testing.T) {
+	dir := newRepo(t)
+	writeFile(t, dir, "pages/a.md", "- a\n")
+	writeFile(t, dir, "pages/b.md", "- b\n")
+	if err := git.CommitFiles(dir, "Add pages", []string{"pages/a.md", "pages/b.md"}); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, dir, "pages/a.md", "- a\n- edited\n")
+	writeFile(t, dir, "pages/new.md", "- new\n")
+	if err := os.Remove(filepath.Join(dir, "pages/b.md")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		path string
+		want string
+	}{
+		"modified":  {path: "pages/a.md", want: "modified"},
+		"created":   {path: "pages/new.md", want: "created"},
+		"deleted":   {path: "pages/b.md", want: "deleted"},
+		"unchanged": {path: "pages/missing.md", want: ""},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := git.Status(dir, tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Status(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func newRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"config", "user.name", "lsq"},
+		{"config", "user.email", "lsq@example.com"},
+		{"config", "commit.gpgsign", "false"},
+	} {
+		cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %v\n%s", args[0], err, out)
+		}
+	}
+	return dir
+}
+
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
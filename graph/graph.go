@@ -0,0 +1,141 @@
+// Package graph lists the journal and page files of a Logseq graph.
+package graph
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jrswab/lsq/config"
+)
+
+// File is a single journal or page of the graph.
+type File struct {
+	Path string
+	// Name is the page name as shown in Logseq.
+	Name    string
+	Journal bool
+	// Date is only set for journals whose file name matches the configured format.
+	Date time.Time
+}
+
+// Journals lists the journal files sorted by file name.
+func Journals(cfg *config.Config) ([]File, error) {
+	entries, err := readDir(cfg.JournalsDir)
+	if err != nil {
+		return nil, err
+	}
+
+	layout := config.ConvertDateFormat(cfg.FileFmt)
+	files := make([]File, 0, len(entries))
+	for _, name := range entries {
+		f := File{
+			Path:    filepath.Join(cfg.JournalsDir, name),
+			Name:    strings.TrimSuffix(name, filepath.Ext(name)),
+			Journal: true,
+		}
+
+		if date, err := time.ParseInLocation(layout, f.Name, time.Local); err == nil {
+			f.Date = date
+		}
+
+		files = append(files, f)
+	}
+
+	return files, nil
+}
+
+// Pages lists the page files sorted by file name.
+func Pages(cfg *config.Config) ([]File, error) {
+	entries, err := readDir(cfg.PagesDir)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]File, 0, len(entries))
+	for _, name := range entries {
+		files = append(files, File{
+			Path: filepath.Join(cfg.PagesDir, name),
+			Name: PageName(name),
+		})
+	}
+
+	return files, nil
+}
+
+// All lists the journals followed by the pages.
+func All(cfg *config.Config) ([]File, error) {
+	journals, err := Journals(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	pages, err := Pages(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(journals, pages...), nil
+}
+
+// PageName converts a file name into the page name Logseq shows for it.
+// Namespaces are stored as "___" and other reserved characters are URL encoded.
+func PageName(fileName string) string {
+	name := strings.TrimSuffix(fileName, filepath.Ext(fileName))
+	name = strings.ReplaceAll(name, "___", "/")
+
+	if decoded, err := url.PathUnescape(name); err == nil {
+		name = decoded
+	}
+
+	return name
+}
+
+// FileName converts a page name into the file name Logseq stores it under,
+// without an extension. Namespaces use "___" and characters that are not
+// allowed in file names on every platform are URL encoded.
+func FileName(pageName string) string {
+	var sb strings.Builder
+	for _, r := range pageName {
+		switch r {
+		case '/':
+			sb.WriteString("___")
+		case '<', '>', ':', '"', '\\', '|', '?', '*', '#', '%':
+			sb.WriteString(url.QueryEscape(string(r)))
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// IsPageFile reports whether the file name has an extension Logseq reads.
+func IsPageFile(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".md", ".org":
+		return true
+	}
+	return false
+}
+
+// readDir returns the sorted names of the page files within dir.
+func readDir(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || !IsPageFile(entry.Name()) {
+			continue
+		}
+		names = append(names, entry.Name())
+	}
+
+	sort.Strings(names)
+	return names, nil
+}
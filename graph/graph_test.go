@@ -0,0 +1,109 @@
+package graph_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jrswab/lsq/config"
+	"github.com/jrswab/lsq/graph"
+)
+
+func TestPageName(t *testing.T) {
+	tests := map[string]struct {
+		input    string
+		expected string
+	}{
+		"plain page":       {input: "Recipes.md", expected: "Recipes"},
+		"org page":         {input: "Recipes.org", expected: "Recipes"},
+		"namespace":        {input: "work___projects___lsq.md", expected: "work/projects/lsq"},
+		"url encoded":      {input: "what%3F.md", expected: "what?"},
+		"invalid encoding": {input: "100%.md", expected: "100%"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := graph.PageName(tt.input); got != tt.expected {
+				t.Errorf("PageName(%q) = %q, want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFileName(t *testing.T) {
+	tests := map[string]struct {
+		input    string
+		expected string
+	}{
+		"plain page":   {input: "Recipes", expected: "Recipes"},
+		"namespace":    {input: "work/projects/lsq", expected: "work___projects___lsq"},
+		"reserved":     {input: "what?", expected: "what%3F"},
+		"percent sign": {input: "100%", expected: "100%25"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := graph.FileName(tt.input)
+			if got != tt.expected {
+				t.Errorf("FileName(%q) = %q, want %q", tt.input, got, tt.expected)
+			}
+			if back := graph.PageName(got + ".md"); back != tt.input {
+				t.Errorf("PageName(%q) = %q, want %q", got+".md", back, tt.input)
+			}
+		})
+	}
+}
+
+func TestAll(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.Config{
+		FileFmt:     "yyyy_MM_dd",
+		JournalsDir: filepath.Join(dir, "journals"),
+		PagesDir:    filepath.Join(dir, "pages"),
+	}
+
+	files := map[string]string{
+		"journals/2025_01_02.md": "- b",
+		"journals/2025_01_01.md": "- a",
+		"journals/notes.md":      "- misnamed",
+		"pages/b___c.md":         "- page",
+		"pages/image.png":        "",
+	}
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := graph.All(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		name    string
+		journal bool
+		date    time.Time
+	}{
+		{"2025_01_01", true, time.Date(2025, 1, 1, 0, 0, 0, 0, time.Local)},
+		{"2025_01_02", true, time.Date(2025, 1, 2, 0, 0, 0, 0, time.Local)},
+		{"notes", true, time.Time{}},
+		{"b/c", false, time.Time{}},
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("All() returned %d files, want %d: %v", len(got), len(want), got)
+	}
+
+	for i, w := range want {
+		if got[i].Name != w.name || got[i].Journal != w.journal || !got[i].Date.Equal(w.date) {
+			t.Errorf("All()[%d] = %+v, want %+v", i, got[i], w)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/jrswab/lsq/git"
+	"github.com/jrswab/lsq/store"
+)
+
+func runHistory(args []string) error {
+	fs, dir := newFlagSet("history")
+	n := fs.Int("n", 10, "Number of operations to list, newest first.")
+	fs.Parse(args)
+
+	cfg, err := loadConfig(*dir)
+	if err != nil {
+		return err
+	}
+
+	ops, err := store.History(cfg.DirPath)
+	if err != nil {
+		return fmt.Errorf("error reading history: %v", err)
+	}
+
+	for i := len(ops) - 1; i >= 0 && i >= len(ops)-*n; i-- {
+		printOperation(ops[i])
+	}
+	return nil
+}
+
+func runUndo(args []string) error {
+	fs, dir := newFlagSet("undo")
+	n := fs.Int("n", 1, "Number of operations to undo, newest first.")
+	fs.Parse(args)
+
+	if *n < 1 {
+		return fmt.Errorf("-n must be at least 1")
+	}
+
+	cfg, err := loadConfig(*dir)
+	if err != nil {
+		return err
+	}
+
+	undone, err := store.Undo(cfg.DirPath, *n)
+	for _, op := range undone {
+		fmt.Print("Undid ")
+		printOperation(op)
+	}
+	_, command, _ := store.Recorded()
+	files := reverted(undone)
+	runHooks(cfg.DirPath, files)
+	commitFiles(cfg.DirPath, command, files)
+	if err != nil {
+		return err
+	}
+	if len(undone) == 0 {
+		fmt.Println("Nothing to undo.")
+	}
+	return nil
+}
+
+// printOperation prints when an operation ran and the files it changed.
+func printOperation(op store.Operation) {
+	fmt.Printf("%s  %s\n", op.Time.Format("2006-01-02 15:04:05"), op.Command)
+	for _, c := range op.Changes {
+		fmt.Printf("\t%s (%s)\n", filepath.ToSlash(c.Path), c.Action())
+	}
+}
+
+// undoActions maps what an operation did to a file to what undoing it does.
+var undoActions = map[string]string{"created": "deleted", "modified": "modified", "deleted": "created"}
+
+// reverted lists the files restored by undoing ops, once each.
+func reverted(ops []store.Operation) []git.File {
+	var (
+		files []git.File
+		seen  = make(map[string]bool)
+	)
+	for _, op := range ops {
+		for _, c := range op.Changes {
+			if seen[c.Path] || c.Backup == "" && c.Hash == "" {
+				continue
+			}
+			seen[c.Path] = true
+			files = append(files, git.File{Path: c.Path, Action: undoActions[c.Action()]})
+		}
+	}
+	return files
+}
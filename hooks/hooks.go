@@ -0,0 +1,111 @@
+// Package hooks runs the commands configured to run before and after lsq
+// changes a journal or page.
+package hooks
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// nested is set in the environment of hooks so that lsq commands started
+// by a hook do not run the hooks again.
+const nested = "LSQ_HOOK"
+
+// Output receives what post-write hooks print. lsq mcp sets it to stderr
+// because stdout carries the protocol.
+var Output io.Writer = os.Stdout
+
+// ErrRejected is returned when a pre-write hook rejects the content.
+var ErrRejected = errors.New("rejected the change")
+
+// Event is a change to the graph. Hooks receive it in LSQ_OPERATION,
+// LSQ_FILE, LSQ_ACTION and LSQ_DIRECTORY.
+type Event struct {
+	// Operation is what changed the file: "edit", "append", "task" or the
+	// name of a subcommand such as "carry".
+	Operation string
+	Path      string
+	// Action is "created", "modified" or "deleted", or empty when the file
+	// was changed by an external editor.
+	Action string
+	// Dir is the directory of the graph.
+	Dir string
+}
+
+func (e Event) env() []string {
+	return append(os.Environ(),
+		nested+"=1",
+		"LSQ_OPERATION="+e.Operation,
+		"LSQ_FILE="+e.Path,
+		"LSQ_ACTION="+e.Action,
+		"LSQ_DIRECTORY="+e.Dir,
+	)
+}
+
+// Disabled reports whether hooks are skipped because lsq was started by a hook.
+func Disabled() bool {
+	return os.Getenv(nested) != ""
+}
+
+// Pre runs the pre-write hooks in order on content about to be written.
+// Each hook reads the content on stdin and what it prints replaces it, so
+// hooks can transform the content. A hook exiting with an error rejects it.
+func Pre(commands []string, e Event, content string) (string, error) {
+	if Disabled() {
+		return content, nil
+	}
+
+	for _, c := range commands {
+		var stdout, stderr bytes.Buffer
+		cmd := shell(c)
+		cmd.Env = e.env()
+		cmd.Stdin = strings.NewReader(content)
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+
+		if err := cmd.Run(); err != nil {
+			if msg := strings.TrimSpace(stderr.String()); msg != "" {
+				return "", fmt.Errorf("pre-write hook %q %w: %s", c, ErrRejected, msg)
+			}
+			return "", fmt.Errorf("pre-write hook %q %w: %v", c, ErrRejected, err)
+		}
+		content = stdout.String()
+	}
+	return content, nil
+}
+
+// Post runs the post-write hooks in order after a file changed. Every hook
+// runs even when an earlier one fails; the failures are returned together.
+func Post(commands []string, e Event) error {
+	if Disabled() {
+		return nil
+	}
+
+	var errs []error
+	for _, c := range commands {
+		cmd := shell(c)
+		cmd.Env = e.env()
+		cmd.Stdout = Output
+		cmd.Stderr = os.Stderr
+
+		if err := cmd.Run(); err != nil {
+			errs = append(errs, fmt.Errorf("post-write hook %q: %v", c, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// shell runs command with the shell of the system, so hooks can use pipes
+// and variables such as "$LSQ_FILE".
+func shell(command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.Command("cmd", "/C", command)
+	}
+	return exec.Command("sh", "-c", command)
+}
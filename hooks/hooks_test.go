@@ -0,0 +1,103 @@
+package hooks_test
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/jrswab/lsq/hooks"
+)
+
+func TestPre(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hooks use sh in these tests")
+	}
+
+	event := hooks.Event{Operation: "append", Path: "/graph/journals/2025_01_02.md", Dir: "/graph"}
+
+	tests := map[string]struct {
+		commands    []string
+		content     string
+		want        string
+		expectError string
+	}{
+		"no hooks": {
+			content: "- note\n",
+			want:    "- note\n",
+		},
+		"transform": {
+			commands: []string{`sed 's/token=[^ ]*/token=[redacted]/'`},
+			content:  "- call api token=abc123 today\n",
+			want:     "- call api token=[redacted] today\n",
+		},
+		"hooks run in order": {
+			commands: []string{"tr a-z A-Z", `sed 's/^/> /'`},
+			content:  "note\n",
+			want:     "> NOTE\n",
+		},
+		"environment": {
+			commands: []string{`echo "$LSQ_OPERATION $LSQ_FILE $LSQ_DIRECTORY"`},
+			want:     "append /graph/journals/2025_01_02.md /graph\n",
+		},
+		"reject": {
+			commands:    []string{`grep -q secret && { echo "contains a secret" >&2; exit 1; }; cat`},
+			content:     "- my secret\n",
+			expectError: "contains a secret",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := hooks.Pre(tt.commands, event, tt.content)
+			if tt.expectError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectError) {
+					t.Fatalf("Pre() error = %v, want %q", err, tt.expectError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Pre() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPost(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hooks use sh in these tests")
+	}
+
+	out := filepath.Join(t.TempDir(), "out")
+	event := hooks.Event{Operation: "carry", Path: "/graph/pages/a.md", Action: "modified", Dir: "/graph"}
+
+	err := hooks.Post([]string{
+		`echo "$LSQ_OPERATION $LSQ_ACTION $LSQ_FILE" >> ` + out,
+		"exit 3",
+		`echo second >> ` + out,
+	}, event)
+	if err == nil || !strings.Contains(err.Error(), "exit 3") {
+		t.Errorf("Post() error = %v, want the failing hook", err)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(data), "carry modified /graph/pages/a.md\nsecond\n"; got != want {
+		t.Errorf("hooks wrote %q, want %q", got, want)
+	}
+}
+
+func TestNested(t *testing.T) {
+	t.Setenv("LSQ_HOOK", "1")
+
+	got, err := hooks.Pre([]string{"exit 1"}, hooks.Event{}, "text")
+	if err != nil || got != "text" {
+		t.Errorf("Pre() = %q, %v; want hooks skipped", got, err)
+	}
+}
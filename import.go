@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/jrswab/lsq/importer"
+)
+
+func runImport(args []string) error {
+	fs, dir := newFlagSet("import")
+	dryRun := fs.Bool("dry-run", false, "Print where each file would be imported without writing anything.")
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		return fmt.Errorf("usage: lsq import [-dry-run] path...")
+	}
+
+	cfg, err := loadConfig(*dir)
+	if err != nil {
+		return err
+	}
+
+	results, err := importer.Run(cfg, fs.Args(), importer.Options{DryRun: *dryRun})
+	for _, r := range results {
+		if r.Skipped {
+			fmt.Printf("%s -> %s (exists, skipped)\n", r.Source, r.Target)
+			continue
+		}
+		fmt.Printf("%s -> %s\n", r.Source, r.Target)
+	}
+	if err != nil {
+		return fmt.Errorf("error importing notes: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,99 @@
+package importer
+
+import (
+	"strings"
+)
+
+// propertyNames maps front matter keys onto the Logseq property with the same meaning.
+var propertyNames = map[string]string{
+	"aliases": "alias",
+	"tag":     "tags",
+}
+
+// FrontMatter splits YAML front matter from the document and converts it into
+// Logseq properties. Only the flat subset of YAML used by note taking tools is
+// understood: scalars, inline lists and block lists. Nested maps are skipped.
+func FrontMatter(content string) (props [][2]string, body string) {
+	if !strings.HasPrefix(content, "---\n") {
+		return nil, content
+	}
+
+	rest := content[len("---\n"):]
+	end := strings.Index(rest, "\n---")
+	if end < 0 {
+		return nil, content
+	}
+
+	body = strings.TrimPrefix(rest[end+len("\n---"):], "\n")
+
+	var (
+		key    string
+		values []string
+	)
+
+	add := func() {
+		if key == "" || len(values) == 0 {
+			return
+		}
+		name := strings.ToLower(key)
+		if mapped, ok := propertyNames[name]; ok {
+			name = mapped
+		}
+		props = append(props, [2]string{name, strings.Join(values, ", ")})
+	}
+
+	for _, line := range strings.Split(rest[:end], "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		// Block list item belonging to the previous key.
+		if strings.HasPrefix(trimmed, "- ") && key != "" {
+			values = append(values, scalar(strings.TrimPrefix(trimmed, "- "), key))
+			continue
+		}
+
+		// Nested maps are not representable as properties.
+		if line != strings.TrimLeft(line, " \t") {
+			continue
+		}
+
+		add()
+		k, v, found := strings.Cut(line, ":")
+		if !found {
+			key, values = "", nil
+			continue
+		}
+
+		key, values = strings.TrimSpace(k), nil
+		v = strings.TrimSpace(v)
+
+		switch {
+		case v == "":
+		case strings.HasPrefix(v, "[") && strings.HasSuffix(v, "]"):
+			for _, item := range strings.Split(v[1:len(v)-1], ",") {
+				if item = scalar(item, key); item != "" {
+					values = append(values, item)
+				}
+			}
+		default:
+			values = append(values, scalar(v, key))
+		}
+	}
+	add()
+
+	return props, body
+}
+
+// scalar unquotes a YAML value. Tags lose the "#" Obsidian allows in front matter.
+func scalar(v, key string) string {
+	v = strings.TrimSpace(v)
+	if len(v) >= 2 && (v[0] == '"' && v[len(v)-1] == '"' || v[0] == '\'' && v[len(v)-1] == '\'') {
+		v = v[1 : len(v)-1]
+	}
+	if strings.EqualFold(key, "tags") || strings.EqualFold(key, "tag") {
+		v = strings.TrimPrefix(v, "#")
+	}
+	return v
+}
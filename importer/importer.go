@@ -0,0 +1,316 @@
+// Package importer converts plain Markdown notes, such as Obsidian vaults
+// or exported documents with YAML front matter, into Logseq pages.
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jrswab/lsq/config"
+	"github.com/jrswab/lsq/graph"
+	"github.com/jrswab/lsq/store"
+)
+
+// dateLayouts are the file names recognised as daily notes.
+var dateLayouts = []string{"2006-01-02", "2006_01_02", "2006.01.02", "20060102"}
+
+var (
+	headingRe  = regexp.MustCompile(`^(#{1,6})\s+(.*)$`)
+	listItemRe = regexp.MustCompile(`^(\s*)(?:[-*+]|\d+[.)])\s+(.*)$`)
+	taskRe     = regexp.MustCompile(`^\[([ xX])\]\s+`)
+	fenceRe    = regexp.MustCompile("^\\s*(```|~~~)")
+	embedRe    = regexp.MustCompile(`!\[\[([^\[\]|#]+)(?:#[^\[\]|]*)?(?:\|[^\[\]]*)?\]\]`)
+	aliasRe    = regexp.MustCompile(`\[\[([^\[\]|#]+)(?:#[^\[\]|]*)?\|([^\[\]]+)\]\]`)
+	sectionRe  = regexp.MustCompile(`\[\[([^\[\]|#]+)#[^\[\]|]*\]\]`)
+	imageExtRe = regexp.MustCompile(`(?i)\.(png|jpe?g|gif|svg|webp|pdf)$`)
+)
+
+// Result describes where a source file was imported to.
+type Result struct {
+	Source string
+	Target string
+	// Skipped is set when the target already existed and was left untouched.
+	Skipped bool
+}
+
+// Options controls an import.
+type Options struct {
+	// DryRun reports the results without writing any files.
+	DryRun bool
+}
+
+// Run imports every Markdown file found at the paths. Directories are
+// walked recursively and their sub-directories become page namespaces.
+func Run(cfg *config.Config, paths []string, opts Options) ([]Result, error) {
+	var results []Result
+	for _, root := range paths {
+		info, err := os.Stat(root)
+		if err != nil {
+			return results, err
+		}
+
+		if !info.IsDir() {
+			r, err := importFile(cfg, root, strings.TrimSuffix(filepath.Base(root), filepath.Ext(root)), opts)
+			if err != nil {
+				return results, err
+			}
+			results = append(results, r)
+			continue
+		}
+
+		err = filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			// Skip tool settings such as .obsidian and .trash.
+			if d.IsDir() && path != root && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+
+			if d.IsDir() || !strings.EqualFold(filepath.Ext(path), ".md") {
+				return nil
+			}
+
+			rel, err := filepath.Rel(root, path)
+			if err != nil {
+				return err
+			}
+
+			name := filepath.ToSlash(strings.TrimSuffix(rel, filepath.Ext(rel)))
+			r, err := importFile(cfg, path, name, opts)
+			if err != nil {
+				return err
+			}
+			results = append(results, r)
+			return nil
+		})
+		if err != nil {
+			return results, err
+		}
+	}
+
+	return results, nil
+}
+
+// importFile converts the file at path into the page called name.
+func importFile(cfg *config.Config, path, name string, opts Options) (Result, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Result{}, err
+	}
+
+	r := Result{Source: path, Target: Target(cfg, name)}
+
+	if _, err := os.Stat(r.Target); err == nil {
+		r.Skipped = true
+		return r, nil
+	}
+
+	if opts.DryRun {
+		return r, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(r.Target), 0755); err != nil {
+		return r, err
+	}
+
+	err = store.Create(r.Target, []byte(Convert(string(data))))
+	if errors.Is(err, fs.ErrExist) {
+		// Created by someone else since it was checked above.
+		r.Skipped = true
+		return r, nil
+	}
+	if err != nil {
+		return r, fmt.Errorf("error writing %s: %w", r.Target, err)
+	}
+
+	return r, nil
+}
+
+// Target returns the path a note called name is imported to. Notes named
+// after a date become journals using the configured file name format.
+func Target(cfg *config.Config, name string) string {
+	base := name[strings.LastIndex(name, "/")+1:]
+	for _, layout := range dateLayouts {
+		date, err := time.Parse(layout, base)
+		if err != nil {
+			continue
+		}
+		return filepath.Join(cfg.JournalsDir, date.Format(config.ConvertDateFormat(cfg.FileFmt))+".md")
+	}
+
+	return filepath.Join(cfg.PagesDir, graph.FileName(name)+".md")
+}
+
+// Convert turns a Markdown document into a Logseq page. Front matter becomes
+// page properties, headings become blocks with the content below them nested
+// as children, and Obsidian style links are rewritten into Logseq syntax.
+func Convert(content string) string {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+	props, body := FrontMatter(content)
+
+	c := converter{}
+	for _, line := range strings.Split(body, "\n") {
+		c.line(line)
+	}
+	c.flush()
+
+	var sb strings.Builder
+	for _, prop := range props {
+		sb.WriteString(prop[0] + ":: " + prop[1] + "\n")
+	}
+	if len(props) > 0 && len(c.out) > 0 {
+		sb.WriteString("\n")
+	}
+	for _, line := range c.out {
+		sb.WriteString(line + "\n")
+	}
+	return sb.String()
+}
+
+type converter struct {
+	out []string
+
+	// headings holds the levels of the headings enclosing the current line.
+	headings []int
+	// lists holds the indentation widths of the enclosing list items.
+	lists []int
+
+	// pending is the block being collected and depth its nesting level.
+	pending []string
+	depth   int
+	fence   bool
+}
+
+func (c *converter) line(line string) {
+	if c.fence {
+		c.pending = append(c.pending, line)
+		if fenceRe.MatchString(line) {
+			c.fence = false
+		}
+		return
+	}
+
+	if fenceRe.MatchString(line) {
+		if len(c.pending) == 0 {
+			c.start(c.level(), strings.TrimSpace(line))
+		} else {
+			c.pending = append(c.pending, strings.TrimSpace(line))
+		}
+		c.fence = true
+		return
+	}
+
+	if strings.TrimSpace(line) == "" {
+		c.flush()
+		return
+	}
+
+	if m := headingRe.FindStringSubmatch(line); m != nil {
+		level := len(m[1])
+		for len(c.headings) > 0 && c.headings[len(c.headings)-1] >= level {
+			c.headings = c.headings[:len(c.headings)-1]
+		}
+		c.lists = nil
+		c.start(len(c.headings), m[1]+" "+m[2])
+		c.flush()
+		c.headings = append(c.headings, level)
+		return
+	}
+
+	if m := listItemRe.FindStringSubmatch(line); m != nil {
+		width := indentWidth(m[1])
+		for len(c.lists) > 0 && c.lists[len(c.lists)-1] >= width {
+			c.lists = c.lists[:len(c.lists)-1]
+		}
+		c.start(len(c.headings)+len(c.lists), task(m[2]))
+		c.lists = append(c.lists, width)
+		return
+	}
+
+	if len(c.pending) > 0 {
+		c.pending = append(c.pending, strings.TrimSpace(line))
+		return
+	}
+
+	// An unindented paragraph ends any list above it.
+	if strings.TrimLeft(line, " \t") == line {
+		c.lists = nil
+	}
+
+	c.start(c.level(), strings.TrimSpace(line))
+}
+
+// level is the nesting level for a new paragraph.
+func (c *converter) level() int {
+	return len(c.headings) + len(c.lists)
+}
+
+// start begins a new block, writing out the previous one.
+func (c *converter) start(depth int, text string) {
+	c.flush()
+	c.depth = depth
+	c.pending = []string{text}
+}
+
+// flush writes the pending block as a bullet with continuation lines.
+func (c *converter) flush() {
+	if len(c.pending) == 0 {
+		return
+	}
+
+	indent := strings.Repeat("\t", c.depth)
+	for i, line := range c.pending {
+		line = Links(line)
+		if i == 0 {
+			c.out = append(c.out, indent+"- "+line)
+			continue
+		}
+		c.out = append(c.out, indent+"  "+line)
+	}
+	c.pending = nil
+}
+
+// task converts "[ ] text" and "[x] text" list items into TODO and DONE.
+func task(text string) string {
+	m := taskRe.FindStringSubmatch(text)
+	if m == nil {
+		return text
+	}
+	if m[1] == " " {
+		return "TODO " + text[len(m[0]):]
+	}
+	return "DONE " + text[len(m[0]):]
+}
+
+// Links rewrites Obsidian style links and embeds into Logseq syntax.
+func Links(text string) string {
+	text = embedRe.ReplaceAllStringFunc(text, func(match string) string {
+		name := strings.TrimSpace(embedRe.FindStringSubmatch(match)[1])
+		if imageExtRe.MatchString(name) {
+			return "![" + name + "](../assets/" + name + ")"
+		}
+		return "{{embed [[" + name + "]]}}"
+	})
+	text = aliasRe.ReplaceAllString(text, "[$2]([[$1]])")
+	return sectionRe.ReplaceAllString(text, "[[$1]]")
+}
+
+func indentWidth(indent string) int {
+	w := 0
+	for _, r := range indent {
+		if r == '\t' {
+			w += 4
+			continue
+		}
+		w++
+	}
+	return w
+}
@@ -0,0 +1,138 @@
+package importer_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jrswab/lsq/config"
+	"github.com/jrswab/lsq/importer"
+)
+
+func TestConvert(t *testing.T) {
+	tests := map[string]struct {
+		input    string
+		expected string
+	}{
+		"paragraphs": {
+			input:    "First line\nstill first\n\nSecond\n",
+			expected: "- First line\n  still first\n- Second\n",
+		},
+		"headings nest content": {
+			input:    "# Title\nintro\n## Part\nbody\n# Other\n",
+			expected: "- # Title\n\t- intro\n\t- ## Part\n\t\t- body\n- # Other\n",
+		},
+		"nested lists and tasks": {
+			input:    "- [ ] write\n  - [x] outline\n- read\n\nafter\n",
+			expected: "- TODO write\n\t- DONE outline\n- read\n- after\n",
+		},
+		"code fence stays in one block": {
+			input:    "```go\nfunc main() {\n\n}\n```\n",
+			expected: "- ```go\n  func main() {\n  \n  }\n  ```\n",
+		},
+		"front matter": {
+			input:    "---\ntitle: \"Notes\"\naliases: [n, notes]\ntags:\n  - \"#work\"\n  - ideas\n---\ntext\n",
+			expected: "title:: Notes\nalias:: n, notes\ntags:: work, ideas\n\n- text\n",
+		},
+		"obsidian links": {
+			input:    "See [[Page|this]], [[Page#Heading]] and ![[Other]] ![[cat.png]]\n",
+			expected: "- See [this]([[Page]]), [[Page]] and {{embed [[Other]]}} ![cat.png](../assets/cat.png)\n",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := importer.Convert(tt.input); got != tt.expected {
+				t.Errorf("Convert() =\n%q\nwant\n%q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestTarget(t *testing.T) {
+	cfg := &config.Config{FileFmt: "yyyy_MM_dd", JournalsDir: "journals", PagesDir: "pages"}
+
+	tests := map[string]struct {
+		input    string
+		expected string
+	}{
+		"page":           {input: "Recipes", expected: filepath.Join("pages", "Recipes.md")},
+		"namespace":      {input: "work/lsq", expected: filepath.Join("pages", "work___lsq.md")},
+		"dashed date":    {input: "daily/2025-01-02", expected: filepath.Join("journals", "2025_01_02.md")},
+		"compact date":   {input: "20250102", expected: filepath.Join("journals", "2025_01_02.md")},
+		"not quite date": {input: "2025-13-02", expected: filepath.Join("pages", "2025-13-02.md")},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := importer.Target(cfg, tt.input); got != tt.expected {
+				t.Errorf("Target(%q) = %q, want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.Config{
+		FileFmt:     "yyyy_MM_dd",
+		JournalsDir: filepath.Join(dir, "graph", "journals"),
+		PagesDir:    filepath.Join(dir, "graph", "pages"),
+	}
+
+	files := map[string]string{
+		"vault/Ideas.md":         "- idea",
+		"vault/work/Plan.md":     "# Plan",
+		"vault/2025-01-02.md":    "- met [[Plan]]",
+		"vault/.obsidian/app.md": "- settings",
+		"vault/image.png":        "",
+		"graph/pages/Ideas.md":   "- existing",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	vault := filepath.Join(dir, "vault")
+
+	results, err := importer.Run(cfg, []string{vault}, importer.Options{DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("got %d results, want 3: %+v", len(results), results)
+	}
+	if _, err := os.Stat(filepath.Join(cfg.PagesDir, "work___Plan.md")); !os.IsNotExist(err) {
+		t.Errorf("dry run wrote a file: %v", err)
+	}
+
+	if _, err := importer.Run(cfg, []string{vault}, importer.Options{}); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		filepath.Join(cfg.PagesDir, "Ideas.md"):         "- existing",
+		filepath.Join(cfg.PagesDir, "work___Plan.md"):   "- # Plan\n",
+		filepath.Join(cfg.JournalsDir, "2025_01_02.md"): "- met [[Plan]]\n",
+	}
+	for path, want := range expected {
+		got, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Errorf("%s = %q, want %q", path, got, want)
+		}
+	}
+
+	for _, r := range results {
+		if r.Skipped != (filepath.Base(r.Target) == "Ideas.md") {
+			t.Errorf("%s skipped = %v", r.Target, r.Skipped)
+		}
+	}
+}
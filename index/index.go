@@ -0,0 +1,249 @@
+// Package index keeps the page names, aliases, lines and tasks of a graph
+// in memory so searches do not read every file. Files are added, updated
+// and removed one at a time as they change.
+package index
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/jrswab/lsq/config"
+	"github.com/jrswab/lsq/outline"
+	"github.com/jrswab/lsq/search"
+	"github.com/jrswab/lsq/todo"
+	"github.com/jrswab/lsq/trie"
+)
+
+// Hit is a line matching a regex search.
+type Hit struct {
+	Path string `json:"path"`
+	Line int    `json:"line"`
+	Text string `json:"text"`
+}
+
+// Task is a block starting with a task marker such as TODO.
+type Task struct {
+	Path    string `json:"path"`
+	Line    int    `json:"line"`
+	State   string `json:"state"`
+	Content string `json:"content"`
+}
+
+// file is what the index knows about a journal or page.
+type file struct {
+	journal bool
+	lines   []string
+	aliases []string
+	tasks   []Task
+}
+
+// Index holds the journals and pages of a graph.
+type Index struct {
+	cfg *config.Config
+
+	mu    sync.RWMutex
+	files map[string]*file
+	// trie is built from the names and aliases when a search needs it
+	// and dropped when a page changes.
+	trie *trie.Trie
+}
+
+// Build reads every journal and page of the graph of cfg, the same files
+// "lsq -r" searches without the daemon.
+func Build(cfg *config.Config) (*Index, error) {
+	x := &Index{cfg: cfg, files: make(map[string]*file)}
+
+	files, err := search.Files(cfg, search.Options{})
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range files {
+		if err := x.Update(f.Path); err != nil {
+			return nil, err
+		}
+	}
+	return x, nil
+}
+
+// Reload reads every journal and page again, for when changes were missed.
+func (x *Index) Reload() error {
+	fresh, err := Build(x.cfg)
+	if err != nil {
+		return err
+	}
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.files, x.trie = fresh.files, nil
+	return nil
+}
+
+// Update reads the file at path again, or forgets it when it was removed.
+// Files that are not journals or pages of the graph are ignored.
+func (x *Index) Update(path string) error {
+	journal, ok := x.kind(path)
+	if !ok {
+		return nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		x.Remove(path)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	content := string(data)
+	f := &file{journal: journal, lines: strings.Split(content, "\n")}
+	if !journal {
+		f.aliases = trie.Aliases(content)
+	}
+	outline.ParseFile(path, content).Walk(func(b *outline.Block) bool {
+		if state := todo.Marker(b.Content()); state != "" {
+			f.tasks = append(f.tasks, Task{Path: path, Line: b.Line + 1, State: state, Content: b.Content()})
+		}
+		return true
+	})
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.files[path] = f
+	if !journal {
+		x.trie = nil
+	}
+	return nil
+}
+
+// Remove forgets the file at path, or every file below it when it was a
+// directory.
+func (x *Index) Remove(path string) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	prefix := filepath.Clean(path) + string(filepath.Separator)
+	for p, f := range x.files {
+		if p != path && !strings.HasPrefix(p, prefix) {
+			continue
+		}
+		delete(x.files, p)
+		if !f.journal {
+			x.trie = nil
+		}
+	}
+}
+
+// kind reports whether path is a journal, and whether it is a journal or
+// page of the graph at all.
+func (x *Index) kind(path string) (journal, ok bool) {
+	return search.Kind(x.cfg, path)
+}
+
+// Len returns the number of journals and pages.
+func (x *Index) Len() int {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+	return len(x.files)
+}
+
+// Search returns the file names of the pages whose name or alias starts
+// with prefix, like "lsq -f".
+func (x *Index) Search(prefix string) []string {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	if x.trie == nil {
+		x.trie = x.buildTrie()
+	}
+	return x.trie.Search(prefix)
+}
+
+// buildTrie inserts the page names and then the aliases, which point at the
+// last page in name order that lists them, as trie.Init does. Like it, only
+// the pages directly in the pages directory are named.
+func (x *Index) buildTrie() *trie.Trie {
+	t := trie.NewTrie()
+	aliases := make(map[string]string)
+	for _, path := range x.paths(false) {
+		if filepath.Dir(path) != filepath.Clean(x.cfg.PagesDir) {
+			continue
+		}
+		name := filepath.Base(path)
+		t.InsertFileName(name)
+		for _, alias := range x.files[path].aliases {
+			aliases[alias] = name
+		}
+	}
+	for alias, name := range aliases {
+		t.InsertAlias(alias, name)
+	}
+	return t
+}
+
+// Grep returns the lines matching re, journals first and in file name
+// order like "lsq -r".
+func (x *Index) Grep(re *regexp.Regexp) []Hit {
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	hits := []Hit{}
+	for _, path := range x.all() {
+		for n, line := range x.files[path].lines {
+			if re.MatchString(line) {
+				hits = append(hits, Hit{Path: path, Line: n + 1, Text: line})
+			}
+		}
+	}
+	return hits
+}
+
+// Tasks lists the tasks in the given states, or in the open states when
+// there are none. The state "all" lists every task.
+func (x *Index) Tasks(states []string) []Task {
+	switch {
+	case len(states) == 0:
+		states = todo.OpenStates
+	case len(states) == 1 && strings.EqualFold(states[0], "all"):
+		states = todo.Markers
+	}
+
+	x.mu.RLock()
+	defer x.mu.RUnlock()
+
+	tasks := []Task{}
+	for _, path := range x.all() {
+		for _, task := range x.files[path].tasks {
+			for _, state := range states {
+				if strings.EqualFold(task.State, strings.TrimSpace(state)) {
+					tasks = append(tasks, task)
+					break
+				}
+			}
+		}
+	}
+	return tasks
+}
+
+// all returns the paths of the journals followed by the pages.
+func (x *Index) all() []string {
+	return append(x.paths(true), x.paths(false)...)
+}
+
+// paths returns the sorted paths of the journals or of the pages.
+func (x *Index) paths(journals bool) []string {
+	var paths []string
+	for path, f := range x.files {
+		if f.journal == journals {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
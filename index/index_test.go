@@ -0,0 +1,280 @@
+package index_test
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"testing"
+
+	"github.com/jrswab/lsq/config"
+	"github.com/jrswab/lsq/index"
+	"github.com/jrswab/lsq/search"
+)
+
+func newGraph(t *testing.T, files map[string]string) *config.Config {
+	t.Helper()
+	dir := t.TempDir()
+	cfg := &config.Config{
+		FileType:    "Markdown",
+		FileFmt:     "yyyy_MM_dd",
+		DirPath:     dir,
+		JournalsDir: filepath.Join(dir, "journals"),
+		PagesDir:    filepath.Join(dir, "pages"),
+	}
+	for _, sub := range []string{cfg.JournalsDir, cfg.PagesDir} {
+		if err := os.MkdirAll(sub, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for name, content := range files {
+		write(t, filepath.Join(dir, name), content)
+	}
+	return cfg
+}
+
+func write(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	cfg := newGraph(t, map[string]string{
+		"pages/project alpha.md": "alias:: Apollo\n\n- notes",
+		"pages/project beta.md":  "- notes",
+		"pages/.hidden.md":       "- hidden",
+		"journals/2024_01_01.md": "- alias:: Journal",
+	})
+	x, err := index.Build(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := map[string]struct {
+		prefix string
+		want   []string
+	}{
+		"name prefix": {
+			prefix: "project",
+			want:   []string{"project alpha.md", "project beta.md"},
+		},
+		"alias": {
+			prefix: "apo",
+			want:   []string{"project alpha.md"},
+		},
+		"hidden file": {
+			prefix: ".hid",
+			want:   nil,
+		},
+		"journal alias": {
+			prefix: "journal",
+			want:   nil,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			got := x.Search(tc.prefix)
+			slices.Sort(got)
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("Search(%q) = %v, want %v", tc.prefix, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	cfg := newGraph(t, map[string]string{
+		"pages/alpha.md": "- first",
+	})
+	x, err := index.Build(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	alpha := filepath.Join(cfg.PagesDir, "alpha.md")
+	gamma := filepath.Join(cfg.PagesDir, "gamma.md")
+
+	testCases := map[string]struct {
+		change func(t *testing.T)
+		prefix string
+		want   []string
+		len    int
+	}{
+		"new page": {
+			change: func(t *testing.T) {
+				write(t, gamma, "alias:: Greek\n")
+				if err := x.Update(gamma); err != nil {
+					t.Fatal(err)
+				}
+			},
+			prefix: "gre",
+			want:   []string{"gamma.md"},
+			len:    2,
+		},
+		"removed page": {
+			change: func(t *testing.T) {
+				if err := os.Remove(alpha); err != nil {
+					t.Fatal(err)
+				}
+				if err := x.Update(alpha); err != nil {
+					t.Fatal(err)
+				}
+			},
+			prefix: "alpha",
+			want:   nil,
+			len:    1,
+		},
+		"file outside the graph": {
+			change: func(t *testing.T) {
+				other := filepath.Join(cfg.DirPath, "notes.md")
+				write(t, other, "- outside")
+				if err := x.Update(other); err != nil {
+					t.Fatal(err)
+				}
+			},
+			prefix: "notes",
+			want:   nil,
+			len:    1,
+		},
+	}
+
+	for _, name := range []string{"new page", "removed page", "file outside the graph"} {
+		tc := testCases[name]
+		t.Run(name, func(t *testing.T) {
+			tc.change(t)
+			if got := x.Search(tc.prefix); !slices.Equal(got, tc.want) {
+				t.Errorf("Search(%q) = %v, want %v", tc.prefix, got, tc.want)
+			}
+			if got := x.Len(); got != tc.len {
+				t.Errorf("Len() = %d, want %d", got, tc.len)
+			}
+		})
+	}
+}
+
+func TestGrep(t *testing.T) {
+	cfg := newGraph(t, map[string]string{
+		"pages/b.md":             "- match in b",
+		"pages/a.md":             "- nothing\n- match in a",
+		"journals/2024_01_02.md": "- match in journal",
+	})
+	x, err := index.Build(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := x.Grep(regexp.MustCompile("match"))
+	want := []index.Hit{
+		{Path: filepath.Join(cfg.JournalsDir, "2024_01_02.md"), Line: 1, Text: "- match in journal"},
+		{Path: filepath.Join(cfg.PagesDir, "a.md"), Line: 2, Text: "- match in a"},
+		{Path: filepath.Join(cfg.PagesDir, "b.md"), Line: 1, Text: "- match in b"},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("Grep() = %v, want %v", got, want)
+	}
+}
+
+func TestFiles(t *testing.T) {
+	cfg := newGraph(t, map[string]string{
+		"journals/2024_01_01.md":      "- line",
+		"pages/a.md":                  "- line",
+		"pages/sub/b.md":              "- line",
+		"pages/.hidden.md":            "- line",
+		"pages/.git/c.md":             "- line",
+		"pages/.recycle/d.md":         "- line",
+		"pages/logseq/bak/pages/e.md": "- line",
+		"pages/image.png":             "line",
+	})
+	x, err := index.Build(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The index holds the files "lsq -r" searches without the daemon.
+	indexed := func() []string {
+		var paths []string
+		for _, hit := range x.Grep(regexp.MustCompile("line")) {
+			paths = append(paths, hit.Path)
+		}
+		return paths
+	}
+	files, err := search.Files(cfg, search.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want []string
+	for _, f := range files {
+		want = append(want, f.Path)
+	}
+	if got := indexed(); !slices.Equal(got, want) {
+		t.Errorf("indexed files = %v, want %v", got, want)
+	}
+
+	// Updates outside the searched directories are ignored.
+	skipped := filepath.Join(cfg.PagesDir, ".recycle", "f.md")
+	write(t, skipped, "- line")
+	if err := x.Update(skipped); err != nil {
+		t.Fatal(err)
+	}
+	if got := indexed(); !slices.Equal(got, want) {
+		t.Errorf("indexed files after update = %v, want %v", got, want)
+	}
+
+	// A removed directory takes its files with it.
+	x.Remove(filepath.Join(cfg.PagesDir, "sub"))
+	if got := indexed(); slices.Contains(got, filepath.Join(cfg.PagesDir, "sub", "b.md")) {
+		t.Errorf("indexed files after removing sub = %v", got)
+	}
+
+	// Only the pages directly in the pages directory are named, as with
+	// "lsq -f" without the daemon.
+	if got := x.Search("b"); got != nil {
+		t.Errorf("Search(b) = %v, want nothing", got)
+	}
+}
+
+func TestTasks(t *testing.T) {
+	cfg := newGraph(t, map[string]string{
+		"pages/work.md":          "- TODO write tests\n- DONE ship\n  - LATER review",
+		"journals/2024_01_03.md": "- NOW call",
+	})
+	x, err := index.Build(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := map[string]struct {
+		states []string
+		want   []string
+	}{
+		"open by default": {
+			states: nil,
+			want:   []string{"NOW call", "TODO write tests", "LATER review"},
+		},
+		"given states": {
+			states: []string{"done"},
+			want:   []string{"DONE ship"},
+		},
+		"all": {
+			states: []string{"all"},
+			want:   []string{"NOW call", "TODO write tests", "DONE ship", "LATER review"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			var got []string
+			for _, task := range x.Tasks(tc.states) {
+				got = append(got, task.Content)
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("Tasks(%v) = %v, want %v", tc.states, got, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/jrswab/lsq/lint"
+)
+
+func runLint(args []string) error {
+	fs, dir := newFlagSet("lint")
+	asJSON := fs.Bool("json", false, "Print the issues as a JSON array.")
+	fix := fs.Bool("fix", false, "Repair indentation, trailing newlines, misnamed, duplicate and empty journals.")
+	diff := fs.Bool("diff", false, "Print the fixes as a unified diff. Nothing is written unless -fix is also given.")
+	fs.Parse(args)
+
+	cfg, err := loadConfig(*dir)
+	if err != nil {
+		return err
+	}
+
+	if *fix || *diff {
+		fixes, err := lint.Fixes(cfg)
+		if err != nil {
+			return fmt.Errorf("error finding fixes: %v", err)
+		}
+
+		for _, f := range fixes {
+			if *diff {
+				fmt.Print(f.Diff())
+				continue
+			}
+			fmt.Printf("%s: %s\n", f.Path, strings.Join(f.Reasons, ", "))
+		}
+
+		if !*fix {
+			return nil
+		}
+
+		if err := lint.Apply(fixes); err != nil {
+			return fmt.Errorf("error applying fixes: %v", err)
+		}
+	}
+
+	issues, err := lint.Run(cfg)
+	if err != nil {
+		return fmt.Errorf("error linting graph: %v", err)
+	}
+
+	if *asJSON {
+		if issues == nil {
+			issues = []lint.Issue{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(issues); err != nil {
+			return err
+		}
+	} else {
+		for _, issue := range issues {
+			fmt.Println(issue)
+		}
+	}
+
+	if len(issues) > 0 {
+		return fmt.Errorf("found %d issue(s)", len(issues))
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+package lint
+
+import (
+	"fmt"
+	"strings"
+)
+
+// context is the number of unchanged lines shown around each change.
+const context = 3
+
+type edit struct {
+	op   byte // ' ', '-' or '+'
+	text string
+}
+
+// Diff returns a unified diff turning before into after. The names are used
+// in the file headers; "/dev/null" marks a created or deleted file.
+func Diff(oldName, newName, before, after string) string {
+	if before == after && oldName == newName {
+		return ""
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", oldName, newName)
+
+	a, b := splitLines(before), splitLines(after)
+	edits := myers(a, b)
+
+	for start := 0; start < len(edits); {
+		// Find the next change.
+		for start < len(edits) && edits[start].op == ' ' {
+			start++
+		}
+		if start == len(edits) {
+			break
+		}
+
+		// Extend the hunk while changes are close enough to share context.
+		end := start
+		for i := start; i < len(edits); i++ {
+			if edits[i].op != ' ' {
+				end = i + 1
+				continue
+			}
+			if i-end >= 2*context {
+				break
+			}
+		}
+
+		from := max(start-context, 0)
+		to := min(end+context, len(edits))
+
+		oldStart, newStart := 1, 1
+		for _, e := range edits[:from] {
+			if e.op != '+' {
+				oldStart++
+			}
+			if e.op != '-' {
+				newStart++
+			}
+		}
+
+		oldLen, newLen := 0, 0
+		var lines []string
+		for _, e := range edits[from:to] {
+			if e.op != '+' {
+				oldLen++
+			}
+			if e.op != '-' {
+				newLen++
+			}
+			lines = append(lines, string(e.op)+e.text)
+		}
+
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(oldStart, oldLen), hunkRange(newStart, newLen))
+		for _, line := range lines {
+			sb.WriteString(line + "\n")
+		}
+		start = to
+	}
+
+	return sb.String()
+}
+
+// hunkRange formats the start and length of a hunk, where an empty range
+// starts at the line before it.
+func hunkRange(start, length int) string {
+	if length == 0 {
+		start--
+	}
+	if length == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, length)
+}
+
+// splitLines splits content into lines, marking a missing final newline
+// the way diff does.
+func splitLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+	lines := strings.Split(content, "\n")
+	if lines[len(lines)-1] == "" {
+		return lines[:len(lines)-1]
+	}
+	lines[len(lines)-1] += "\n\\ No newline at end of file"
+	return lines
+}
+
+// myers returns the shortest edit script turning a into b.
+func myers(a, b []string) []edit {
+	n, m := len(a), len(b)
+	offset := n + m + 1
+	v := make([]int, 2*offset+1)
+
+	// trace holds v as it was before each step d.
+	var trace [][]int
+
+	var d int
+search:
+	for d = 0; d <= n+m; d++ {
+		trace = append(trace, append([]int(nil), v...))
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || k != d && v[offset+k-1] < v[offset+k+1] {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				break search
+			}
+		}
+	}
+
+	var edits []edit
+	x, y := n, m
+	for ; d >= 0; d-- {
+		v := trace[d]
+		k := x - y
+
+		var prevK int
+		if k == -d || k != d && v[offset+k-1] < v[offset+k+1] {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[offset+prevK]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			x--
+			y--
+			edits = append(edits, edit{' ', a[x]})
+		}
+		if d == 0 {
+			break
+		}
+		if x == prevX {
+			y--
+			edits = append(edits, edit{'+', b[y]})
+		} else {
+			x--
+			edits = append(edits, edit{'-', a[x]})
+		}
+	}
+
+	for i, j := 0, len(edits)-1; i < j; i, j = i+1, j-1 {
+		edits[i], edits[j] = edits[j], edits[i]
+	}
+	return edits
+}
@@ -0,0 +1,53 @@
+package lint_test
+
+import (
+	"testing"
+
+	"github.com/jrswab/lsq/lint"
+)
+
+func TestDiff(t *testing.T) {
+	tests := map[string]struct {
+		before   string
+		after    string
+		expected string
+	}{
+		"unchanged": {
+			before:   "- a\n",
+			after:    "- a\n",
+			expected: "",
+		},
+		"changed line": {
+			before:   "- a\n  - b\n- c\n",
+			after:    "- a\n\t- b\n- c\n",
+			expected: "--- old\n+++ new\n@@ -1,3 +1,3 @@\n - a\n-  - b\n+\t- b\n - c\n",
+		},
+		"missing newline": {
+			before:   "- a",
+			after:    "- a\n",
+			expected: "--- old\n+++ new\n@@ -1 +1 @@\n-- a\n\\ No newline at end of file\n+- a\n",
+		},
+		"separate hunks": {
+			before:   "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+			after:    "one\n2\n3\n4\n5\n6\n7\n8\n9\nten\n",
+			expected: "--- old\n+++ new\n@@ -1,4 +1,4 @@\n-1\n+one\n 2\n 3\n 4\n@@ -7,4 +7,4 @@\n 7\n 8\n 9\n-10\n+ten\n",
+		},
+		"deleted file": {
+			before:   "- a\n",
+			after:    "",
+			expected: "--- old\n+++ new\n@@ -1 +0,0 @@\n-- a\n",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			oldName, newName := "old", "new"
+			if tt.expected == "" {
+				newName = oldName
+			}
+			if got := lint.Diff(oldName, newName, tt.before, tt.after); got != tt.expected {
+				t.Errorf("Diff() =\n%s\nwant\n%s", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,270 @@
+package lint
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/jrswab/lsq/config"
+	"github.com/jrswab/lsq/graph"
+	"github.com/jrswab/lsq/org"
+	"github.com/jrswab/lsq/outline"
+	"github.com/jrswab/lsq/store"
+)
+
+// Fix is a change to a single file that repairs one or more issues.
+type Fix struct {
+	Path string
+	// NewPath is set when the file is renamed.
+	NewPath string
+	// Delete is set when the file is removed.
+	Delete bool
+	Before string
+	After  string
+	// Reasons describes what the fix repairs.
+	Reasons []string
+}
+
+// Target returns the path the fixed file is written to.
+func (f Fix) Target() string {
+	if f.NewPath != "" {
+		return f.NewPath
+	}
+	return f.Path
+}
+
+// Diff previews the fix as a unified diff.
+func (f Fix) Diff() string {
+	if f.Delete {
+		return Diff(f.Path, "/dev/null", f.Before, "")
+	}
+	return Diff(f.Path, f.Target(), f.Before, f.After)
+}
+
+// Fixes works out how to repair the mechanical issues of the graph: bullets
+// indented with spaces are indented with tabs, missing trailing newlines are
+// added, misnamed journals are renamed to the configured format or merged
+// into the journal of the same date and empty journals are deleted.
+func Fixes(cfg *config.Config) ([]Fix, error) {
+	files, err := graph.All(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	layout := config.ConvertDateFormat(cfg.FileFmt)
+
+	var (
+		fixes []Fix
+		// byDate groups the non-empty journals by the path they should have, without extension.
+		byDate  = make(map[string][]graph.File)
+		content = make(map[string]string, len(files))
+	)
+
+	for _, f := range files {
+		data, err := os.ReadFile(f.Path)
+		if err != nil {
+			return nil, err
+		}
+		content[f.Path] = string(data)
+
+		if !f.Journal {
+			fixes = appendFix(fixes, Fix{Path: f.Path, Before: string(data), After: format(f.Path, string(data))})
+			continue
+		}
+
+		if IsEmpty(string(data)) {
+			fixes = append(fixes, Fix{Path: f.Path, Delete: true, Before: string(data), Reasons: []string{"deleted empty journal"}})
+			continue
+		}
+
+		key := strings.TrimSuffix(f.Path, filepath.Ext(f.Path))
+		if f.Date.IsZero() {
+			if date, ok := JournalDate(f.Name); ok {
+				key = filepath.Join(filepath.Dir(f.Path), date.Format(layout))
+			}
+		}
+		byDate[key] = append(byDate[key], f)
+	}
+
+	keys := make([]string, 0, len(byDate))
+	for key := range byDate {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		fixes = append(fixes, mergeJournals(key, org.Ext(cfg.FileType), byDate[key], content)...)
+	}
+
+	return fixes, nil
+}
+
+// mergeJournals renames the journals of a date to key, the path without
+// extension, merging them when there are several. A correctly named journal
+// in the configured format, with extension ext, keeps its content first.
+func mergeJournals(key, ext string, journals []graph.File, content map[string]string) []Fix {
+	rank := func(f graph.File) int {
+		switch {
+		case f.Path == key+ext:
+			return 0
+		case strings.TrimSuffix(f.Path, filepath.Ext(f.Path)) == key:
+			return 1
+		}
+		return 2
+	}
+	sort.SliceStable(journals, func(i, j int) bool {
+		return rank(journals[i]) < rank(journals[j])
+	})
+
+	keep := journals[0]
+	target := key + filepath.Ext(keep.Path)
+
+	fix := Fix{Path: keep.Path, Before: content[keep.Path]}
+	if keep.Path != target {
+		fix.NewPath = target
+		fix.Reasons = append(fix.Reasons, "renamed to match the journal format")
+	}
+
+	merged := content[keep.Path]
+	var fixes []Fix
+	for _, j := range journals[1:] {
+		text := content[j.Path]
+		switch {
+		case outline.IsOrg(target) && !outline.IsOrg(j.Path):
+			text = org.ToOrg(text)
+		case !outline.IsOrg(target) && outline.IsOrg(j.Path):
+			text = org.ToMarkdown(text)
+		}
+
+		if merged != "" && !strings.HasSuffix(merged, "\n") {
+			merged += "\n"
+		}
+		merged += text
+
+		fix.Reasons = append(fix.Reasons, fmt.Sprintf("merged %s", filepath.Base(j.Path)))
+		fixes = append(fixes, Fix{
+			Path:    j.Path,
+			Delete:  true,
+			Before:  content[j.Path],
+			Reasons: []string{fmt.Sprintf("merged into %s", filepath.Base(target))},
+		})
+	}
+
+	fix.After = format(target, merged)
+	fix.Reasons = append(fix.Reasons, reasons(target, merged)...)
+	if fix.NewPath != "" || len(fix.Reasons) > 0 {
+		fixes = append([]Fix{fix}, fixes...)
+	}
+	return fixes
+}
+
+// appendFix adds fix when formatting changed the file.
+func appendFix(fixes []Fix, fix Fix) []Fix {
+	if fix.After == fix.Before {
+		return fixes
+	}
+	fix.Reasons = reasons(fix.Path, fix.Before)
+	return append(fixes, fix)
+}
+
+// reasons describes the formatting changes format makes to content.
+func reasons(path, content string) []string {
+	var out []string
+	if !outline.IsOrg(path) && normalizeIndent(content) != content {
+		out = append(out, "indented bullets with tabs")
+	}
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		out = append(out, "added trailing newline")
+	}
+	return out
+}
+
+// format normalises the indentation of Markdown pages and ends the file with a newline.
+func format(path, content string) string {
+	if !outline.IsOrg(path) {
+		content = normalizeIndent(content)
+	}
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	return content
+}
+
+// normalizeIndent indents every bullet with one tab per level, keeping the
+// continuation lines aligned with their bullet. Lines in code fences are
+// never taken for bullets; they move with the block holding the fence.
+func normalizeIndent(content string) string {
+	lines := strings.Split(content, "\n")
+	fenced := make(map[int]bool)
+	code := false
+	for i, line := range lines {
+		if fenceRe.MatchString(line) {
+			code = !code
+			continue
+		}
+		if code {
+			// Hide the line from the bullet parser behind a marker after
+			// its indentation, removed again below.
+			trimmed := strings.TrimLeft(line, " \t")
+			lines[i] = line[:len(line)-len(trimmed)] + fenceMark + trimmed
+			fenced[i] = true
+		}
+	}
+
+	page := outline.Parse(strings.Join(lines, "\n"))
+	changed := false
+
+	page.Walk(func(b *outline.Block) bool {
+		indent := strings.Repeat("\t", b.Level())
+		if indent == b.Indent {
+			return true
+		}
+
+		for i, line := range b.Lines {
+			if strings.HasPrefix(line, b.Indent) {
+				b.Lines[i] = indent + strings.TrimPrefix(line, b.Indent)
+			}
+		}
+		b.Indent = indent
+		changed = true
+		return true
+	})
+
+	if !changed {
+		return content
+	}
+
+	lines = strings.Split(page.String(), "\n")
+	for i := range fenced {
+		lines[i] = strings.Replace(lines[i], fenceMark, "", 1)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// fenceMark keeps code lines from being parsed as bullets while indenting.
+const fenceMark = "\x00"
+
+// Apply writes the fixes to disk. A file that changed since the fixes were
+// worked out is left alone and reported with store.ErrChanged.
+func Apply(fixes []Fix) error {
+	for _, fix := range fixes {
+		var err error
+		switch {
+		case fix.Delete:
+			err = store.Remove(fix.Path, []byte(fix.Before))
+		case fix.NewPath != "" && fix.NewPath != fix.Path:
+			if err = store.Create(fix.NewPath, []byte(fix.After)); err == nil {
+				err = store.Remove(fix.Path, []byte(fix.Before))
+			}
+		default:
+			err = store.Replace(fix.Path, []byte(fix.Before), []byte(fix.After))
+		}
+
+		if err != nil {
+			return fmt.Errorf("error fixing %s: %w", fix.Path, err)
+		}
+	}
+	return nil
+}
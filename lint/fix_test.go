@@ -0,0 +1,85 @@
+package lint_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jrswab/lsq/config"
+	"github.com/jrswab/lsq/lint"
+)
+
+func TestFixes(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.Config{
+		FileType:    "Markdown",
+		FileFmt:     "yyyy_MM_dd",
+		DirPath:     dir,
+		JournalsDir: filepath.Join(dir, "journals"),
+		PagesDir:    filepath.Join(dir, "pages"),
+	}
+
+	files := map[string]string{
+		"journals/2025_01_01.md": "- kept\n",
+		"journals/2025-01-01.md": "- merged\n",
+		"journals/20250102.md":   "- renamed",
+		"journals/2025_01_03.md": "-\n",
+		"journals/2025_01_04.md": "- fine\n",
+		"pages/spaces.md":        "- a\n  - b\n    text\n    - c\n",
+		"pages/org.org":          "* heading",
+		"pages/fence.md":         "- config\n  ```yaml\n  list:\n    - a\n  ```\n",
+		"pages/nested-fence.md":  "- a\n  - b\n    ```\n    - not a bullet\n        - nor this\n    ```\n    - c\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	fixes, err := lint.Fixes(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := lint.Apply(fixes); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		"journals/2025_01_01.md": "- kept\n- merged\n",
+		"journals/2025_01_02.md": "- renamed\n",
+		"journals/2025_01_04.md": "- fine\n",
+		"pages/spaces.md":        "- a\n\t- b\n\t  text\n\t\t- c\n",
+		"pages/org.org":          "* heading\n",
+		"pages/fence.md":         "- config\n  ```yaml\n  list:\n    - a\n  ```\n",
+		"pages/nested-fence.md":  "- a\n\t- b\n\t  ```\n\t  - not a bullet\n\t      - nor this\n\t  ```\n\t\t- c\n",
+	}
+	for name, want := range expected {
+		got, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+
+	for _, name := range []string{"journals/2025-01-01.md", "journals/20250102.md", "journals/2025_01_03.md"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); !os.IsNotExist(err) {
+			t.Errorf("%s still exists: %v", name, err)
+		}
+	}
+
+	// Fixing again finds nothing left to do.
+	fixes, err = lint.Fixes(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fixes) != 0 {
+		t.Errorf("second Fixes() = %+v", fixes)
+	}
+}
@@ -0,0 +1,409 @@
+// Package lint reports problems in a Logseq graph such as broken
+// links, orphaned pages and files Logseq will not read as expected.
+package lint
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jrswab/lsq/config"
+	"github.com/jrswab/lsq/graph"
+	"github.com/jrswab/lsq/outline"
+)
+
+// Checks reported by Run.
+const (
+	BrokenLink        = "broken-link"
+	OrphanPage        = "orphan-page"
+	DuplicateAlias    = "duplicate-alias"
+	MalformedProperty = "malformed-property"
+	MixedIndentation  = "mixed-indentation"
+	JournalName       = "journal-name"
+	EmptyJournal      = "empty-journal"
+)
+
+var (
+	// fileLayouts are the journal file names recognised as dates.
+	fileLayouts = []string{"2006_01_02", "2006-01-02", "2006.01.02", "20060102"}
+	// titleLayouts are the journal page titles recognised in links, besides fileLayouts.
+	titleLayouts = []string{"Jan 2, 2006", "January 2, 2006", "Mon, Jan 2, 2006", "2006/01/02", "02-01-2006", "01/02/2006"}
+)
+
+var (
+	linkRe       = regexp.MustCompile(`\[\[([^\[\]]+)\]\]`)
+	codeSpanRe   = regexp.MustCompile("`[^`]*`")
+	fenceRe      = regexp.MustCompile("^\\s*(- )?```")
+	ordinalRe    = regexp.MustCompile(`(\d)(st|nd|rd|th)\b`)
+	propertyLike = regexp.MustCompile("^[^\\s:`\\[#][^:`\\[]*::(\\s|$)")
+)
+
+// Issue is a single problem found in the graph.
+type Issue struct {
+	Check string `json:"check"`
+	Path  string `json:"path"`
+	// Line is one-based and zero when the issue concerns the whole file.
+	Line    int    `json:"line,omitempty"`
+	Message string `json:"message"`
+}
+
+// String formats the issue like the search results of lsq.
+func (i Issue) String() string {
+	if i.Line == 0 {
+		return fmt.Sprintf("%s: %s (%s)", i.Path, i.Message, i.Check)
+	}
+	return fmt.Sprintf("%s#%d: %s (%s)", i.Path, i.Line, i.Message, i.Check)
+}
+
+// file is a graph file with the content lint works on.
+type file struct {
+	graph.File
+	lines   []string
+	aliases []string
+	// refs holds the page names referenced by the file.
+	refs []string
+}
+
+// Run checks every journal and page of the graph and returns the issues
+// sorted by path and line.
+func Run(cfg *config.Config) ([]Issue, error) {
+	entries, err := graph.All(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]*file, 0, len(entries))
+	for _, e := range entries {
+		data, err := os.ReadFile(e.Path)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, read(e, string(data)))
+	}
+
+	g := newIndex(files)
+
+	var issues []Issue
+	issues = append(issues, duplicateAliases(files, g)...)
+	for _, f := range files {
+		issues = append(issues, brokenLinks(f, g)...)
+		issues = append(issues, lines(f)...)
+		issues = append(issues, journal(cfg, f)...)
+	}
+	issues = append(issues, orphans(files, g)...)
+
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].Path != issues[j].Path {
+			return issues[i].Path < issues[j].Path
+		}
+		return issues[i].Line < issues[j].Line
+	})
+	return issues, nil
+}
+
+// read collects the aliases and references of a file.
+func read(e graph.File, content string) *file {
+	f := &file{File: e, lines: strings.Split(content, "\n")}
+	if len(f.lines) > 0 && f.lines[len(f.lines)-1] == "" {
+		f.lines = f.lines[:len(f.lines)-1]
+	}
+
+	code := false
+	for i, line := range f.lines {
+		if fenceRe.MatchString(line) {
+			code = !code
+			continue
+		}
+		if code {
+			continue
+		}
+
+		text := codeSpanRe.ReplaceAllString(line, "")
+		f.refs = append(f.refs, outline.Links(text)...)
+
+		key, value, ok := property(f.Path, text)
+		if !ok {
+			continue
+		}
+
+		switch strings.ToLower(key) {
+		case "alias":
+			// Only page properties before the first block define aliases.
+			if beforeFirstBlock(f.Path, f.lines[:i]) {
+				f.aliases = append(f.aliases, outline.PropertyValues(value)...)
+			}
+		case "tags":
+			f.refs = append(f.refs, outline.PropertyValues(value)...)
+		}
+	}
+
+	return f
+}
+
+// property parses "key:: value" lines and the "#+key: value" page properties of Org files.
+func property(path, line string) (key, value string, ok bool) {
+	trimmed := strings.TrimSpace(line)
+	if outline.IsOrg(path) {
+		if k, v, found := strings.Cut(strings.TrimPrefix(trimmed, "#+"), ":"); found && strings.HasPrefix(trimmed, "#+") {
+			return k, strings.TrimSpace(v), true
+		}
+		return "", "", false
+	}
+	return outline.ParseProperty(strings.TrimPrefix(trimmed, "- "))
+}
+
+func beforeFirstBlock(path string, lines []string) bool {
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if outline.IsOrg(path) && strings.HasPrefix(line, "*") {
+			return false
+		}
+		if !outline.IsOrg(path) && (trimmed == "-" || strings.HasPrefix(trimmed, "- ")) {
+			return false
+		}
+	}
+	return true
+}
+
+// index maps lower case page names and aliases to their files.
+type index struct {
+	names   map[string]*file
+	aliases map[string]*file
+}
+
+func newIndex(files []*file) *index {
+	g := &index{
+		names:   make(map[string]*file, len(files)),
+		aliases: make(map[string]*file),
+	}
+	for _, f := range files {
+		g.names[strings.ToLower(f.Name)] = f
+		for _, alias := range f.aliases {
+			if _, ok := g.aliases[strings.ToLower(alias)]; !ok {
+				g.aliases[strings.ToLower(alias)] = f
+			}
+		}
+	}
+	return g
+}
+
+// lookup finds the file of a page by name or alias.
+func (g *index) lookup(name string) *file {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if f, ok := g.names[name]; ok {
+		return f
+	}
+	return g.aliases[name]
+}
+
+// brokenLinks reports "[[links]]" to pages that do not exist.
+func brokenLinks(f *file, g *index) []Issue {
+	var issues []Issue
+	code := false
+	for i, line := range f.lines {
+		if fenceRe.MatchString(line) {
+			code = !code
+			continue
+		}
+		if code {
+			continue
+		}
+
+		for _, m := range linkRe.FindAllStringSubmatch(codeSpanRe.ReplaceAllString(line, ""), -1) {
+			if g.lookup(m[1]) != nil || strings.Contains(m[1], "://") || isJournalTitle(m[1]) {
+				continue
+			}
+			issues = append(issues, Issue{
+				Check:   BrokenLink,
+				Path:    f.Path,
+				Line:    i + 1,
+				Message: fmt.Sprintf("link to missing page [[%s]]", m[1]),
+			})
+		}
+	}
+	return issues
+}
+
+// orphans reports pages that no other file links to, tags or embeds.
+// Namespace parents count as referenced by their children.
+func orphans(files []*file, g *index) []Issue {
+	referenced := make(map[string]bool)
+	for _, f := range files {
+		for _, ref := range f.refs {
+			if target := g.lookup(ref); target != nil && target != f {
+				referenced[target.Path] = true
+			}
+		}
+
+		parts := strings.Split(f.Name, "/")
+		for i := 1; i < len(parts); i++ {
+			if parent, ok := g.names[strings.ToLower(strings.Join(parts[:i], "/"))]; ok {
+				referenced[parent.Path] = true
+			}
+		}
+	}
+
+	var issues []Issue
+	for _, f := range files {
+		// The contents page is shown in the sidebar rather than linked to.
+		if f.Journal || referenced[f.Path] || strings.EqualFold(f.Name, "contents") {
+			continue
+		}
+		issues = append(issues, Issue{
+			Check:   OrphanPage,
+			Path:    f.Path,
+			Message: fmt.Sprintf("no other page references %q", f.Name),
+		})
+	}
+	return issues
+}
+
+// duplicateAliases reports aliases claimed by several files or matching another page's name.
+func duplicateAliases(files []*file, g *index) []Issue {
+	var issues []Issue
+	owners := make(map[string]*file)
+	for _, f := range files {
+		for _, alias := range f.aliases {
+			key := strings.ToLower(alias)
+			if page, ok := g.names[key]; ok && page != f {
+				issues = append(issues, Issue{
+					Check:   DuplicateAlias,
+					Path:    f.Path,
+					Message: fmt.Sprintf("alias %q is the name of %s", alias, page.Path),
+				})
+				continue
+			}
+			if owner, ok := owners[key]; ok && owner != f {
+				issues = append(issues, Issue{
+					Check:   DuplicateAlias,
+					Path:    f.Path,
+					Message: fmt.Sprintf("alias %q is also an alias of %s", alias, owner.Path),
+				})
+				continue
+			}
+			owners[key] = f
+		}
+	}
+	return issues
+}
+
+// lines reports malformed properties and mixed indentation in Markdown files.
+func lines(f *file) []Issue {
+	if outline.IsOrg(f.Path) {
+		return nil
+	}
+
+	var (
+		issues     []Issue
+		tabs       bool
+		spaceLines []int
+		code       bool
+	)
+
+	for i, line := range f.lines {
+		if fenceRe.MatchString(line) {
+			code = !code
+			continue
+		}
+		if code {
+			continue
+		}
+
+		trimmed := strings.TrimLeft(line, " \t")
+		if indent := line[:len(line)-len(trimmed)]; trimmed == "-" || strings.HasPrefix(trimmed, "- ") {
+			switch {
+			case strings.Contains(indent, " ") && strings.Contains(indent, "\t"):
+				issues = append(issues, Issue{
+					Check:   MixedIndentation,
+					Path:    f.Path,
+					Line:    i + 1,
+					Message: "bullet is indented with both tabs and spaces",
+				})
+			case strings.Contains(indent, "\t"):
+				tabs = true
+			case indent != "":
+				spaceLines = append(spaceLines, i+1)
+			}
+			trimmed = strings.TrimPrefix(strings.TrimPrefix(trimmed, "-"), " ")
+		}
+
+		text := codeSpanRe.ReplaceAllString(trimmed, "")
+		if propertyLike.MatchString(text) && !outline.IsProperty(text) {
+			issues = append(issues, Issue{
+				Check:   MalformedProperty,
+				Path:    f.Path,
+				Line:    i + 1,
+				Message: fmt.Sprintf("malformed property %q", strings.TrimSpace(trimmed)),
+			})
+		}
+	}
+
+	if tabs && len(spaceLines) > 0 {
+		issues = append(issues, Issue{
+			Check:   MixedIndentation,
+			Path:    f.Path,
+			Line:    spaceLines[0],
+			Message: fmt.Sprintf("%d bullet(s) are indented with spaces while the rest of the file uses tabs", len(spaceLines)),
+		})
+	}
+
+	return issues
+}
+
+// journal reports misnamed and empty journals.
+func journal(cfg *config.Config, f *file) []Issue {
+	if !f.Journal {
+		return nil
+	}
+
+	var issues []Issue
+	if f.Date.IsZero() {
+		msg := fmt.Sprintf("file name does not match the journal format %q", cfg.FileFmt)
+		if date, ok := JournalDate(f.Name); ok {
+			msg += fmt.Sprintf(", expected %s", date.Format(config.ConvertDateFormat(cfg.FileFmt))+filepath.Ext(f.Path))
+		}
+		issues = append(issues, Issue{Check: JournalName, Path: f.Path, Message: msg})
+	}
+
+	if IsEmpty(strings.Join(f.lines, "\n")) {
+		issues = append(issues, Issue{Check: EmptyJournal, Path: f.Path, Message: "journal has no content"})
+	}
+	return issues
+}
+
+// IsEmpty reports whether a page has no content besides empty bullets or headings.
+func IsEmpty(content string) bool {
+	for _, line := range strings.Split(content, "\n") {
+		switch strings.TrimSpace(line) {
+		case "", "-", "*":
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// JournalDate parses a journal file name written in any of the common date formats.
+func JournalDate(name string) (time.Time, bool) {
+	for _, layout := range fileLayouts {
+		if date, err := time.ParseInLocation(layout, name, time.Local); err == nil {
+			return date, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// isJournalTitle reports whether a link points at a journal page such as "Jan 2nd, 2025".
+func isJournalTitle(name string) bool {
+	name = ordinalRe.ReplaceAllString(strings.TrimSpace(name), "$1")
+	for _, layout := range append(titleLayouts, fileLayouts...) {
+		if _, err := time.Parse(layout, name); err == nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,100 @@
+package lint_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/jrswab/lsq/config"
+	"github.com/jrswab/lsq/lint"
+)
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &config.Config{
+		FileType:    "Markdown",
+		FileFmt:     "yyyy_MM_dd",
+		DirPath:     dir,
+		JournalsDir: filepath.Join(dir, "journals"),
+		PagesDir:    filepath.Join(dir, "pages"),
+	}
+
+	files := map[string]string{
+		"journals/2025_01_01.md": "- met [[Alpha]] and [[a]] about [[Missing]]\n- see [[Jan 1st, 2025]] and `[[code]]`\n",
+		"journals/2025_01_02.md": "- \n",
+		"journals/2025-01-03.md": "- #beta\n",
+		"pages/Alpha.md":         "alias:: a\n\n- text\n",
+		"pages/beta.md":          "alias:: a, Alpha\n\n- parent\n\t- tab child\n    - space child\n\t  text\n  \t- both\n",
+		"pages/orphan.md":        "- status :: broken\n- good:: property\n",
+		"pages/ns___child.md":    "- [[ns]]\n",
+		"pages/ns.md":            "- namespace parent\n",
+		"pages/contents.md":      "- [[ns/child]]\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	issues, err := lint.Run(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type found struct {
+		Check string
+		File  string
+		Line  int
+	}
+
+	var got []found
+	for _, issue := range issues {
+		rel, _ := filepath.Rel(dir, issue.Path)
+		got = append(got, found{issue.Check, filepath.ToSlash(rel), issue.Line})
+	}
+
+	want := []found{
+		{lint.JournalName, "journals/2025-01-03.md", 0},
+		{lint.BrokenLink, "journals/2025_01_01.md", 1},
+		{lint.EmptyJournal, "journals/2025_01_02.md", 0},
+		{lint.DuplicateAlias, "pages/beta.md", 0},
+		{lint.DuplicateAlias, "pages/beta.md", 0},
+		{lint.MixedIndentation, "pages/beta.md", 5},
+		{lint.MixedIndentation, "pages/beta.md", 7},
+		{lint.OrphanPage, "pages/orphan.md", 0},
+		{lint.MalformedProperty, "pages/orphan.md", 1},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Run() =\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestJournalDate(t *testing.T) {
+	tests := map[string]struct {
+		input string
+		ok    bool
+	}{
+		"underscores": {input: "2025_01_02", ok: true},
+		"dashes":      {input: "2025-01-02", ok: true},
+		"compact":     {input: "20250102", ok: true},
+		"not a date":  {input: "notes", ok: false},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			date, ok := lint.JournalDate(tt.input)
+			if ok != tt.ok {
+				t.Fatalf("JournalDate(%q) ok = %v, want %v", tt.input, ok, tt.ok)
+			}
+			if ok && date.Format("2006-01-02") != "2025-01-02" {
+				t.Errorf("JournalDate(%q) = %v", tt.input, date)
+			}
+		})
+	}
+}
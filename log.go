@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/jrswab/lsq/config"
+	"github.com/jrswab/lsq/git"
+)
+
+func runLog(args []string) error {
+	fs, dir := newFlagSet("log")
+	specDate := fs.String("s", "", "Show the history of this journal. Use yyyy-MM-dd after the flag.")
+	patch := fs.Bool("patch", false, "Print the changes made by each commit.")
+	fs.Parse(args)
+
+	if fs.NArg() > 1 {
+		return fmt.Errorf("usage: lsq log [-patch] [-s yyyy-MM-dd | page]")
+	}
+
+	cfg, err := loadConfig(*dir)
+	if err != nil {
+		return err
+	}
+
+	path, err := historyPath(cfg, fs.Arg(0), *specDate)
+	if err != nil {
+		return err
+	}
+
+	commits, err := git.Log(cfg.DirPath, path)
+	if err != nil {
+		return fmt.Errorf("error reading history: %v", err)
+	}
+
+	for _, c := range commits {
+		fmt.Printf("%s %s  %s\n", c.Hash[:7], c.Time.Local().Format("2006-01-02 15:04"), c.Subject)
+		if !*patch {
+			continue
+		}
+		out, err := git.Show(cfg.DirPath, c.Hash, path)
+		if err != nil {
+			return err
+		}
+		fmt.Print(out)
+	}
+	return nil
+}
+
+func runDiff(args []string) error {
+	fs, dir := newFlagSet("diff")
+	from := fs.String("from", time.Now().AddDate(0, 0, -7).Format("2006-01-02"), "Compare the graph as it was at the start of this day. Use yyyy-MM-dd.")
+	to := fs.String("to", time.Now().Format("2006-01-02"), "Compare with the graph as it was at the end of this day. Use yyyy-MM-dd.")
+	specDate := fs.String("s", "", "Only show the changes to this journal. Use yyyy-MM-dd after the flag.")
+	fs.Parse(args)
+
+	if fs.NArg() > 1 {
+		return fmt.Errorf("usage: lsq diff [-from yyyy-MM-dd] [-to yyyy-MM-dd] [-s yyyy-MM-dd | page]")
+	}
+
+	fromDate, err := time.ParseInLocation("2006-01-02", *from, time.Local)
+	if err != nil {
+		return fmt.Errorf("error parsing -from date: %v", err)
+	}
+
+	toDate, err := time.ParseInLocation("2006-01-02", *to, time.Local)
+	if err != nil {
+		return fmt.Errorf("error parsing -to date: %v", err)
+	}
+
+	cfg, err := loadConfig(*dir)
+	if err != nil {
+		return err
+	}
+
+	var paths []string
+	if fs.NArg() > 0 || *specDate != "" {
+		path, err := historyPath(cfg, fs.Arg(0), *specDate)
+		if err != nil {
+			return err
+		}
+		paths = append(paths, path)
+	}
+
+	out, err := git.Diff(cfg.DirPath, fromDate, toDate.AddDate(0, 0, 1), paths)
+	if err != nil {
+		return fmt.Errorf("error comparing dates: %v", err)
+	}
+	fmt.Print(out)
+	return nil
+}
+
+// historyPath returns the path of a page file name or of the journal of a
+// yyyy-MM-dd date relative to the graph. Without either it is today's journal.
+func historyPath(cfg *config.Config, page, date string) (string, error) {
+	path := filepath.Join(cfg.PagesDir, page)
+	if page == "" {
+		if date == "" {
+			date = time.Now().Format("2006-01-02")
+		}
+
+		var err error
+		if path, err = journalPath(cfg, date); err != nil {
+			return "", err
+		}
+	}
+
+	return filepath.Rel(cfg.DirPath, path)
+}
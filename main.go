@@ -1,7 +1,8 @@
 package main
 
 import (
-	"bufio"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"io"
@@ -9,70 +10,112 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
-	"strings"
 	"time"
 
 	"github.com/jrswab/lsq/config"
+	"github.com/jrswab/lsq/daemon"
+	"github.com/jrswab/lsq/search"
 	"github.com/jrswab/lsq/system"
 	"github.com/jrswab/lsq/trie"
 )
 
 const semVer string = "1.4.0"
 
-// Search regex pattern in given file, print matching lines
-func searchInFile(filePath string, pattern *regexp.Regexp) error {
-	file, err := os.Open(filePath)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	scanner := bufio.NewScanner(file)
-	lineNumber := 0
-	for scanner.Scan() {
-		lineNumber++
-		line := scanner.Text()
-		if pattern.MatchString(line) {
-			fmt.Printf("%s#%d: %s\n", filePath, lineNumber, line)
+// errFound stops a regex search at the first hit when it is opened with -o.
+var errFound = errors.New("found")
+
+// searchGraph calls found with the lines of the journals and pages matching
+// pattern, asking the daemon of the graph when one is running and reading
+// the directories otherwise.
+func searchGraph(cfg *config.Config, pattern *regexp.Regexp, found func(path string, line int, text string) error) error {
+	hits, err := daemon.Grep(cfg.DirPath, pattern.String())
+	if err == nil {
+		for _, hit := range hits {
+			if err := found(hit.Path, hit.Line, hit.Text); err != nil {
+				return err
+			}
 		}
+		return nil
 	}
-	return scanner.Err()
-}
+	warnDaemon(err)
 
-// Search regex pattern in all files within directory
-func searchInDirectory(directory string, pattern *regexp.Regexp) error {
-	return filepath.Walk(directory, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-		if !info.IsDir() {
-			err := searchInFile(path, pattern)
-			if err != nil {
+	files, err := search.Files(cfg, search.Options{})
+	if err != nil {
+		fmt.Println("Error searching directory:", err)
+		return nil
+	}
+	err = search.Run(context.Background(), files, pattern, search.Options{}, func(r search.Result) error {
+		for _, line := range r.Lines {
+			if err := found(r.Path, line.Number, line.Text); err != nil {
 				return err
 			}
 		}
 		return nil
 	})
+	if err == errFound {
+		return err
+	}
+	if err != nil {
+		fmt.Println("Error searching directory:", err)
+	}
+	return nil
+}
+
+// searchPages returns the file names of the pages matching prefix, asking
+// the daemon of the graph when one is running.
+func searchPages(cfg *config.Config, prefix string) ([]string, error) {
+	results, err := daemon.Search(cfg.DirPath, prefix)
+	if err == nil {
+		return results, nil
+	}
+	warnDaemon(err)
+
+	searchTrie, err := trie.Init(cfg.PagesDir)
+	if err != nil {
+		return nil, err
+	}
+	return searchTrie.Search(prefix), nil
+}
+
+// warnDaemon reports a daemon that is running but failed to answer before
+// lsq reads the files itself.
+func warnDaemon(err error) {
+	if !errors.Is(err, daemon.ErrNotRunning) {
+		fmt.Fprintf(os.Stderr, "Warning: %v; searching without the daemon\n", err)
+	}
 }
 
 func main() {
+	// Subcommands such as "lsq clock report" have their own flags.
+	if runCommand(os.Args[1:]) {
+		return
+	}
+
 	// File Path Override
 	lsqDirPath := flag.String("d", "", "The path to the Logseq directory to use.")
 
 	apndStdin := flag.Bool("A", false, "Append STDIN to the current journal page. This will not open $EDITOR.")
 	apnd := flag.String("a", "", "Append text to the current journal page. This will not open $EDITOR.")
 	catFile := flag.Bool("c", false, "Print journal or page content to STDOUT instead of opening an editor.")
-	editorType := flag.String("e", "", "The external editor to use. Will use $EDITOR when blank or omitted.")
+	editorType := flag.String("e", "", "The editor command to use, e.g. \"code --wait\". Will use :editor/command or $EDITOR when blank or omitted. Use lsq for the built-in outliner.")
 	cliSearch := flag.String("f", "", "Search by file name in your pages directory.")
-	indent := flag.Int("i", 0, "Absolute indentation level (number of tab characters) for appended text. Requires -a or -A.")
+	indent := flag.Int("i", 0, "Absolute indentation level (number of tab characters, or extra heading stars in Org files) for appended text. Requires -a or -A.")
 	daysAgo := flag.Int("n", 0, "Number of days ago to target for the journal entry.")
-	openFirstResult := flag.Bool("o", false, "Open the first result from search automatically.")
+	openFirstResult := flag.Bool("o", false, "Open the first result from search automatically, at the matching line for -r.")
 	pageToOpen := flag.String("p", "", "Open a specific page from the pages directory. Must be a file name with extension.")
+	renderOut := flag.Bool("R", false, "Render the outline for terminal display when printing with -c.")
 	regexSearch := flag.String("r", "", "Search by regex pattern in pages directory.")
 	specDate := flag.String("s", "", "Open a specific journal. Use yyyy-MM-dd after the flag.")
+	taskLine := flag.Int("t", 0, "Cycle the TODO state of the block at the given line number of the journal or page.")
 	version := flag.Bool("v", false, "Display current lsq version")
+	refChildren := flag.Bool("x", false, "Include the children of referenced blocks when printing with -c.")
 	yesterday := flag.Bool("y", false, "Open yesterday's journal page")
 
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage of %s:\n", os.Args[0])
+		flag.PrintDefaults()
+		printCommands()
+	}
 	flag.Parse()
 
 	if *version {
@@ -90,6 +133,11 @@ func main() {
 		os.Exit(1)
 	}
 
+	if *taskLine < 0 {
+		fmt.Fprintln(os.Stderr, "Error: -t must be a positive line number")
+		os.Exit(1)
+	}
+
 	if *indent > 0 && *apnd == "" && !*apndStdin {
 		fmt.Fprintln(os.Stderr, "Error: -i requires -a or -A")
 		os.Exit(1)
@@ -104,32 +152,19 @@ func main() {
 		*apnd = string(content)
 	}
 
-	cfg, err := config.Load()
-	if err != nil && !os.IsNotExist(err) {
-		// The user has a config file but we couldn't read it.
-		// Report the error instead of ignoring their configuration.
-		log.Printf("Error loading configuration: %v\n", err)
+	cfg, err := loadConfig(*lsqDirPath)
+	if err != nil {
+		log.Printf("%v\n", err)
 		os.Exit(1)
 	}
-
-	// When this flag is used override the config.
-	if *lsqDirPath != "" {
-		expanded, expandErr := config.ExpandPath(*lsqDirPath)
-		if expandErr != nil {
-			log.Printf("Error expanding directory path: %v\n", expandErr)
-			os.Exit(1)
-		}
-		cfg.DirPath = expanded
-		cfg.JournalsDir = filepath.Join(cfg.DirPath, "journals")
-		cfg.PagesDir = filepath.Join(cfg.DirPath, "pages")
-	}
+	defer afterChanges()
 
 	if *pageToOpen != "" {
 		pagePath := filepath.Join(cfg.PagesDir, *pageToOpen)
 
 		// Print page content to STDOUT and exit.
 		if *catFile {
-			if err := system.PrintFile(pagePath); err != nil {
+			if err := printPage(cfg, pagePath, *renderOut, *refChildren); err != nil {
 				log.Printf("Error printing page: %v\n", err)
 				os.Exit(1)
 			}
@@ -138,7 +173,7 @@ func main() {
 
 		// Append to page and exit.
 		if *apnd != "" {
-			err := system.AppendToFile(pagePath, *apnd, *indent)
+			err := appendText(cfg, pagePath, *apnd, *indent)
 			if err != nil {
 				log.Printf("Error appending data to file: %v\n", err)
 				os.Exit(1)
@@ -147,8 +182,21 @@ func main() {
 			return
 		}
 
+		// Cycle a task on the page and exit.
+		if *taskLine > 0 {
+			operation = "task"
+			if err := system.CycleTask(pagePath, *taskLine, time.Now()); err != nil {
+				log.Printf("Error cycling task: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+
 		// Open page in default editor if specified:
-		system.LoadEditor(*editorType, pagePath)
+		if err := editFile(cfg, *editorType, pagePath, 0); err != nil {
+			log.Printf("%v\n", err)
+			os.Exit(1)
+		}
 		return
 	}
 
@@ -160,36 +208,54 @@ func main() {
 			os.Exit(1)
 		}
 
+		found := func(path string, line int, text string) error {
+			fmt.Printf("%s#%d: %s\n", path, line, text)
+			return nil
+		}
+
+		// Remember the first hit to open it at its line.
+		var hitPath string
+		var hitLine int
+		if *openFirstResult {
+			found = func(path string, line int, text string) error {
+				hitPath, hitLine = path, line
+				return errFound
+			}
+		}
+
 		// Search in the directories
-		for _, searchDirectory := range []string{cfg.JournalsDir, cfg.PagesDir} {
-			err = searchInDirectory(searchDirectory, pattern)
-			if err != nil {
-				fmt.Println("Error searching directory:", err)
+		searchGraph(cfg, pattern, found)
+
+		if *openFirstResult {
+			if hitPath == "" {
+				fmt.Println("No results found")
+				return
+			}
+			if err := editFile(cfg, *editorType, hitPath, hitLine); err != nil {
+				log.Printf("%v\n", err)
+				os.Exit(1)
 			}
 		}
 
 		return
 	}
 
-	// Init Search only when "-f" is passed
-	var searchTrie *trie.Trie
-	if !strings.EqualFold(*cliSearch, "") {
-		searchTrie, err = trie.Init(cfg.PagesDir)
+	if *cliSearch != "" {
+		results, err := searchPages(cfg, *cliSearch)
 		if err != nil {
 			log.Printf("error loading pages directory for search: %v\n", err)
 			os.Exit(1)
 		}
-	}
-
-	if *cliSearch != "" {
-		results := searchTrie.Search(*cliSearch)
 		if len(results) < 1 {
 			fmt.Println("No results found")
 			return
 		}
 
 		if *openFirstResult {
-			system.LoadEditor(*editorType, fmt.Sprintf("%s/%s", cfg.PagesDir, results[0]))
+			if err := editFile(cfg, *editorType, fmt.Sprintf("%s/%s", cfg.PagesDir, results[0]), 0); err != nil {
+				log.Printf("%v\n", err)
+				os.Exit(1)
+			}
 			return
 		}
 
@@ -230,7 +296,7 @@ func main() {
 
 	// Print journal content to STDOUT and exit.
 	if *catFile {
-		if err := system.PrintFile(journalPath); err != nil {
+		if err := printPage(cfg, journalPath, *renderOut, *refChildren); err != nil {
 			log.Printf("Error printing journal: %v\n", err)
 			os.Exit(1)
 		}
@@ -238,7 +304,7 @@ func main() {
 	}
 
 	if *apnd != "" {
-		err := system.AppendToFile(journalPath, *apnd, *indent)
+		err := appendText(cfg, journalPath, *apnd, *indent)
 		if err != nil {
 			log.Printf("Error appending data to file: %v\n", err)
 			os.Exit(1)
@@ -248,5 +314,17 @@ func main() {
 		return
 	}
 
-	system.LoadEditor(*editorType, journalPath)
+	if *taskLine > 0 {
+		operation = "task"
+		if err := system.CycleTask(journalPath, *taskLine, time.Now()); err != nil {
+			log.Printf("Error cycling task: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if err := editFile(cfg, *editorType, journalPath, 0); err != nil {
+		log.Printf("%v\n", err)
+		os.Exit(1)
+	}
 }
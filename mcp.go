@@ -0,0 +1,32 @@
+package main
+
+import (
+	"os"
+
+	"github.com/jrswab/lsq/hooks"
+	"github.com/jrswab/lsq/mcp"
+	"github.com/jrswab/lsq/server"
+)
+
+func runMCP(args []string) error {
+	fs, dir := newFlagSet("mcp")
+	fs.Parse(args)
+
+	cfg, err := loadConfig(*dir)
+	if err != nil {
+		return err
+	}
+
+	graph := server.New(cfg)
+	graph.Append = func(path, text string, indent int) error {
+		return appendText(cfg, path, text, indent)
+	}
+	graph.Changed = func(op string) {
+		operation = op
+		afterChanges()
+	}
+
+	// Stdout carries the protocol, so hooks print to stderr.
+	hooks.Output = os.Stderr
+	return mcp.New(graph, semVer).Serve(os.Stdin, os.Stdout)
+}
@@ -0,0 +1,258 @@
+// Package mcp serves the graph to assistants over the Model Context
+// Protocol: JSON-RPC 2.0 messages, one per line, on stdin and stdout.
+package mcp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/jrswab/lsq/server"
+)
+
+// ProtocolVersions are the MCP revisions understood, newest first.
+var ProtocolVersions = []string{"2025-06-18", "2025-03-26", "2024-11-05"}
+
+// JSON-RPC error codes.
+const (
+	codeParse          = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeInternal       = -32603
+)
+
+// resourcePrefix starts the URI of every page resource.
+const resourcePrefix = "lsq://pages/"
+
+// maxMessage is the longest message read, large enough for whole pages.
+const maxMessage = 16 << 20
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *rpcError) Error() string {
+	return e.Message
+}
+
+// Server answers MCP requests with the operations of a graph server.
+type Server struct {
+	graph   *server.Server
+	version string
+
+	out *json.Encoder
+	// mu keeps the messages written to out whole.
+	mu sync.Mutex
+}
+
+// New returns an MCP server for graph reporting lsq version.
+func New(graph *server.Server, version string) *Server {
+	return &Server{graph: graph, version: version}
+}
+
+// Serve answers the requests read from r on w until r is closed.
+func (s *Server) Serve(r io.Reader, w io.Writer) error {
+	s.out = json.NewEncoder(w)
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxMessage)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var req request
+		if err := json.Unmarshal([]byte(line), &req); err != nil {
+			s.send(response{ID: json.RawMessage("null"), Error: &rpcError{Code: codeParse, Message: "parse error: " + err.Error()}})
+			continue
+		}
+		if req.JSONRPC != "2.0" || req.Method == "" {
+			s.send(response{ID: idOrNull(req.ID), Error: &rpcError{Code: codeInvalidRequest, Message: "invalid request"}})
+			continue
+		}
+
+		result, err := s.handle(req)
+		// Notifications have no id and get no response.
+		if req.ID == nil {
+			continue
+		}
+
+		if result == nil {
+			result = struct{}{}
+		}
+		resp := response{ID: req.ID, Result: result}
+		var rpcErr *rpcError
+		switch {
+		case errors.As(err, &rpcErr):
+			resp.Result, resp.Error = nil, rpcErr
+		case err != nil:
+			resp.Result, resp.Error = nil, &rpcError{Code: codeInternal, Message: err.Error()}
+		}
+		s.send(resp)
+	}
+	return scanner.Err()
+}
+
+func (s *Server) send(resp response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	resp.JSONRPC = "2.0"
+	s.out.Encode(resp)
+}
+
+func idOrNull(id json.RawMessage) json.RawMessage {
+	if id == nil {
+		return json.RawMessage("null")
+	}
+	return id
+}
+
+func (s *Server) handle(req request) (any, error) {
+	switch req.Method {
+	case "initialize":
+		return s.initialize(req.Params)
+	case "ping":
+		return struct{}{}, nil
+	case "tools/list":
+		return map[string]any{"tools": tools}, nil
+	case "tools/call":
+		return s.callTool(req.Params)
+	case "resources/list":
+		return s.listResources()
+	case "resources/templates/list":
+		return map[string]any{"resourceTemplates": []map[string]string{{
+			"uriTemplate": resourcePrefix + "{name}",
+			"name":        "page",
+			"description": "A page of the graph by name.",
+			"mimeType":    "text/markdown",
+		}}}, nil
+	case "resources/read":
+		return s.readResource(req.Params)
+	}
+
+	if strings.HasPrefix(req.Method, "notifications/") {
+		return nil, nil
+	}
+	return nil, &rpcError{Code: codeMethodNotFound, Message: "method not found: " + req.Method}
+}
+
+func (s *Server) initialize(params json.RawMessage) (any, error) {
+	var p struct {
+		ProtocolVersion string `json:"protocolVersion"`
+	}
+	if err := decode(params, &p); err != nil {
+		return nil, err
+	}
+
+	// Answer with the client's revision when it is known, else the newest.
+	version := ProtocolVersions[0]
+	if slices.Contains(ProtocolVersions, p.ProtocolVersion) {
+		version = p.ProtocolVersion
+	}
+
+	return map[string]any{
+		"protocolVersion": version,
+		"capabilities": map[string]any{
+			"tools":     map[string]any{},
+			"resources": map[string]any{},
+		},
+		"serverInfo":   map[string]string{"name": "lsq", "version": s.version},
+		"instructions": "Tools and resources for a Logseq graph of daily journals and pages.",
+	}, nil
+}
+
+func (s *Server) listResources() (any, error) {
+	pages, err := s.graph.Pages()
+	if err != nil {
+		return nil, err
+	}
+
+	resources := make([]map[string]string, len(pages))
+	for i, p := range pages {
+		resources[i] = map[string]string{
+			"uri":      resourceURI(p.Name),
+			"name":     p.Name,
+			"mimeType": mimeType(p.Path),
+		}
+	}
+	return map[string]any{"resources": resources}, nil
+}
+
+func (s *Server) readResource(params json.RawMessage) (any, error) {
+	var p struct {
+		URI string `json:"uri"`
+	}
+	if err := decode(params, &p); err != nil {
+		return nil, err
+	}
+
+	name, ok := strings.CutPrefix(p.URI, resourcePrefix)
+	if !ok {
+		return nil, &rpcError{Code: codeInvalidParams, Message: "unknown resource " + p.URI}
+	}
+	name, err := url.PathUnescape(name)
+	if err != nil {
+		return nil, &rpcError{Code: codeInvalidParams, Message: "invalid resource " + p.URI}
+	}
+
+	page, err := s.graph.Page(name)
+	if errors.Is(err, server.ErrNotFound) || errors.Is(err, server.ErrInvalid) {
+		return nil, &rpcError{Code: codeInvalidParams, Message: err.Error()}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]any{"contents": []map[string]string{{
+		"uri":      p.URI,
+		"mimeType": mimeType(page.Path),
+		"text":     page.Content,
+	}}}, nil
+}
+
+// resourceURI returns the URI of the page called name.
+func resourceURI(name string) string {
+	return resourcePrefix + url.PathEscape(name)
+}
+
+func mimeType(path string) string {
+	if strings.EqualFold(filepath.Ext(path), ".org") {
+		return "text/org"
+	}
+	return "text/markdown"
+}
+
+// decode reads the params of a request into v.
+func decode(params json.RawMessage, v any) error {
+	if len(params) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(params, v); err != nil {
+		return &rpcError{Code: codeInvalidParams, Message: fmt.Sprintf("invalid params: %v", err)}
+	}
+	return nil
+}
@@ -0,0 +1,323 @@
+package mcp_test
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jrswab/lsq/config"
+	"github.com/jrswab/lsq/mcp"
+	"github.com/jrswab/lsq/server"
+)
+
+// client is a fake MCP client talking to the server through pipes.
+type client struct {
+	t      *testing.T
+	in     io.WriteCloser
+	out    *bufio.Scanner
+	nextID int
+	done   chan error
+}
+
+type message struct {
+	ID     json.RawMessage `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func newClient(t *testing.T) (*client, *config.Config) {
+	t.Helper()
+	dir := t.TempDir()
+	cfg := &config.Config{
+		FileType:    "Markdown",
+		FileFmt:     "yyyy_MM_dd",
+		DirPath:     dir,
+		JournalsDir: filepath.Join(dir, "journals"),
+		PagesDir:    filepath.Join(dir, "pages"),
+	}
+
+	files := map[string]string{
+		"journals/2025_01_02.md": "- TODO call mom\n- met [[Meeting Notes]]\n",
+		"pages/meeting notes.md": "alias:: standup\n\n- agenda\n",
+		"pages/work___ideas.org": "* DOING write docs\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	graph := server.New(cfg)
+	graph.Now = func() time.Time { return time.Date(2025, 1, 2, 9, 0, 0, 0, time.Local) }
+
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	c := &client{t: t, in: inW, out: bufio.NewScanner(outR), done: make(chan error, 1)}
+	go func() {
+		c.done <- mcp.New(graph, "1.2.3").Serve(inR, outW)
+		outW.Close()
+	}()
+	t.Cleanup(func() {
+		inW.Close()
+		if err := <-c.done; err != nil {
+			t.Errorf("Serve() error = %v", err)
+		}
+	})
+	return c, cfg
+}
+
+// send writes a raw line to the server.
+func (c *client) send(line string) {
+	c.t.Helper()
+	if _, err := io.WriteString(c.in, line+"\n"); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+// receive reads the next message from the server.
+func (c *client) receive() message {
+	c.t.Helper()
+	if !c.out.Scan() {
+		c.t.Fatalf("no response: %v", c.out.Err())
+	}
+	var m message
+	if err := json.Unmarshal(c.out.Bytes(), &m); err != nil {
+		c.t.Fatalf("invalid response %s: %v", c.out.Bytes(), err)
+	}
+	return m
+}
+
+// call sends a request and returns its response.
+func (c *client) call(method string, params any) message {
+	c.t.Helper()
+	c.nextID++
+	data, err := json.Marshal(map[string]any{"jsonrpc": "2.0", "id": c.nextID, "method": method, "params": params})
+	if err != nil {
+		c.t.Fatal(err)
+	}
+	c.send(string(data))
+
+	m := c.receive()
+	if string(m.ID) != strings.TrimSpace(string(mustJSON(c.t, c.nextID))) {
+		c.t.Fatalf("response id = %s, want %d", m.ID, c.nextID)
+	}
+	return m
+}
+
+// tool calls a tool and returns its text and whether it failed.
+func (c *client) tool(name string, args any) (string, bool) {
+	c.t.Helper()
+	m := c.call("tools/call", map[string]any{"name": name, "arguments": args})
+	if m.Error != nil {
+		c.t.Fatalf("tools/call %s error = %s", name, m.Error.Message)
+	}
+
+	var result struct {
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+		IsError bool `json:"isError"`
+	}
+	if err := json.Unmarshal(m.Result, &result); err != nil || len(result.Content) != 1 {
+		c.t.Fatalf("invalid tool result %s: %v", m.Result, err)
+	}
+	return result.Content[0].Text, result.IsError
+}
+
+func mustJSON(t *testing.T, v any) []byte {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestInitialize(t *testing.T) {
+	c, _ := newClient(t)
+
+	m := c.call("initialize", map[string]any{
+		"protocolVersion": "2025-03-26",
+		"capabilities":    map[string]any{},
+		"clientInfo":      map[string]string{"name": "fake", "version": "0"},
+	})
+	var result struct {
+		ProtocolVersion string `json:"protocolVersion"`
+		Capabilities    map[string]any
+		ServerInfo      struct{ Name, Version string }
+	}
+	if err := json.Unmarshal(m.Result, &result); err != nil {
+		t.Fatal(err)
+	}
+	if result.ProtocolVersion != "2025-03-26" || result.ServerInfo.Name != "lsq" || result.ServerInfo.Version != "1.2.3" {
+		t.Errorf("initialize = %s", m.Result)
+	}
+	if _, ok := result.Capabilities["tools"]; !ok {
+		t.Errorf("tools capability missing: %s", m.Result)
+	}
+
+	// The notification gets no response, so the next message answers ping.
+	c.send(`{"jsonrpc":"2.0","method":"notifications/initialized"}`)
+	if m := c.call("ping", nil); m.Error != nil || string(m.Result) != "{}" {
+		t.Errorf("ping = %s, %v", m.Result, m.Error)
+	}
+
+	m = c.call("initialize", map[string]any{"protocolVersion": "1999-01-01"})
+	if !strings.Contains(string(m.Result), mcp.ProtocolVersions[0]) {
+		t.Errorf("unknown version answered with %s", m.Result)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	c, _ := newClient(t)
+
+	tests := map[string]struct {
+		line string
+		code int
+	}{
+		"parse error":      {line: `{"jsonrpc":`, code: -32700},
+		"not json-rpc 2.0": {line: `{"id":1,"method":"ping"}`, code: -32600},
+		"unknown method":   {line: `{"jsonrpc":"2.0","id":2,"method":"prompts/get"}`, code: -32601},
+		"unknown tool":     {line: `{"jsonrpc":"2.0","id":3,"method":"tools/call","params":{"name":"rm"}}`, code: -32602},
+		"bad arguments":    {line: `{"jsonrpc":"2.0","id":4,"method":"tools/call","params":{"name":"search","arguments":{"query":1}}}`, code: -32602},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			c.t = t
+			c.send(tt.line)
+			m := c.receive()
+			if m.Error == nil || m.Error.Code != tt.code {
+				t.Errorf("error = %+v, want code %d", m.Error, tt.code)
+			}
+		})
+	}
+}
+
+func TestTools(t *testing.T) {
+	c, cfg := newClient(t)
+
+	m := c.call("tools/list", nil)
+	for _, name := range []string{"read_journal", "read_page", "search", "append_block", "list_tasks"} {
+		if !strings.Contains(string(m.Result), `"name":"`+name+`"`) {
+			t.Errorf("tools/list is missing %s", name)
+		}
+	}
+
+	tests := map[string]struct {
+		tool    string
+		args    any
+		want    []string
+		isError bool
+	}{
+		"read journal": {
+			tool: "read_journal",
+			args: map[string]string{"date": "2025-01-02"},
+			want: []string{`"content": "- TODO call mom\n- met [[Meeting Notes]]\n"`},
+		},
+		"read today": {
+			tool: "read_journal",
+			want: []string{`"name": "2025_01_02"`},
+		},
+		"missing journal": {
+			tool:    "read_journal",
+			args:    map[string]string{"date": "2024-12-31"},
+			want:    []string{"2024_12_31.md not found"},
+			isError: true,
+		},
+		"read namespaced page": {
+			tool: "read_page",
+			args: map[string]string{"name": "work/ideas"},
+			want: []string{`"content": "* DOING write docs\n"`},
+		},
+		"search by alias": {
+			tool: "search",
+			args: map[string]string{"query": "stand"},
+			want: []string{`"name": "meeting notes"`},
+		},
+		"search by regex": {
+			tool: "search",
+			args: map[string]string{"regex": `\[\[Meeting`},
+			want: []string{`"line": 2`, `"text": "- met [[Meeting Notes]]"`},
+		},
+		"list tasks": {
+			tool: "list_tasks",
+			want: []string{`"content": "TODO call mom"`, `"content": "DOING write docs"`},
+		},
+		"empty search": {
+			tool:    "search",
+			want:    []string{"a query or regex is required"},
+			isError: true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			c.t = t
+			text, isError := c.tool(tt.tool, tt.args)
+			if isError != tt.isError {
+				t.Errorf("isError = %v, want %v: %s", isError, tt.isError, text)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(text, want) {
+					t.Errorf("result does not contain %q:\n%s", want, text)
+				}
+			}
+		})
+	}
+	c.t = t
+
+	if _, isError := c.tool("append_block", map[string]any{"text": "idea", "indent": 1}); isError {
+		t.Fatal("append_block to the journal failed")
+	}
+	if _, isError := c.tool("append_block", map[string]any{"text": "DONE ship", "page": "work/ideas"}); isError {
+		t.Fatal("append_block to a page failed")
+	}
+
+	data, _ := os.ReadFile(filepath.Join(cfg.JournalsDir, "2025_01_02.md"))
+	if got, want := string(data), "- TODO call mom\n- met [[Meeting Notes]]\n\t- idea\n"; got != want {
+		t.Errorf("journal = %q, want %q", got, want)
+	}
+	data, _ = os.ReadFile(filepath.Join(cfg.PagesDir, "work___ideas.org"))
+	if got, want := string(data), "* DOING write docs\n* DONE ship\n"; got != want {
+		t.Errorf("page = %q, want %q", got, want)
+	}
+}
+
+func TestResources(t *testing.T) {
+	c, _ := newClient(t)
+
+	m := c.call("resources/list", nil)
+	var list struct {
+		Resources []struct{ URI, Name, MimeType string }
+	}
+	if err := json.Unmarshal(m.Result, &list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Resources) != 2 || list.Resources[1].URI != "lsq://pages/work%2Fideas" || list.Resources[1].MimeType != "text/org" {
+		t.Errorf("resources/list = %s", m.Result)
+	}
+
+	m = c.call("resources/read", map[string]string{"uri": "lsq://pages/meeting%20notes"})
+	if !strings.Contains(string(m.Result), `"text":"alias:: standup\n\n- agenda\n"`) {
+		t.Errorf("resources/read = %s", m.Result)
+	}
+
+	m = c.call("resources/read", map[string]string{"uri": "lsq://pages/nothing"})
+	if m.Error == nil || m.Error.Code != -32602 {
+		t.Errorf("missing resource error = %+v", m.Error)
+	}
+}
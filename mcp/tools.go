@@ -0,0 +1,168 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// tool describes a tool for "tools/list".
+type tool struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description"`
+	InputSchema map[string]any `json:"inputSchema"`
+}
+
+func schema(required []string, properties map[string]any) map[string]any {
+	s := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
+
+func property(kind, description string) map[string]any {
+	return map[string]any{"type": kind, "description": description}
+}
+
+var tools = []tool{
+	{
+		Name:        "read_journal",
+		Description: "Read the daily journal of a date.",
+		InputSchema: schema(nil, map[string]any{
+			"date": property("string", "The date as yyyy-MM-dd, or today when omitted."),
+		}),
+	},
+	{
+		Name:        "read_page",
+		Description: "Read a page by name. Page names are not case sensitive and namespaces are written as a/b.",
+		InputSchema: schema([]string{"name"}, map[string]any{
+			"name": property("string", "The page name."),
+		}),
+	},
+	{
+		Name:        "search",
+		Description: "Find pages and aliases whose name starts with query, and lines of journals and pages matching a regular expression.",
+		InputSchema: schema(nil, map[string]any{
+			"query": property("string", "The start of a page name or alias."),
+			"regex": property("string", "A Go regular expression matched against every line."),
+		}),
+	},
+	{
+		Name:        "append_block",
+		Description: "Append a block to a journal, or to a page when page is given. Missing journals and pages are created.",
+		InputSchema: schema([]string{"text"}, map[string]any{
+			"text":   property("string", "The text of the block, without a bullet."),
+			"date":   property("string", "The journal date as yyyy-MM-dd, or today when omitted."),
+			"page":   property("string", "The page to append to instead of a journal."),
+			"indent": property("integer", "How many levels to nest the block."),
+		}),
+	},
+	{
+		Name:        "list_tasks",
+		Description: "List the tasks of the graph with their file and line.",
+		InputSchema: schema(nil, map[string]any{
+			"states": map[string]any{
+				"type":        "array",
+				"items":       map[string]string{"type": "string"},
+				"description": `Task states such as TODO or DONE, the open states when omitted, or ["all"].`,
+			},
+		}),
+	},
+}
+
+// callTool runs a tool. Failures of the tool are reported in the result so
+// the assistant can see them; only unknown tools and malformed arguments
+// are protocol errors.
+func (s *Server) callTool(params json.RawMessage) (any, error) {
+	var p struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	}
+	if err := decode(params, &p); err != nil {
+		return nil, err
+	}
+
+	var (
+		out any
+		err error
+	)
+	command := "lsq mcp: " + p.Name
+
+	switch p.Name {
+	case "read_journal":
+		var args struct {
+			Date string `json:"date"`
+		}
+		if err := decode(p.Arguments, &args); err != nil {
+			return nil, err
+		}
+		out, err = s.graph.Journal(orToday(args.Date))
+	case "read_page":
+		var args struct {
+			Name string `json:"name"`
+		}
+		if err := decode(p.Arguments, &args); err != nil {
+			return nil, err
+		}
+		out, err = s.graph.Page(args.Name)
+	case "search":
+		var args struct {
+			Query string `json:"query"`
+			Regex string `json:"regex"`
+		}
+		if err := decode(p.Arguments, &args); err != nil {
+			return nil, err
+		}
+		out, err = s.graph.Search(args.Query, args.Regex)
+	case "append_block":
+		var args struct {
+			Text   string `json:"text"`
+			Date   string `json:"date"`
+			Page   string `json:"page"`
+			Indent int    `json:"indent"`
+		}
+		if err := decode(p.Arguments, &args); err != nil {
+			return nil, err
+		}
+		if args.Page != "" {
+			out, err = s.graph.AppendPage(args.Page, args.Text, args.Indent, command)
+		} else {
+			out, err = s.graph.AppendJournal(orToday(args.Date), args.Text, args.Indent, command)
+		}
+	case "list_tasks":
+		var args struct {
+			States []string `json:"states"`
+		}
+		if err := decode(p.Arguments, &args); err != nil {
+			return nil, err
+		}
+		out, err = s.graph.Tasks(args.States)
+	default:
+		return nil, &rpcError{Code: codeInvalidParams, Message: "unknown tool: " + p.Name}
+	}
+
+	if err != nil {
+		return toolResult(err.Error(), true), nil
+	}
+
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("error encoding result: %v", err)
+	}
+	return toolResult(string(data), false), nil
+}
+
+func toolResult(text string, isError bool) map[string]any {
+	return map[string]any{
+		"content": []map[string]string{{"type": "text", "text": text}},
+		"isError": isError,
+	}
+}
+
+func orToday(date string) string {
+	if strings.TrimSpace(date) == "" {
+		return "today"
+	}
+	return date
+}
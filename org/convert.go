@@ -0,0 +1,277 @@
+// Package org handles the Org-mode flavour of Logseq pages and converts
+// pages between Logseq Markdown and Logseq Org.
+package org
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jrswab/lsq/outline"
+)
+
+var (
+	mdHeadingRe   = regexp.MustCompile(`^(#{1,6}) (.*)$`)
+	mdFenceRe     = regexp.MustCompile("^```\\s*(\\S*)")
+	mdImageRe     = regexp.MustCompile(`!\[[^\[\]]*\]\(([^()\s]+)\)`)
+	mdLabelLinkRe = regexp.MustCompile(`\[([^\[\]]+)\]\(\[\[([^\[\]]+)\]\]\)`)
+	mdURLLinkRe   = regexp.MustCompile(`\[([^\[\]]+)\]\(([a-zA-Z][a-zA-Z0-9+.-]*:[^()\s]+)\)`)
+	mdBoldRe      = regexp.MustCompile(`\*\*([^*]+)\*\*`)
+
+	headingRe    = regexp.MustCompile(`^(\*+)(?: (.*))?$`)
+	drawerPropRe = regexp.MustCompile(`^:([A-Za-z0-9_\-/.]+):(?:\s+(.*))?$`)
+	keywordRe    = regexp.MustCompile(`^#\+([A-Za-z0-9_\-]+):\s*(.*)$`)
+	srcBeginRe   = regexp.MustCompile(`(?i)^\s*#\+begin_src\s*(\S*)`)
+	srcEndRe     = regexp.MustCompile(`(?i)^\s*#\+end_src`)
+	descLinkRe   = regexp.MustCompile(`\[\[([^\[\]]+)\]\[([^\[\]]+)\]\]`)
+	urlLinkRe    = regexp.MustCompile(`\[\[([a-zA-Z][a-zA-Z0-9+.-]*:[^\[\]\s]+)\]\]`)
+	boldRe       = regexp.MustCompile(`(^|[\s(])\*([^*\s](?:[^*]*[^*\s])?)\*($|[\s).,;:!?])`)
+	imageExtRe   = regexp.MustCompile(`(?i)\.(png|jpe?g|gif|svg|webp)$`)
+	schemeRe     = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9+.-]*:`)
+	pageLinkRe   = regexp.MustCompile(`\[\[([^\[\]]+)\]\]`)
+)
+
+// ToOrg converts a Logseq Markdown page into a Logseq Org page. Bullets
+// become headings, "key:: value" properties become property drawers and
+// page properties become "#+key: value" keywords. Task markers, planning
+// lines and drawers such as :LOGBOOK: are shared by both formats.
+func ToOrg(content string) string {
+	page := outline.Parse(strings.ReplaceAll(content, "\r\n", "\n"))
+
+	var out []string
+	for _, line := range page.Preamble {
+		if key, value, ok := outline.ParseProperty(line); ok {
+			out = append(out, "#+"+key+": "+value)
+			continue
+		}
+		out = append(out, mdInline(line))
+	}
+
+	page.Walk(func(b *outline.Block) bool {
+		out = append(out, orgBlock(b)...)
+		return true
+	})
+
+	if len(out) == 0 {
+		return ""
+	}
+	return strings.Join(out, "\n") + "\n"
+}
+
+// orgBlock converts a single Markdown block into a heading and its section.
+func orgBlock(b *outline.Block) []string {
+	stars := strings.Repeat("*", b.Level()+1)
+	lines := append([]string{b.Content()}, b.Body()...)
+
+	var props [][2]string
+	title := lines[0]
+	if m := mdHeadingRe.FindStringSubmatch(title); m != nil {
+		title = m[2]
+		props = append(props, [2]string{"heading", strconv.Itoa(len(m[1]))})
+	}
+
+	// A block starting with a code fence gets an empty heading.
+	rest := lines[1:]
+	if mdFenceRe.MatchString(title) {
+		title = ""
+		rest = lines
+	}
+
+	var planning []string
+	i := 0
+	for ; i < len(rest) && outline.IsProperty(rest[i]); i++ {
+		key, value, _ := outline.ParseProperty(rest[i])
+		props = append(props, [2]string{key, value})
+	}
+	rest = rest[i:]
+
+	var section []string
+	code := false
+	for _, line := range rest {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case mdFenceRe.MatchString(trimmed):
+			if code {
+				section = append(section, "#+END_SRC")
+			} else {
+				lang := mdFenceRe.FindStringSubmatch(trimmed)[1]
+				section = append(section, strings.TrimSpace("#+BEGIN_SRC "+lang))
+			}
+			code = !code
+		case code:
+			section = append(section, line)
+		case isPlanning(trimmed):
+			planning = append(planning, trimmed)
+		default:
+			section = append(section, mdInline(line))
+		}
+	}
+
+	heading := stars
+	if title != "" {
+		heading += " " + mdInline(title)
+	}
+
+	out := append([]string{heading}, planning...)
+	if len(props) > 0 {
+		out = append(out, ":PROPERTIES:")
+		for _, prop := range props {
+			out = append(out, ":"+prop[0]+": "+prop[1])
+		}
+		out = append(out, ":END:")
+	}
+	return append(out, section...)
+}
+
+// ToMarkdown converts a Logseq Org page into a Logseq Markdown page.
+// It is the inverse of ToOrg.
+func ToMarkdown(content string) string {
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	var (
+		out     []string
+		current *mdBlock
+		code    bool
+	)
+
+	flush := func() {
+		if current != nil {
+			out = append(out, current.lines()...)
+		}
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+
+		if !code {
+			if m := headingRe.FindStringSubmatch(line); m != nil {
+				flush()
+				current = &mdBlock{level: len(m[1]) - 1, title: orgInline(m[2])}
+
+				// Planning lines come before the property drawer.
+				for i+1 < len(lines) && isPlanning(strings.TrimSpace(lines[i+1])) {
+					i++
+					current.body = append(current.body, strings.TrimSpace(lines[i]))
+				}
+				if i+1 < len(lines) && strings.EqualFold(strings.TrimSpace(lines[i+1]), ":PROPERTIES:") {
+					i += 2
+					for ; i < len(lines) && !strings.EqualFold(strings.TrimSpace(lines[i]), ":END:"); i++ {
+						if m := drawerPropRe.FindStringSubmatch(strings.TrimSpace(lines[i])); m != nil {
+							current.props = append(current.props, [2]string{m[1], m[2]})
+						}
+					}
+				}
+				continue
+			}
+		}
+
+		var converted string
+		switch {
+		case srcBeginRe.MatchString(line) && !code:
+			code = true
+			converted = "```" + srcBeginRe.FindStringSubmatch(line)[1]
+		case srcEndRe.MatchString(line) && code:
+			code = false
+			converted = "```"
+		case code:
+			converted = line
+		default:
+			converted = orgInline(strings.TrimSpace(line))
+		}
+
+		if current == nil {
+			if m := keywordRe.FindStringSubmatch(line); m != nil {
+				converted = strings.ToLower(m[1]) + ":: " + m[2]
+			}
+			out = append(out, converted)
+			continue
+		}
+		current.body = append(current.body, converted)
+	}
+	flush()
+
+	if len(out) == 0 {
+		return ""
+	}
+	return strings.Join(out, "\n") + "\n"
+}
+
+// mdBlock collects an Org heading while it is converted into a bullet.
+type mdBlock struct {
+	level int
+	title string
+	props [][2]string
+	body  []string
+}
+
+func (b *mdBlock) lines() []string {
+	indent := strings.Repeat("\t", b.level)
+	title := b.title
+
+	var props []string
+	for _, prop := range b.props {
+		if strings.EqualFold(prop[0], "heading") {
+			if n, err := strconv.Atoi(prop[1]); err == nil && n >= 1 && n <= 6 {
+				title = strings.Repeat("#", n) + " " + title
+				continue
+			}
+		}
+		props = append(props, prop[0]+":: "+prop[1])
+	}
+
+	// A heading without a title holding a code block becomes a block starting with the fence.
+	body := b.body
+	if title == "" && len(props) == 0 && len(body) > 0 {
+		title, body = body[0], body[1:]
+	}
+
+	out := []string{strings.TrimRight(indent+"- "+title, " ")}
+	for _, line := range append(props, body...) {
+		if line == "" {
+			out = append(out, "")
+			continue
+		}
+		out = append(out, indent+"  "+line)
+	}
+	return out
+}
+
+// mdInline rewrites Markdown links, images and bold text into Org syntax.
+func mdInline(text string) string {
+	text = mdImageRe.ReplaceAllString(text, "[[$1]]")
+	text = mdLabelLinkRe.ReplaceAllString(text, "[[$2][$1]]")
+	text = mdURLLinkRe.ReplaceAllString(text, "[[$2][$1]]")
+	return mdBoldRe.ReplaceAllString(text, "*$1*")
+}
+
+// orgInline rewrites Org links, images and bold text into Markdown syntax.
+func orgInline(text string) string {
+	text = boldRe.ReplaceAllString(text, "$1**$2**$3")
+	text = descLinkRe.ReplaceAllStringFunc(text, func(match string) string {
+		m := descLinkRe.FindStringSubmatch(match)
+		if schemeRe.MatchString(m[1]) {
+			return "[" + m[2] + "](" + m[1] + ")"
+		}
+		return "[" + m[2] + "]([[" + m[1] + "]])"
+	})
+	text = urlLinkRe.ReplaceAllString(text, "$1")
+	return imageLinks(text)
+}
+
+// imageLinks turns "[[../assets/a.png]]" into a Markdown image.
+func imageLinks(text string) string {
+	return pageLinkRe.ReplaceAllStringFunc(text, func(match string) string {
+		target := pageLinkRe.FindStringSubmatch(match)[1]
+		if !imageExtRe.MatchString(target) {
+			return match
+		}
+		return "![" + target[strings.LastIndex(target, "/")+1:] + "](" + target + ")"
+	})
+}
+
+func isPlanning(line string) bool {
+	return strings.HasPrefix(line, "SCHEDULED:") || strings.HasPrefix(line, "DEADLINE:")
+}
@@ -0,0 +1,54 @@
+package org_test
+
+import (
+	"testing"
+
+	"github.com/jrswab/lsq/org"
+)
+
+func TestConvert(t *testing.T) {
+	tests := map[string]struct {
+		markdown string
+		org      string
+	}{
+		"nested blocks": {
+			markdown: "- parent\n\t- child\n\t\t- grandchild\n- sibling\n",
+			org:      "* parent\n** child\n*** grandchild\n* sibling\n",
+		},
+		"page and block properties": {
+			markdown: "alias:: notes\n\n- block\n  id:: 6578a1c2-0000-4000-8000-000000000000\n  more text\n",
+			org:      "#+alias: notes\n\n* block\n:PROPERTIES:\n:id: 6578a1c2-0000-4000-8000-000000000000\n:END:\nmore text\n",
+		},
+		"tasks and planning": {
+			markdown: "- TODO [#A] write\n  SCHEDULED: <2025-01-06 Mon>\n  :LOGBOOK:\n  CLOCK: [2025-01-06 Mon 09:00:00]\n  :END:\n",
+			org:      "* TODO [#A] write\nSCHEDULED: <2025-01-06 Mon>\n:LOGBOOK:\nCLOCK: [2025-01-06 Mon 09:00:00]\n:END:\n",
+		},
+		"links and bold": {
+			markdown: "- see [this]([[Page]]), [[Other]], [site](https://example.com) and **bold**\n",
+			org:      "* see [[Page][this]], [[Other]], [[https://example.com][site]] and *bold*\n",
+		},
+		"headings": {
+			markdown: "- ## Section\n\t- text\n",
+			org:      "* Section\n:PROPERTIES:\n:heading: 2\n:END:\n** text\n",
+		},
+		"code blocks": {
+			markdown: "- ```go\n  * not a heading\n  ```\n",
+			org:      "*\n#+BEGIN_SRC go\n* not a heading\n#+END_SRC\n",
+		},
+		"images": {
+			markdown: "- ![cat.png](../assets/cat.png)\n",
+			org:      "* [[../assets/cat.png]]\n",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := org.ToOrg(tt.markdown); got != tt.org {
+				t.Errorf("ToOrg() =\n%q\nwant\n%q", got, tt.org)
+			}
+			if got := org.ToMarkdown(tt.org); got != tt.markdown {
+				t.Errorf("ToMarkdown() =\n%q\nwant\n%q", got, tt.markdown)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package org
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jrswab/lsq/graph"
+	"github.com/jrswab/lsq/store"
+)
+
+// File types as used by the "file/type" configuration option.
+const (
+	Markdown = "Markdown"
+	Org      = "Org"
+)
+
+// Ext returns the file extension used for the file type.
+func Ext(fileType string) string {
+	if strings.EqualFold(fileType, Org) {
+		return ".org"
+	}
+	return ".md"
+}
+
+// Result describes the conversion of a single file.
+type Result struct {
+	Source string
+	Target string
+	// Skipped explains why the file was left untouched, e.g. because the target already exists.
+	Skipped string
+}
+
+// Options controls a conversion.
+type Options struct {
+	// To is Markdown or Org.
+	To string
+	// DryRun reports the results without writing or removing any files.
+	DryRun bool
+}
+
+// Convert rewrites every file that is not yet in the opts.To format. The
+// converted page replaces the original, keeping its name with the new extension.
+func Convert(files []graph.File, opts Options) ([]Result, error) {
+	ext := Ext(opts.To)
+
+	var results []Result
+	for _, f := range files {
+		if strings.EqualFold(filepath.Ext(f.Path), ext) {
+			continue
+		}
+
+		r := Result{Source: f.Path, Target: strings.TrimSuffix(f.Path, filepath.Ext(f.Path)) + ext}
+		if _, err := os.Stat(r.Target); err == nil {
+			r.Skipped = "target exists"
+			results = append(results, r)
+			continue
+		}
+
+		results = append(results, r)
+		if opts.DryRun {
+			continue
+		}
+
+		data, err := os.ReadFile(f.Path)
+		if err != nil {
+			return results, err
+		}
+
+		converted := ToMarkdown(string(data))
+		if ext == ".org" {
+			converted = ToOrg(string(data))
+		}
+
+		if err := store.Create(r.Target, []byte(converted)); err != nil {
+			return results, fmt.Errorf("error writing %s: %w", r.Target, err)
+		}
+		if err := store.Remove(f.Path, data); err != nil {
+			return results, fmt.Errorf("error removing %s: %w", f.Path, err)
+		}
+	}
+
+	return results, nil
+}
@@ -0,0 +1,64 @@
+package org_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jrswab/lsq/graph"
+	"github.com/jrswab/lsq/org"
+)
+
+func TestConvertFiles(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"a.md":  "- a\n",
+		"b.org": "* b\n",
+		"c.md":  "- c\n",
+		"c.org": "* existing\n",
+	}
+
+	var graphFiles []graph.File
+	for _, name := range []string{"a.md", "b.org", "c.md", "c.org"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(files[name]), 0644); err != nil {
+			t.Fatal(err)
+		}
+		graphFiles = append(graphFiles, graph.File{Path: path, Name: name})
+	}
+
+	results, err := org.Convert(graphFiles, org.Options{To: org.Org, DryRun: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 || results[0].Skipped != "" || results[1].Skipped == "" {
+		t.Fatalf("unexpected dry run results: %+v", results)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "a.org")); !os.IsNotExist(err) {
+		t.Fatalf("dry run wrote a file: %v", err)
+	}
+
+	if _, err := org.Convert(graphFiles, org.Options{To: org.Org}); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		"a.org": "* a\n",
+		"b.org": "* b\n",
+		"c.md":  "- c\n",
+		"c.org": "* existing\n",
+	}
+	for name, want := range expected {
+		got, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "a.md")); !os.IsNotExist(err) {
+		t.Errorf("a.md was not removed: %v", err)
+	}
+}
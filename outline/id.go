@@ -0,0 +1,40 @@
+package outline
+
+import (
+	"crypto/rand"
+	"fmt"
+)
+
+// NewUUID returns a random version 4 UUID like the ones Logseq uses for block ids.
+func NewUUID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// EnsureID returns the "id::" property of the block, assigning a new one when missing.
+func (b *Block) EnsureID() string {
+	if id, ok := b.Property("id"); ok && id != "" {
+		return id
+	}
+
+	id := NewUUID()
+	b.SetProperty("id", id)
+	return id
+}
+
+// Subtree returns the lines of the block followed by the lines of all its descendants.
+func (b *Block) Subtree() []string {
+	var lines []string
+	b.Walk(func(d *Block) bool {
+		lines = append(lines, d.Lines...)
+		return true
+	})
+	return lines
+}
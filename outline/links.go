@@ -0,0 +1,57 @@
+package outline
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	linkRe = regexp.MustCompile(`\[\[([^\[\]]+)\]\]`)
+	tagRe  = regexp.MustCompile(`(?:^|\s)#(?:\[\[([^\[\]]+)\]\]|([^\s#\[\],!?;:"']+))`)
+)
+
+// Links returns the page names referenced by "[[page]]", "#tag"
+// and "#[[tag]]" within text, in order of appearance.
+func Links(text string) []string {
+	var links []string
+	for _, m := range linkRe.FindAllStringSubmatch(text, -1) {
+		links = append(links, m[1])
+	}
+	for _, tag := range Tags(text) {
+		if !strings.Contains(text, "#[["+tag+"]]") {
+			links = append(links, tag)
+		}
+	}
+	return links
+}
+
+// Tags returns the page names referenced by "#tag" and "#[[tag]]" within text.
+func Tags(text string) []string {
+	var tags []string
+	for _, m := range tagRe.FindAllStringSubmatch(text, -1) {
+		tag := m[1]
+		if tag == "" {
+			tag = strings.TrimRight(m[2], ".")
+		}
+		if tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// PropertyValues splits a property value such as "[[a]], b, #c"
+// into the page names it lists.
+func PropertyValues(value string) []string {
+	var values []string
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		part = strings.TrimPrefix(part, "#")
+		part = strings.TrimPrefix(part, "[[")
+		part = strings.TrimSuffix(part, "]]")
+		if part != "" {
+			values = append(values, part)
+		}
+	}
+	return values
+}
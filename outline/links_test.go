@@ -0,0 +1,38 @@
+package outline_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jrswab/lsq/outline"
+)
+
+func TestLinks(t *testing.T) {
+	tests := map[string]struct {
+		input    string
+		expected []string
+	}{
+		"no links":       {input: "plain text", expected: nil},
+		"page link":      {input: "see [[Recipes]] now", expected: []string{"Recipes"}},
+		"tag":            {input: "#work task", expected: []string{"work"}},
+		"bracketed tag":  {input: "task #[[deep work]]", expected: []string{"deep work"}},
+		"mixed":          {input: "[[a/b]] and #c.", expected: []string{"a/b", "c"}},
+		"heading marker": {input: "# Title", expected: nil},
+		"url fragment":   {input: "https://x.org/#frag", expected: nil},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := outline.Links(tt.input); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Links(%q) = %q, want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestPropertyValues(t *testing.T) {
+	got := outline.PropertyValues("[[a]], b , #c,")
+	if want := []string{"a", "b", "c"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("PropertyValues() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,194 @@
+package outline
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var (
+	orgHeadingRe  = regexp.MustCompile(`^(\*+)(?: |$)`)
+	drawerPropRe  = regexp.MustCompile(`^:([A-Za-z0-9_\-/.]+):(?:\s+(.*))?$`)
+	srcBlockRe    = regexp.MustCompile(`(?i)^\s*#\+(begin|end)_src`)
+	orgPlanningRe = regexp.MustCompile(`^\s*(SCHEDULED|DEADLINE):`)
+)
+
+// ParseFile parses content as an Org page when name has the ".org"
+// extension and as a Markdown page otherwise.
+func ParseFile(name, content string) *Page {
+	if IsOrg(name) {
+		return ParseOrg(content)
+	}
+	return Parse(content)
+}
+
+// IsOrg reports whether the file name is an Org page.
+func IsOrg(name string) bool {
+	return strings.EqualFold(filepath.Ext(name), ".org")
+}
+
+// ParsePageProperty splits a page property line of the preamble: "key:: value"
+// in Markdown pages and "#+key: value" in Org pages.
+func ParsePageProperty(org bool, line string) (key, value string, ok bool) {
+	if !org {
+		return ParseProperty(line)
+	}
+
+	trimmed := strings.TrimSpace(line)
+	if !strings.HasPrefix(trimmed, "#+") {
+		return "", "", false
+	}
+	key, value, ok = strings.Cut(trimmed[2:], ":")
+	return strings.TrimSpace(key), strings.TrimSpace(value), ok && strings.TrimSpace(key) != ""
+}
+
+// Bullet returns the first line of a new block at the given zero-based level.
+// Markdown blocks are indented with tabs, Org headings use one star per level.
+func Bullet(org bool, level int, content string) string {
+	if org {
+		return strings.Repeat("*", level+1) + " " + content
+	}
+	return strings.Repeat("\t", level) + "- " + content
+}
+
+// ParseOrg splits an Org page into blocks, one per heading. Lines before
+// the first heading, where Logseq keeps "#+key: value" page properties,
+// become the preamble.
+func ParseOrg(content string) *Page {
+	page := &Page{trailingNewline: true}
+	if content == "" {
+		return page
+	}
+
+	lines := strings.Split(content, "\n")
+	page.trailingNewline = lines[len(lines)-1] == ""
+	if page.trailingNewline {
+		lines = lines[:len(lines)-1]
+	}
+
+	var (
+		stack   []*Block
+		current *Block
+		src     bool
+	)
+
+	for i, line := range lines {
+		m := orgHeadingRe.FindStringSubmatch(line)
+		if m == nil || src {
+			if srcBlockRe.MatchString(line) {
+				src = strings.EqualFold(srcBlockRe.FindStringSubmatch(line)[1], "begin")
+			}
+			if current == nil {
+				page.Preamble = append(page.Preamble, line)
+				continue
+			}
+			current.Lines = append(current.Lines, line)
+			continue
+		}
+
+		block := &Block{Lines: []string{line}, Line: i, org: true}
+		stars := len(m[1])
+
+		for len(stack) > 0 && stack[len(stack)-1].stars() >= stars {
+			stack = stack[:len(stack)-1]
+		}
+
+		if len(stack) == 0 {
+			page.Blocks = append(page.Blocks, block)
+		} else {
+			parent := stack[len(stack)-1]
+			block.Parent = parent
+			parent.Children = append(parent.Children, block)
+		}
+
+		stack = append(stack, block)
+		current = block
+	}
+
+	return page
+}
+
+// stars returns the number of stars of an Org heading.
+func (b *Block) stars() int {
+	return len(b.Lines[0]) - len(strings.TrimLeft(b.Lines[0], "*"))
+}
+
+// drawer returns the line indexes of the ":PROPERTIES:" and ":END:" lines
+// of an Org heading or -1 when it has no property drawer.
+func (b *Block) drawer() (start, end int) {
+	start, end = -1, -1
+	for i := 1; i < len(b.Lines); i++ {
+		trimmed := strings.TrimSpace(b.Lines[i])
+		switch {
+		case start < 0 && strings.EqualFold(trimmed, ":PROPERTIES:"):
+			start = i
+		case start >= 0 && strings.EqualFold(trimmed, ":END:"):
+			return start, i
+		case start < 0 && !orgPlanningRe.MatchString(trimmed):
+			// The drawer must directly follow the heading and its planning lines.
+			return -1, -1
+		}
+	}
+	return -1, -1
+}
+
+func (b *Block) drawerProperties() [][2]string {
+	start, end := b.drawer()
+	if start < 0 {
+		return nil
+	}
+
+	var props [][2]string
+	for _, line := range b.Lines[start+1 : end] {
+		if m := drawerPropRe.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			props = append(props, [2]string{m[1], m[2]})
+		}
+	}
+	return props
+}
+
+// setDrawerProperty sets a property in the drawer of an Org heading,
+// creating the drawer below the planning lines when needed.
+func (b *Block) setDrawerProperty(key, value string) {
+	line := ":" + key + ": " + value
+
+	start, end := b.drawer()
+	if start < 0 {
+		at := 1
+		for at < len(b.Lines) && orgPlanningRe.MatchString(b.Lines[at]) {
+			at++
+		}
+		drawer := []string{":PROPERTIES:", line, ":END:"}
+		b.Lines = append(b.Lines[:at], append(drawer, b.Lines[at:]...)...)
+		return
+	}
+
+	for i := start + 1; i < end; i++ {
+		m := drawerPropRe.FindStringSubmatch(strings.TrimSpace(b.Lines[i]))
+		if m != nil && strings.EqualFold(m[1], key) {
+			b.Lines[i] = line
+			return
+		}
+	}
+
+	b.Lines = append(b.Lines[:end], append([]string{line}, b.Lines[end:]...)...)
+}
+
+// removeDrawerProperty removes a property from the drawer of an Org
+// heading, dropping the drawer when it is left empty.
+func (b *Block) removeDrawerProperty(key string) {
+	start, end := b.drawer()
+	for i := start + 1; start >= 0 && i < end; i++ {
+		m := drawerPropRe.FindStringSubmatch(strings.TrimSpace(b.Lines[i]))
+		if m == nil || !strings.EqualFold(m[1], key) {
+			continue
+		}
+
+		if end-start == 2 {
+			b.Lines = append(b.Lines[:start], b.Lines[end+1:]...)
+			return
+		}
+		b.Lines = append(b.Lines[:i], b.Lines[i+1:]...)
+		return
+	}
+}
@@ -0,0 +1,303 @@
+// Package outline parses Logseq pages into a tree of blocks.
+// Every block keeps its original lines so a page can be
+// written back to disk exactly as it was read.
+package outline
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Block is a single bullet of a Logseq page.
+// Lines holds the bullet line followed by any continuation
+// lines (properties, SCHEDULED, drawers, multi-line text).
+type Block struct {
+	Indent   string
+	Lines    []string
+	Children []*Block
+	Parent   *Block
+
+	// Line is the zero-based index of the bullet line in the parsed source.
+	Line int
+
+	// org is set for the headings of Org pages.
+	org bool
+}
+
+// Page is a parsed Logseq page.
+// Preamble holds every line before the first bullet; this is
+// where Logseq stores page properties such as "alias::".
+type Page struct {
+	Preamble []string
+	Blocks   []*Block
+
+	trailingNewline bool
+}
+
+var propertyRe = regexp.MustCompile(`^([A-Za-z0-9_\-/.]+):: ?(.*)$`)
+
+// Parse splits content into blocks based on the indentation of each bullet.
+func Parse(content string) *Page {
+	page := &Page{trailingNewline: true}
+	if content == "" {
+		return page
+	}
+
+	lines := strings.Split(content, "\n")
+	page.trailingNewline = lines[len(lines)-1] == ""
+	if page.trailingNewline {
+		lines = lines[:len(lines)-1]
+	}
+
+	var (
+		stack   []*Block
+		current *Block
+	)
+
+	for i, line := range lines {
+		indent, ok := bulletIndent(line)
+		if !ok {
+			if current == nil {
+				page.Preamble = append(page.Preamble, line)
+				continue
+			}
+			current.Lines = append(current.Lines, line)
+			continue
+		}
+
+		block := &Block{Indent: indent, Lines: []string{line}, Line: i}
+
+		// Pop every block that is not a parent of this one.
+		for len(stack) > 0 && width(stack[len(stack)-1].Indent) >= width(indent) {
+			stack = stack[:len(stack)-1]
+		}
+
+		if len(stack) == 0 {
+			page.Blocks = append(page.Blocks, block)
+		} else {
+			parent := stack[len(stack)-1]
+			block.Parent = parent
+			parent.Children = append(parent.Children, block)
+		}
+
+		stack = append(stack, block)
+		current = block
+	}
+
+	return page
+}
+
+// bulletIndent reports whether line is a bullet and returns its indentation.
+func bulletIndent(line string) (string, bool) {
+	trimmed := strings.TrimLeft(line, " \t")
+	if trimmed != "-" && !strings.HasPrefix(trimmed, "- ") {
+		return "", false
+	}
+	return line[:len(line)-len(trimmed)], true
+}
+
+// width measures indentation, counting a tab as four spaces
+// since that is what terminal editors tend to replace them with.
+func width(indent string) int {
+	w := 0
+	for _, r := range indent {
+		if r == '\t' {
+			w += 4
+			continue
+		}
+		w++
+	}
+	return w
+}
+
+// String renders the page back into Logseq Markdown.
+func (p *Page) String() string {
+	var lines []string
+	lines = append(lines, p.Preamble...)
+	p.Walk(func(b *Block) bool {
+		lines = append(lines, b.Lines...)
+		return true
+	})
+
+	if len(lines) == 0 {
+		return ""
+	}
+
+	out := strings.Join(lines, "\n")
+	if p.trailingNewline {
+		out += "\n"
+	}
+	return out
+}
+
+// Walk calls fn for every block in document order.
+// Returning false from fn skips the children of that block.
+func (p *Page) Walk(fn func(b *Block) bool) {
+	for _, b := range p.Blocks {
+		b.Walk(fn)
+	}
+}
+
+// Walk calls fn for the block and all of its descendants in document order.
+func (b *Block) Walk(fn func(b *Block) bool) {
+	if !fn(b) {
+		return
+	}
+	for _, child := range b.Children {
+		child.Walk(fn)
+	}
+}
+
+// BlockAt returns the block containing the zero-based source line,
+// or nil when the line belongs to the preamble or is out of range.
+func (p *Page) BlockAt(line int) *Block {
+	var found *Block
+	p.Walk(func(b *Block) bool {
+		if line >= b.Line && line < b.Line+len(b.Lines) {
+			found = b
+		}
+		return found == nil
+	})
+	return found
+}
+
+// Level returns the depth of the block, starting at zero for top level blocks.
+func (b *Block) Level() int {
+	level := 0
+	for p := b.Parent; p != nil; p = p.Parent {
+		level++
+	}
+	return level
+}
+
+// Content returns the text of the bullet line without indentation and "- ".
+func (b *Block) Content() string {
+	if b.org {
+		return strings.TrimPrefix(strings.TrimLeft(b.Lines[0], "*"), " ")
+	}
+
+	text := strings.TrimPrefix(b.Lines[0], b.Indent)
+	text = strings.TrimPrefix(text, "-")
+	return strings.TrimPrefix(text, " ")
+}
+
+// SetContent replaces the text of the bullet line.
+func (b *Block) SetContent(content string) {
+	if b.org {
+		b.Lines[0] = strings.Repeat("*", b.stars()) + " " + content
+		return
+	}
+	b.Lines[0] = b.Indent + "- " + content
+}
+
+// ContinuationIndent is the prefix Logseq uses for lines below the bullet.
+// Org sections are not indented.
+func (b *Block) ContinuationIndent() string {
+	if b.org {
+		return ""
+	}
+	return b.Indent + "  "
+}
+
+// Org reports whether the block is a heading of an Org page.
+func (b *Block) Org() bool {
+	return b.org
+}
+
+// Body returns the continuation lines with the block indentation removed.
+func (b *Block) Body() []string {
+	if b.org {
+		return append([]string(nil), b.Lines[1:]...)
+	}
+
+	body := make([]string, 0, len(b.Lines)-1)
+	for _, line := range b.Lines[1:] {
+		line = strings.TrimPrefix(line, b.Indent)
+		body = append(body, strings.TrimPrefix(line, "  "))
+	}
+	return body
+}
+
+// Properties returns the "key:: value" pairs of the block in order of appearance.
+func (b *Block) Properties() [][2]string {
+	if b.org {
+		return b.drawerProperties()
+	}
+
+	var props [][2]string
+	for _, line := range b.Body() {
+		if m := propertyRe.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			props = append(props, [2]string{m[1], m[2]})
+		}
+	}
+	return props
+}
+
+// Property returns the value of the named property of the block.
+func (b *Block) Property(key string) (string, bool) {
+	for _, prop := range b.Properties() {
+		if strings.EqualFold(prop[0], key) {
+			return prop[1], true
+		}
+	}
+	return "", false
+}
+
+// SetProperty sets the named property, replacing an existing
+// value or adding a new line below the existing properties.
+func (b *Block) SetProperty(key, value string) {
+	if b.org {
+		b.setDrawerProperty(key, value)
+		return
+	}
+
+	line := b.ContinuationIndent() + key + ":: " + value
+
+	insertAt := 1
+	for i := 1; i < len(b.Lines); i++ {
+		m := propertyRe.FindStringSubmatch(strings.TrimSpace(b.Lines[i]))
+		if m == nil {
+			break
+		}
+		if strings.EqualFold(m[1], key) {
+			b.Lines[i] = line
+			return
+		}
+		insertAt = i + 1
+	}
+
+	b.Lines = append(b.Lines[:insertAt], append([]string{line}, b.Lines[insertAt:]...)...)
+}
+
+// RemoveProperty removes the named property from the block.
+func (b *Block) RemoveProperty(key string) {
+	if b.org {
+		b.removeDrawerProperty(key)
+		return
+	}
+
+	for i := 1; i < len(b.Lines); i++ {
+		m := propertyRe.FindStringSubmatch(strings.TrimSpace(b.Lines[i]))
+		if m == nil {
+			return
+		}
+		if strings.EqualFold(m[1], key) {
+			b.Lines = append(b.Lines[:i], b.Lines[i+1:]...)
+			return
+		}
+	}
+}
+
+// IsProperty reports whether line is a "key:: value" property line.
+func IsProperty(line string) bool {
+	return propertyRe.MatchString(strings.TrimSpace(line))
+}
+
+// ParseProperty splits a property line into its key and value.
+func ParseProperty(line string) (key, value string, ok bool) {
+	m := propertyRe.FindStringSubmatch(strings.TrimSpace(line))
+	if m == nil {
+		return "", "", false
+	}
+	return m[1], m[2], true
+}
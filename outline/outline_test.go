@@ -0,0 +1,229 @@
+package outline_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jrswab/lsq/outline"
+)
+
+func TestParseRoundTrip(t *testing.T) {
+	tests := map[string]string{
+		"empty page":           "",
+		"single block":         "- hello\n",
+		"no trailing newline":  "- hello",
+		"page properties":      "alias:: one, two\n\n- first block\n",
+		"nested blocks":        "- parent\n\t- child\n\t\t- grandchild\n- sibling\n",
+		"continuation lines":   "- TODO task\n  SCHEDULED: <2025-01-06 Mon .+1w>\n  id:: 1234\n",
+		"multi-line text":      "- line1\nline2\n- next\n",
+		"space indentation":    "- parent\n    - child\n",
+		"blank lines retained": "- one\n\n- two\n\n",
+	}
+
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := outline.Parse(content).String(); got != content {
+				t.Errorf("String() = %q, want %q", got, content)
+			}
+		})
+	}
+}
+
+func TestParseTree(t *testing.T) {
+	page := outline.Parse("title:: Test\n- a\n\t- b\n\t\t- c\n\t- d\n- e\n")
+
+	if !reflect.DeepEqual(page.Preamble, []string{"title:: Test"}) {
+		t.Errorf("Preamble = %q", page.Preamble)
+	}
+
+	var got []string
+	var levels []int
+	page.Walk(func(b *outline.Block) bool {
+		got = append(got, b.Content())
+		levels = append(levels, b.Level())
+		return true
+	})
+
+	if want := []string{"a", "b", "c", "d", "e"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Walk() contents = %v, want %v", got, want)
+	}
+
+	if want := []int{0, 1, 2, 1, 0}; !reflect.DeepEqual(levels, want) {
+		t.Errorf("Walk() levels = %v, want %v", levels, want)
+	}
+}
+
+func TestBlockAt(t *testing.T) {
+	page := outline.Parse("title:: Test\n- a\n  id:: 1\n\t- b\n")
+
+	tests := map[string]struct {
+		line int
+		want string
+	}{
+		"preamble":          {line: 0, want: ""},
+		"bullet line":       {line: 1, want: "a"},
+		"continuation line": {line: 2, want: "a"},
+		"child":             {line: 3, want: "b"},
+		"out of range":      {line: 9, want: ""},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			b := page.BlockAt(tt.line)
+			got := ""
+			if b != nil {
+				got = b.Content()
+			}
+			if got != tt.want {
+				t.Errorf("BlockAt(%d) = %q, want %q", tt.line, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetProperty(t *testing.T) {
+	tests := map[string]struct {
+		input    string
+		key      string
+		value    string
+		expected string
+	}{
+		"add to block without properties": {
+			input:    "\t- task\n",
+			key:      "id",
+			value:    "abc",
+			expected: "\t- task\n\t  id:: abc\n",
+		},
+		"replace existing property": {
+			input:    "- task\n  id:: old\n",
+			key:      "id",
+			value:    "new",
+			expected: "- task\n  id:: new\n",
+		},
+		"add below existing properties": {
+			input:    "- task\n  foo:: bar\n  text\n",
+			key:      "id",
+			value:    "abc",
+			expected: "- task\n  foo:: bar\n  id:: abc\n  text\n",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			page := outline.Parse(tt.input)
+			page.Blocks[0].SetProperty(tt.key, tt.value)
+
+			if got := page.String(); got != tt.expected {
+				t.Errorf("SetProperty() = %q, want %q", got, tt.expected)
+			}
+
+			if v, ok := page.Blocks[0].Property(tt.key); !ok || v != tt.value {
+				t.Errorf("Property(%q) = %q, %v", tt.key, v, ok)
+			}
+		})
+	}
+}
+
+func TestParseOrg(t *testing.T) {
+	content := "#+alias: test\n* a\n:PROPERTIES:\n:id: 1\n:END:\n** b\n#+BEGIN_SRC md\n* not a heading\n#+END_SRC\n*** c\n* d\n"
+	page := outline.ParseOrg(content)
+
+	if got := page.String(); got != content {
+		t.Errorf("String() = %q, want %q", got, content)
+	}
+
+	var got []string
+	var levels []int
+	page.Walk(func(b *outline.Block) bool {
+		got = append(got, b.Content())
+		levels = append(levels, b.Level())
+		return true
+	})
+
+	if want := []string{"a", "b", "c", "d"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Walk() contents = %v, want %v", got, want)
+	}
+
+	if want := []int{0, 1, 2, 0}; !reflect.DeepEqual(levels, want) {
+		t.Errorf("Walk() levels = %v, want %v", levels, want)
+	}
+
+	if id, ok := page.Blocks[0].Property("id"); !ok || id != "1" {
+		t.Errorf("Property(id) = %q, %v", id, ok)
+	}
+}
+
+func TestSetOrgProperty(t *testing.T) {
+	tests := map[string]struct {
+		input    string
+		expected string
+	}{
+		"add drawer": {
+			input:    "** task\ntext\n",
+			expected: "** task\n:PROPERTIES:\n:id: abc\n:END:\ntext\n",
+		},
+		"add drawer below planning": {
+			input:    "* TODO task\nSCHEDULED: <2025-01-06 Mon>\n",
+			expected: "* TODO task\nSCHEDULED: <2025-01-06 Mon>\n:PROPERTIES:\n:id: abc\n:END:\n",
+		},
+		"add to existing drawer": {
+			input:    "* task\n:PROPERTIES:\n:foo: bar\n:END:\n",
+			expected: "* task\n:PROPERTIES:\n:foo: bar\n:id: abc\n:END:\n",
+		},
+		"replace existing property": {
+			input:    "* task\n:PROPERTIES:\n:id: old\n:END:\n",
+			expected: "* task\n:PROPERTIES:\n:id: abc\n:END:\n",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			page := outline.ParseFile("page.org", tt.input)
+			page.Blocks[0].SetProperty("id", "abc")
+
+			if got := page.String(); got != tt.expected {
+				t.Errorf("SetProperty() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRemoveProperty(t *testing.T) {
+	tests := map[string]struct {
+		file     string
+		input    string
+		expected string
+	}{
+		"remove property": {
+			file:     "page.md",
+			input:    "\t- task\n\t  collapsed:: true\n\t  id:: abc\n",
+			expected: "\t- task\n\t  id:: abc\n",
+		},
+		"missing property": {
+			file:     "page.md",
+			input:    "- task\n  text collapsed:: true\n",
+			expected: "- task\n  text collapsed:: true\n",
+		},
+		"remove from drawer": {
+			file:     "page.org",
+			input:    "* task\n:PROPERTIES:\n:collapsed: true\n:id: abc\n:END:\n",
+			expected: "* task\n:PROPERTIES:\n:id: abc\n:END:\n",
+		},
+		"drop empty drawer": {
+			file:     "page.org",
+			input:    "* task\n:PROPERTIES:\n:collapsed: true\n:END:\ntext\n",
+			expected: "* task\ntext\n",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			page := outline.ParseFile(tt.file, tt.input)
+			page.Blocks[0].RemoveProperty("collapsed")
+
+			if got := page.String(); got != tt.expected {
+				t.Errorf("RemoveProperty() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/jrswab/lsq/graph"
+	"github.com/jrswab/lsq/picker"
+)
+
+func runPick(args []string) error {
+	fs, dir := newFlagSet("pick")
+	pattern := fs.String("r", "", "Only pick among the lines matching this regex pattern.")
+	editorType := fs.String("e", "", "The editor command to open the page with. Will use :editor/command or $EDITOR when blank or omitted.")
+	fs.Parse(args)
+
+	cfg, err := loadConfig(*dir)
+	if err != nil {
+		return err
+	}
+
+	files, err := graph.All(cfg)
+	if err != nil {
+		return fmt.Errorf("error listing graph files: %v", err)
+	}
+
+	var pages, lines []picker.Item
+	if *pattern != "" {
+		re, err := regexp.Compile(*pattern)
+		if err != nil {
+			return fmt.Errorf("error compiling regex pattern: %v", err)
+		}
+		lines, err = picker.Lines(files, re)
+		if err != nil {
+			return fmt.Errorf("error searching graph: %v", err)
+		}
+	} else {
+		if pages, err = picker.Pages(files); err != nil {
+			return fmt.Errorf("error listing pages: %v", err)
+		}
+		if lines, err = picker.Lines(files, nil); err != nil {
+			return fmt.Errorf("error reading graph: %v", err)
+		}
+	}
+
+	result, err := picker.Run(pages, lines, strings.Join(fs.Args(), " "))
+	if err != nil {
+		return err
+	}
+
+	switch result.Action {
+	case picker.Open:
+		return editFile(cfg, *editorType, result.Item.Path, result.Item.Line)
+	case picker.Append:
+		if err := appendText(cfg, result.Item.Path, result.Text, 0); err != nil {
+			return fmt.Errorf("error appending data to file: %v", err)
+		}
+		fmt.Printf("Appended to %s\n", result.Item.Path)
+	case picker.CopyLink:
+		picker.Copy(result.Item.Link())
+		fmt.Printf("Copied %s\n", result.Item.Link())
+	}
+	return nil
+}
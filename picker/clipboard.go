@@ -0,0 +1,17 @@
+package picker
+
+import (
+	"os"
+
+	"github.com/atotto/clipboard"
+	"github.com/muesli/termenv"
+)
+
+// Copy puts text on the system clipboard. Without a clipboard program, as
+// on remote machines, the terminal is asked to copy it with OSC 52.
+func Copy(text string) {
+	if err := clipboard.WriteAll(text); err == nil {
+		return
+	}
+	termenv.NewOutput(os.Stdout).Copy(text)
+}
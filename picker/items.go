@@ -0,0 +1,141 @@
+package picker
+
+import (
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jrswab/lsq/graph"
+	"github.com/jrswab/lsq/outline"
+)
+
+// Item is a candidate of the picker.
+type Item struct {
+	// Page is the name of the page or journal.
+	Page string
+	Path string
+	// Alias is set when the item is an alias of the page.
+	Alias string
+	// Line is the one-based line of a search hit, zero for pages.
+	Line int
+	// Text is the line of a search hit.
+	Text string
+}
+
+// Key is the text the query is matched against.
+func (i Item) Key() string {
+	switch {
+	case i.Line > 0:
+		return i.Page + " " + i.Text
+	case i.Alias != "":
+		return i.Alias
+	}
+	return i.Page
+}
+
+// Label is how the item is listed.
+func (i Item) Label() string {
+	switch {
+	case i.Line > 0:
+		return i.Page + ":" + strconv.Itoa(i.Line) + ": " + strings.TrimSpace(i.Text)
+	case i.Alias != "":
+		return i.Alias + " → " + i.Page
+	}
+	return i.Page
+}
+
+// Link returns a "[[link]]" to the page of the item, using the alias
+// when the item is one.
+func (i Item) Link() string {
+	if i.Alias != "" {
+		return "[[" + i.Alias + "]]"
+	}
+	return "[[" + i.Page + "]]"
+}
+
+// Pages lists every page and journal by name followed by the aliases of
+// the pages. Pages come before journals and the newest journals first.
+func Pages(files []graph.File) ([]Item, error) {
+	files = append([]graph.File(nil), files...)
+	sort.SliceStable(files, func(i, j int) bool {
+		if files[i].Journal != files[j].Journal {
+			return !files[i].Journal
+		}
+		if files[i].Journal {
+			return files[i].Name > files[j].Name
+		}
+		return false
+	})
+
+	var items, aliases []Item
+	for _, f := range files {
+		items = append(items, Item{Page: f.Name, Path: f.Path})
+		if f.Journal {
+			continue
+		}
+
+		data, err := os.ReadFile(f.Path)
+		if err != nil {
+			return nil, err
+		}
+		for _, alias := range pageAliases(f.Path, string(data)) {
+			aliases = append(aliases, Item{Page: f.Name, Path: f.Path, Alias: alias})
+		}
+	}
+	return append(items, aliases...), nil
+}
+
+// pageAliases reads the "alias::" page property, or "#+alias:" in Org files.
+func pageAliases(path, content string) []string {
+	for _, line := range outline.ParseFile(path, content).Preamble {
+		key, value, ok := outline.ParsePageProperty(outline.IsOrg(path), line)
+		if ok && strings.EqualFold(key, "alias") {
+			return outline.PropertyValues(value)
+		}
+	}
+	return nil
+}
+
+// Lines lists the lines of every file matching pattern, or every line that
+// is not blank when pattern is nil.
+func Lines(files []graph.File, pattern *regexp.Regexp) ([]Item, error) {
+	var items []Item
+	for _, f := range files {
+		data, err := os.ReadFile(f.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		for n, line := range strings.Split(string(data), "\n") {
+			if strings.TrimSpace(line) == "" || pattern != nil && !pattern.MatchString(line) {
+				continue
+			}
+			items = append(items, Item{Page: f.Name, Path: f.Path, Line: n + 1, Text: line})
+		}
+	}
+	return items, nil
+}
+
+// match is an item matching the query.
+type match struct {
+	item      Item
+	score     int
+	positions []int
+}
+
+// filter returns the items matching query, best first. Items that score the
+// same keep their order.
+func filter(items []Item, query string) []match {
+	var matches []match
+	for _, item := range items {
+		if score, positions, ok := Match(query, item.Key()); ok {
+			matches = append(matches, match{item: item, score: score, positions: positions})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].score > matches[j].score
+	})
+	return matches
+}
@@ -0,0 +1,73 @@
+// Package picker lets the user choose a page, alias or search hit in an
+// interactive fuzzy finder with a preview of the page.
+package picker
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// Scores of a fuzzy match. Consecutive characters and characters starting a
+// word count more, so "mn" ranks "Meeting Notes" above "command".
+const (
+	scoreChar        = 1
+	bonusConsecutive = 4
+	bonusWordStart   = 6
+	bonusFirst       = 8
+	penaltyGap       = 1
+	maxGapPenalty    = 5
+)
+
+// Match reports whether every character of query appears in text in order,
+// ignoring case, and scores how well it does. Characters are matched at
+// their first occurrence and positions holds their byte offsets within
+// text. An empty query matches everything with a score of zero.
+func Match(query, text string) (score int, positions []int, ok bool) {
+	if query == "" {
+		return 0, nil, true
+	}
+
+	q := []rune(query)
+	qi := 0
+	// next is the offset after the previous matched character.
+	next := -1
+	var prevRune rune
+
+	for i, r := range text {
+		if qi == len(q) {
+			break
+		}
+
+		if unicode.ToLower(r) != unicode.ToLower(q[qi]) {
+			prevRune = r
+			continue
+		}
+
+		score += scoreChar
+		switch {
+		case i == 0:
+			score += bonusFirst
+		case !isWordChar(prevRune) || unicode.IsUpper(r) && unicode.IsLower(prevRune):
+			score += bonusWordStart
+		}
+		if next == i {
+			score += bonusConsecutive
+		} else if next >= 0 {
+			score -= min((i-next)*penaltyGap, maxGapPenalty)
+		}
+
+		positions = append(positions, i)
+		next = i + utf8.RuneLen(r)
+		prevRune = r
+		qi++
+	}
+
+	if qi < len(q) {
+		return 0, nil, false
+	}
+	return score, positions, true
+}
+
+func isWordChar(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,60 @@
+package picker
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	tests := map[string]struct {
+		query     string
+		text      string
+		ok        bool
+		positions []int
+	}{
+		"empty query":      {query: "", text: "anything", ok: true},
+		"subsequence":      {query: "mtn", text: "meeting notes", ok: true, positions: []int{0, 3, 5}},
+		"ignores case":     {query: "MN", text: "meeting notes", ok: true, positions: []int{0, 5}},
+		"out of order":     {query: "nm", text: "meeting", ok: false},
+		"missing char":     {query: "mx", text: "meeting", ok: false},
+		"non-ascii":        {query: "çi", text: "français", ok: true, positions: []int{4, 7}},
+		"namespace":        {query: "p/n", text: "projects/notes", ok: true, positions: []int{0, 8, 9}},
+		"longer than text": {query: "meetings", text: "meeting", ok: false},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, positions, ok := Match(tt.query, tt.text)
+			if ok != tt.ok {
+				t.Fatalf("Match(%q, %q) ok = %v, want %v", tt.query, tt.text, ok, tt.ok)
+			}
+			if !reflect.DeepEqual(positions, tt.positions) {
+				t.Errorf("Match(%q, %q) positions = %v, want %v", tt.query, tt.text, positions, tt.positions)
+			}
+		})
+	}
+}
+
+func TestMatchRanking(t *testing.T) {
+	tests := map[string]struct {
+		query  string
+		better string
+		worse  string
+	}{
+		"word starts":  {query: "mn", better: "meeting notes", worse: "command"},
+		"consecutive":  {query: "note", better: "notebook", worse: "no time"},
+		"prefix":       {query: "pro", better: "projects", worse: "my projects"},
+		"camel case":   {query: "gh", better: "GitHub", worse: "weigh"},
+		"shorter gaps": {query: "ab", better: "a b", worse: "a   b"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			better, _, _ := Match(tt.query, tt.better)
+			worse, _, _ := Match(tt.query, tt.worse)
+			if better <= worse {
+				t.Errorf("Match(%q) scores %q %d, %q %d", tt.query, tt.better, better, tt.worse, worse)
+			}
+		})
+	}
+}
@@ -0,0 +1,293 @@
+package picker
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Action is what the user chose to do with the selected item.
+type Action int
+
+const (
+	// Cancel means the picker was closed without choosing anything.
+	Cancel Action = iota
+	Open
+	Append
+	CopyLink
+)
+
+// Result is the item chosen in the picker and what to do with it.
+type Result struct {
+	Action Action
+	Item   Item
+	// Text is the text to append for Append.
+	Text string
+}
+
+const help = "enter open  ctrl+a append  ctrl+y copy link  tab pages/lines  esc quit"
+
+var (
+	selectedStyle = lipgloss.NewStyle().Reverse(true)
+	matchStyle    = lipgloss.NewStyle().Bold(true).Underline(true)
+	dimStyle      = lipgloss.NewStyle().Faint(true)
+)
+
+// Run shows the picker until the user chooses an item or quits. pages are
+// listed first; tab switches to lines for a full-text search. Either may
+// be empty, e.g. to only pick among the hits of a regular expression.
+func Run(pages, lines []Item, query string) (Result, error) {
+	final, err := tea.NewProgram(newModel(pages, lines, query), tea.WithAltScreen()).Run()
+	if err != nil {
+		return Result{}, err
+	}
+	return final.(model).result, nil
+}
+
+type model struct {
+	sets    [2][]Item
+	set     int
+	matches []match
+	cursor  int
+	offset  int
+
+	query     textinput.Model
+	appending bool
+	text      textinput.Model
+
+	// preview caches the lines of the files shown in the preview.
+	preview map[string][]string
+
+	result        Result
+	width, height int
+}
+
+func newModel(pages, lines []Item, query string) model {
+	q := textinput.New()
+	q.Prompt = "> "
+	q.SetValue(query)
+	q.Focus()
+
+	text := textinput.New()
+	text.Prompt = "Append: "
+
+	m := model{sets: [2][]Item{pages, lines}, query: q, text: text, preview: make(map[string][]string), width: 80, height: 24}
+	if len(pages) == 0 {
+		m.set = 1
+	}
+	m.matches = filter(m.sets[m.set], query)
+	return m
+}
+
+func (m model) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		return m, nil
+	case tea.KeyMsg:
+		if m.appending {
+			return m.updateAppend(msg)
+		}
+		return m.updateSearch(msg)
+	}
+	return m, nil
+}
+
+func (m model) updateSearch(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "ctrl+c":
+		return m, tea.Quit
+	case "up", "ctrl+p", "ctrl+k":
+		m.cursor = max(m.cursor-1, 0)
+		return m, nil
+	case "down", "ctrl+n", "ctrl+j":
+		m.cursor = min(m.cursor+1, max(len(m.matches)-1, 0))
+		return m, nil
+	case "tab":
+		if len(m.sets[0]) > 0 && len(m.sets[1]) > 0 {
+			m.set = 1 - m.set
+			m = m.refilter()
+		}
+		return m, nil
+	}
+
+	item, ok := m.selected()
+	switch msg.String() {
+	case "enter":
+		if ok {
+			m.result = Result{Action: Open, Item: item}
+			return m, tea.Quit
+		}
+		return m, nil
+	case "ctrl+y":
+		if ok {
+			m.result = Result{Action: CopyLink, Item: item}
+			return m, tea.Quit
+		}
+		return m, nil
+	case "ctrl+a":
+		if ok {
+			m.appending = true
+			m.query.Blur()
+			m.text.Focus()
+		}
+		return m, nil
+	}
+
+	before := m.query.Value()
+	var cmd tea.Cmd
+	m.query, cmd = m.query.Update(msg)
+	if m.query.Value() != before {
+		m = m.refilter()
+	}
+	return m, cmd
+}
+
+func (m model) updateAppend(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.appending = false
+		m.text.Blur()
+		m.query.Focus()
+		return m, nil
+	case "ctrl+c":
+		return m, tea.Quit
+	case "enter":
+		if strings.TrimSpace(m.text.Value()) == "" {
+			return m, nil
+		}
+		item, _ := m.selected()
+		m.result = Result{Action: Append, Item: item, Text: m.text.Value()}
+		return m, tea.Quit
+	}
+
+	var cmd tea.Cmd
+	m.text, cmd = m.text.Update(msg)
+	return m, cmd
+}
+
+func (m model) refilter() model {
+	m.matches = filter(m.sets[m.set], m.query.Value())
+	m.cursor, m.offset = 0, 0
+	return m
+}
+
+func (m model) selected() (Item, bool) {
+	if m.cursor >= len(m.matches) {
+		return Item{}, false
+	}
+	return m.matches[m.cursor].item, true
+}
+
+func (m model) View() string {
+	// The prompt and the help line take two lines.
+	height := max(m.height-2, 1)
+
+	listWidth, previewWidth := m.width, 0
+	if m.width >= 60 {
+		listWidth = m.width * 2 / 5
+		previewWidth = m.width - listWidth - 1
+	}
+
+	list := m.list(listWidth, height)
+	body := lipgloss.NewStyle().Width(listWidth).Height(height).MaxHeight(height).Render(list)
+	if previewWidth > 0 {
+		preview := m.previewLines(previewWidth, height)
+		body = lipgloss.JoinHorizontal(lipgloss.Top, body, " ",
+			lipgloss.NewStyle().Width(previewWidth).Height(height).MaxHeight(height).Render(preview))
+	}
+
+	prompt := m.query.View() + dimStyle.Render(fmt.Sprintf("  %d/%d", len(m.matches), len(m.sets[m.set])))
+	status := dimStyle.Render(help)
+	if m.appending {
+		item, _ := m.selected()
+		status = m.text.View() + dimStyle.Render("  to "+item.Page)
+	}
+	return prompt + "\n" + body + "\n" + status
+}
+
+// list renders the matches that fit in height lines, scrolling so the
+// selected match stays visible.
+func (m *model) list(width, height int) string {
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+height {
+		m.offset = m.cursor - height + 1
+	}
+
+	var rows []string
+	for i := m.offset; i < len(m.matches) && i < m.offset+height; i++ {
+		row := truncate(highlight(m.matches[i]), width)
+		if i == m.cursor {
+			row = selectedStyle.Render(truncate(m.matches[i].item.Label(), width))
+		}
+		rows = append(rows, row)
+	}
+	return strings.Join(rows, "\n")
+}
+
+// highlight marks the matched characters of the label.
+func highlight(mt match) string {
+	label := mt.item.Label()
+	if len(mt.positions) == 0 || !strings.HasPrefix(label, mt.item.Key()) {
+		return label
+	}
+
+	var sb strings.Builder
+	last := 0
+	for _, pos := range mt.positions {
+		end := pos + len(string([]rune(label[pos:])[0]))
+		sb.WriteString(label[last:pos])
+		sb.WriteString(matchStyle.Render(label[pos:end]))
+		last = end
+	}
+	sb.WriteString(label[last:])
+	return sb.String()
+}
+
+// previewLines shows the page of the selected item, centred on the line
+// of a search hit.
+func (m *model) previewLines(width, height int) string {
+	item, ok := m.selected()
+	if !ok {
+		return ""
+	}
+
+	lines, cached := m.preview[item.Path]
+	if !cached {
+		data, err := os.ReadFile(item.Path)
+		if err != nil {
+			return dimStyle.Render(err.Error())
+		}
+		lines = strings.Split(strings.ReplaceAll(string(data), "\t", "    "), "\n")
+		m.preview[item.Path] = lines
+	}
+
+	start := 0
+	if item.Line > 0 {
+		start = max(item.Line-1-height/3, 0)
+	}
+
+	var rows []string
+	for i := start; i < len(lines) && len(rows) < height; i++ {
+		row := truncate(lines[i], width)
+		if i == item.Line-1 {
+			row = selectedStyle.Render(row)
+		}
+		rows = append(rows, row)
+	}
+	return strings.Join(rows, "\n")
+}
+
+func truncate(s string, width int) string {
+	return lipgloss.NewStyle().MaxWidth(width).Render(s)
+}
@@ -0,0 +1,159 @@
+package picker
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/jrswab/lsq/graph"
+)
+
+func testFiles(t *testing.T) []graph.File {
+	t.Helper()
+	dir := t.TempDir()
+
+	contents := map[string]string{
+		"meeting notes.md": "alias:: standup, daily\n\n- agenda\n- TODO send notes\n",
+		"projects.org":     "#+alias: work\n* plan\n",
+		"2025_01_02.md":    "- TODO call\n\n- done\n",
+	}
+
+	var files []graph.File
+	for _, name := range []string{"meeting notes.md", "projects.org", "2025_01_02.md"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(contents[name]), 0644); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, graph.File{
+			Name:    name[:len(name)-len(filepath.Ext(name))],
+			Path:    path,
+			Journal: name == "2025_01_02.md",
+		})
+	}
+	return files
+}
+
+func TestPages(t *testing.T) {
+	items, err := Pages(testFiles(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var labels []string
+	for _, item := range items {
+		labels = append(labels, item.Label())
+	}
+
+	want := []string{"meeting notes", "projects", "2025_01_02", "standup → meeting notes", "daily → meeting notes", "work → projects"}
+	if !reflect.DeepEqual(labels, want) {
+		t.Errorf("Pages() = %q, want %q", labels, want)
+	}
+
+	if got := items[3].Link(); got != "[[standup]]" {
+		t.Errorf("Link() = %q, want [[standup]]", got)
+	}
+}
+
+func TestLines(t *testing.T) {
+	tests := map[string]struct {
+		pattern *regexp.Regexp
+		want    []string
+	}{
+		"every line": {
+			want: []string{
+				"meeting notes:1: alias:: standup, daily", "meeting notes:3: - agenda", "meeting notes:4: - TODO send notes",
+				"projects:1: #+alias: work", "projects:2: * plan",
+				"2025_01_02:1: - TODO call", "2025_01_02:3: - done",
+			},
+		},
+		"pattern": {
+			pattern: regexp.MustCompile(`TODO`),
+			want:    []string{"meeting notes:4: - TODO send notes", "2025_01_02:1: - TODO call"},
+		},
+	}
+
+	files := testFiles(t)
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			items, err := Lines(files, tt.pattern)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var labels []string
+			for _, item := range items {
+				labels = append(labels, item.Label())
+			}
+			if !reflect.DeepEqual(labels, tt.want) {
+				t.Errorf("Lines() = %q, want %q", labels, tt.want)
+			}
+		})
+	}
+}
+
+func TestModel(t *testing.T) {
+	files := testFiles(t)
+	pages, err := Pages(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines, err := Lines(files, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys := func(s string) tea.KeyMsg { return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)} }
+
+	tests := map[string]struct {
+		query string
+		msgs  []tea.Msg
+		want  Result
+	}{
+		"open best match": {
+			query: "proj",
+			msgs:  []tea.Msg{tea.KeyMsg{Type: tea.KeyEnter}},
+			want:  Result{Action: Open, Item: pages[1]},
+		},
+		"type": {
+			msgs: []tea.Msg{keys("wrk"), tea.KeyMsg{Type: tea.KeyCtrlY}},
+			want: Result{Action: CopyLink, Item: pages[5]},
+		},
+		"move down": {
+			msgs: []tea.Msg{tea.KeyMsg{Type: tea.KeyDown}, tea.KeyMsg{Type: tea.KeyDown}, tea.KeyMsg{Type: tea.KeyUp}, tea.KeyMsg{Type: tea.KeyCtrlY}},
+			want: Result{Action: CopyLink, Item: pages[1]},
+		},
+		"append": {
+			query: "work",
+			msgs: []tea.Msg{
+				tea.KeyMsg{Type: tea.KeyCtrlA}, keys("hello"), tea.KeyMsg{Type: tea.KeyEnter},
+			},
+			want: Result{Action: Append, Item: pages[5], Text: "hello"},
+		},
+		"full text": {
+			query: "call",
+			msgs:  []tea.Msg{tea.KeyMsg{Type: tea.KeyTab}, tea.KeyMsg{Type: tea.KeyEnter}},
+			want:  Result{Action: Open, Item: lines[5]},
+		},
+		"cancel": {
+			msgs: []tea.Msg{tea.KeyMsg{Type: tea.KeyEsc}},
+			want: Result{Action: Cancel},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var m tea.Model = newModel(pages, lines, tt.query)
+			for _, msg := range tt.msgs {
+				m, _ = m.Update(msg)
+				m.View()
+			}
+
+			if got := m.(model).result; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("result = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/jrswab/lsq/config"
+	"github.com/jrswab/lsq/graph"
+	"github.com/jrswab/lsq/outline"
+	"github.com/jrswab/lsq/render"
+	"github.com/jrswab/lsq/system"
+)
+
+func runRef(args []string) error {
+	fs, dir := newFlagSet("ref")
+	line := fs.Int("line", 0, "Line number of the block to reference.")
+	page := fs.String("p", "", "Page containing the block. Must be a file name with extension.")
+	specDate := fs.String("s", "", "Journal containing the block. Use yyyy-MM-dd after the flag.")
+	check := fs.Bool("check", false, "Report block references that point at missing blocks.")
+	fs.Parse(args)
+
+	cfg, err := loadConfig(*dir)
+	if err != nil {
+		return err
+	}
+
+	if *check {
+		return checkRefs(cfg)
+	}
+
+	if *line < 1 {
+		return fmt.Errorf("usage: lsq ref -line N [-p page | -s yyyy-MM-dd] or lsq ref -check")
+	}
+
+	path := filepath.Join(cfg.PagesDir, *page)
+	if *page == "" {
+		path, err = system.GetJournal(cfg, cfg.JournalsDir, *specDate)
+		if err != nil {
+			return fmt.Errorf("error setting journal path: %v", err)
+		}
+	}
+
+	id, err := system.BlockID(path, *line)
+	if err != nil {
+		return fmt.Errorf("error referencing block: %v", err)
+	}
+
+	fmt.Printf("((%s))\n", id)
+	return nil
+}
+
+// checkRefs prints every dangling block reference and fails when there are any.
+func checkRefs(cfg *config.Config) error {
+	files, err := graph.All(cfg)
+	if err != nil {
+		return fmt.Errorf("error listing graph files: %v", err)
+	}
+
+	idx, err := render.NewIndex(files)
+	if err != nil {
+		return fmt.Errorf("error indexing blocks: %v", err)
+	}
+
+	dangling := render.FindDangling(files, idx)
+	for _, d := range dangling {
+		fmt.Printf("%s#%d: ((%s))\n", d.File.Path, d.Line, d.ID)
+	}
+
+	if len(dangling) > 0 {
+		return fmt.Errorf("found %d dangling block reference(s)", len(dangling))
+	}
+	return nil
+}
+
+// printPage writes the file to STDOUT with its block references resolved.
+// When rendered is true the page is formatted as an outline for terminals.
+func printPage(cfg *config.Config, path string, rendered, children bool) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("error reading file: %w", err)
+	}
+
+	if !rendered && !render.HasRefs(string(data)) {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+
+	files, err := graph.All(cfg)
+	if err != nil {
+		return fmt.Errorf("error listing graph files: %w", err)
+	}
+
+	idx, err := render.NewIndex(files)
+	if err != nil {
+		return fmt.Errorf("error indexing blocks: %w", err)
+	}
+
+	page := outline.ParseFile(path, string(data))
+
+	if rendered {
+		_, err = os.Stdout.WriteString(render.Terminal(page, idx, render.Options{
+			Hyperlinks:     cfg.Hyperlinks,
+			HideProperties: cfg.HideProperties,
+			Children:       children,
+			Bullets:        cfg.Bullets,
+		}))
+		return err
+	}
+
+	render.ResolveRefs(page, idx, children)
+
+	_, err = os.Stdout.WriteString(page.String())
+	return err
+}
@@ -0,0 +1,211 @@
+// Package render prepares Logseq pages for display outside of Logseq.
+package render
+
+import (
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/jrswab/lsq/graph"
+	"github.com/jrswab/lsq/outline"
+)
+
+// maxDepth stops references that point back at themselves from recursing forever.
+const maxDepth = 8
+
+var (
+	refRe        = regexp.MustCompile(`\(\(([0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12})\)\)`)
+	blockEmbedRe = regexp.MustCompile(`\{\{embed \(\(([0-9a-fA-F-]{36})\)\)\}\}`)
+)
+
+// Located is a block and the file it was found in.
+type Located struct {
+	File  graph.File
+	Block *outline.Block
+}
+
+// Index maps block ids to the blocks that define them
+// and page names and aliases to their files.
+type Index struct {
+	blocks map[string]Located
+	pages  map[string]*outline.Page
+	names  map[string]graph.File
+}
+
+// NewIndex reads every file and records the blocks with an "id::" property.
+func NewIndex(files []graph.File) (*Index, error) {
+	idx := &Index{
+		blocks: make(map[string]Located),
+		pages:  make(map[string]*outline.Page, len(files)),
+		names:  make(map[string]graph.File, len(files)),
+	}
+
+	for _, f := range files {
+		data, err := os.ReadFile(f.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		page := outline.ParseFile(f.Path, string(data))
+		idx.pages[f.Path] = page
+		idx.names[strings.ToLower(f.Name)] = f
+
+		for _, line := range page.Preamble {
+			if key, value, ok := outline.ParsePageProperty(outline.IsOrg(f.Path), line); ok && strings.EqualFold(key, "alias") {
+				for _, alias := range outline.PropertyValues(value) {
+					// Page names take precedence over aliases.
+					if _, exists := idx.names[strings.ToLower(alias)]; !exists {
+						idx.names[strings.ToLower(alias)] = f
+					}
+				}
+			}
+		}
+
+		page.Walk(func(b *outline.Block) bool {
+			if id, ok := b.Property("id"); ok {
+				idx.blocks[strings.ToLower(strings.TrimSpace(id))] = Located{File: f, Block: b}
+			}
+			return true
+		})
+	}
+
+	return idx, nil
+}
+
+// Block looks up a block by its id.
+func (idx *Index) Block(id string) (Located, bool) {
+	loc, ok := idx.blocks[strings.ToLower(id)]
+	return loc, ok
+}
+
+// Page returns the parsed page of a file read while building the index.
+func (idx *Index) Page(path string) (*outline.Page, bool) {
+	page, ok := idx.pages[path]
+	return page, ok
+}
+
+// File looks up the file of a page by its name or one of its aliases.
+func (idx *Index) File(name string) (graph.File, bool) {
+	f, ok := idx.names[strings.ToLower(name)]
+	return f, ok
+}
+
+// HasRefs reports whether content contains block references or embeds.
+func HasRefs(content string) bool {
+	return refRe.MatchString(content)
+}
+
+// Refs returns the block ids referenced within text.
+func Refs(text string) []string {
+	var ids []string
+	for _, m := range refRe.FindAllStringSubmatch(text, -1) {
+		ids = append(ids, m[1])
+	}
+	return ids
+}
+
+// ResolveRefs replaces "((id))" references with the text of the referenced block
+// and expands "{{embed ((id))}}" into the referenced block and its children.
+// When children is true plain references also bring their children along.
+func ResolveRefs(page *outline.Page, idx *Index, children bool) {
+	r := resolver{idx: idx, children: children}
+	page.Walk(func(b *outline.Block) bool {
+		b.Lines = r.block(b)
+		return true
+	})
+}
+
+type resolver struct {
+	idx      *Index
+	children bool
+}
+
+// block resolves the lines of b and appends the children of embedded blocks.
+func (r resolver) block(b *outline.Block) []string {
+	var extra []string
+
+	expand := func(id string) {
+		loc, ok := r.idx.Block(id)
+		if !ok {
+			return
+		}
+		for _, child := range loc.Block.Children {
+			for _, line := range child.Subtree() {
+				if isIDProperty(line) {
+					continue
+				}
+				line = b.Indent + strings.TrimPrefix(line, loc.Block.Indent)
+				extra = append(extra, r.text(line, 1))
+			}
+		}
+	}
+
+	lines := make([]string, 0, len(b.Lines))
+	for _, line := range b.Lines {
+		for _, m := range blockEmbedRe.FindAllStringSubmatch(line, -1) {
+			expand(m[1])
+		}
+		if r.children {
+			for _, m := range refRe.FindAllStringSubmatch(blockEmbedRe.ReplaceAllString(line, ""), -1) {
+				expand(m[1])
+			}
+		}
+		lines = append(lines, r.text(line, 0))
+	}
+
+	return append(lines, extra...)
+}
+
+// text replaces every reference and embed within line with the referenced text.
+func (r resolver) text(line string, depth int) string {
+	if depth >= maxDepth {
+		return line
+	}
+
+	replace := func(re *regexp.Regexp) func(string) string {
+		return func(match string) string {
+			id := re.FindStringSubmatch(match)[1]
+			loc, ok := r.idx.Block(id)
+			if !ok {
+				return match
+			}
+			return r.text(loc.Block.Content(), depth+1)
+		}
+	}
+
+	line = blockEmbedRe.ReplaceAllStringFunc(line, replace(blockEmbedRe))
+	return refRe.ReplaceAllStringFunc(line, replace(refRe))
+}
+
+func isIDProperty(line string) bool {
+	key, _, ok := outline.ParseProperty(line)
+	return ok && strings.EqualFold(key, "id")
+}
+
+// Dangling is a block reference whose target does not exist in the graph.
+type Dangling struct {
+	File graph.File
+	// Line is the one-based line number of the reference.
+	Line int
+	ID   string
+}
+
+// FindDangling lists the references of every indexed file that cannot be resolved.
+func FindDangling(files []graph.File, idx *Index) []Dangling {
+	var dangling []Dangling
+	for _, f := range files {
+		page, ok := idx.Page(f.Path)
+		if !ok {
+			continue
+		}
+
+		for i, line := range strings.Split(page.String(), "\n") {
+			for _, id := range Refs(line) {
+				if _, ok := idx.Block(id); !ok {
+					dangling = append(dangling, Dangling{File: f, Line: i + 1, ID: id})
+				}
+			}
+		}
+	}
+	return dangling
+}
@@ -0,0 +1,112 @@
+package render_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jrswab/lsq/graph"
+	"github.com/jrswab/lsq/outline"
+	"github.com/jrswab/lsq/render"
+)
+
+const (
+	idA = "6500a1b2-0000-4000-8000-00000000000a"
+	idB = "6500a1b2-0000-4000-8000-00000000000b"
+	idC = "6500a1b2-0000-4000-8000-00000000000c"
+	idX = "6500a1b2-0000-4000-8000-0000000000ff"
+)
+
+func writeFiles(t *testing.T, files map[string]string) []graph.File {
+	t.Helper()
+
+	dir := t.TempDir()
+	var out []graph.File
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		out = append(out, graph.File{Path: path, Name: graph.PageName(name)})
+	}
+	return out
+}
+
+func TestResolveRefs(t *testing.T) {
+	files := writeFiles(t, map[string]string{
+		"source.md": "- Buy milk\n  id:: " + idA + "\n\t- whole milk\n\t- two litres\n" +
+			"- See ((" + idA + "))\n  id:: " + idB + "\n",
+		"org source.org": "#+alias: orgy\n\n* TODO Org task\n:PROPERTIES:\n:id: " + idC + "\n:END:\n",
+	})
+
+	idx, err := render.NewIndex(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f, ok := idx.File("orgy"); !ok || f.Name != "org source" {
+		t.Errorf("File(%q) = %+v, %v, want the Org page of the alias", "orgy", f, ok)
+	}
+
+	tests := map[string]struct {
+		input    string
+		children bool
+		expected string
+	}{
+		"inline reference": {
+			input:    "- Remember ((" + idA + ")) today\n",
+			expected: "- Remember Buy milk today\n",
+		},
+		"nested reference": {
+			input:    "- ((" + idB + "))\n",
+			expected: "- See Buy milk\n",
+		},
+		"reference with children": {
+			input:    "\t- ((" + idA + "))\n",
+			children: true,
+			expected: "\t- Buy milk\n\t\t- whole milk\n\t\t- two litres\n",
+		},
+		"embed includes children": {
+			input:    "- {{embed ((" + idA + "))}}\n- next\n",
+			expected: "- Buy milk\n\t- whole milk\n\t- two litres\n- next\n",
+		},
+		"reference to an Org block": {
+			input:    "- Do ((" + idC + "))\n",
+			expected: "- Do TODO Org task\n",
+		},
+		"dangling reference is kept": {
+			input:    "- ((" + idX + "))\n",
+			expected: "- ((" + idX + "))\n",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			page := outline.Parse(tt.input)
+			render.ResolveRefs(page, idx, tt.children)
+			if got := page.String(); got != tt.expected {
+				t.Errorf("ResolveRefs() =\n%q\nwant\n%q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestFindDangling(t *testing.T) {
+	files := writeFiles(t, map[string]string{
+		"a.md": "- target\n  id:: " + idA + "\n",
+		"b.md": "- ((" + idA + "))\n- ((" + idX + "))\n",
+	})
+
+	idx, err := render.NewIndex(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dangling := render.FindDangling(files, idx)
+	if len(dangling) != 1 {
+		t.Fatalf("FindDangling() = %v, want one reference", dangling)
+	}
+
+	if d := dangling[0]; d.ID != idX || d.Line != 2 || d.File.Name != "b" {
+		t.Errorf("FindDangling() = %+v", d)
+	}
+}
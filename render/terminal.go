@@ -0,0 +1,186 @@
+package render
+
+import (
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/jrswab/lsq/outline"
+)
+
+// DefaultBullets are used for each level of the outline when none are configured.
+var DefaultBullets = []string{"•", "◦", "▪"}
+
+var (
+	pageEmbedRe = regexp.MustCompile(`\{\{embed \[\[([^\[\]]+)\]\]\}\}`)
+	tagLinkRe   = regexp.MustCompile(`#\[\[([^\[\]]+)\]\]`)
+	pageLinkRe  = regexp.MustCompile(`\[\[([^\[\]]+)\]\]`)
+	labelLinkRe = regexp.MustCompile(`\[([^\[\]]+)\]\(\[\[([^\[\]]+)\]\]\)`)
+)
+
+// Options controls how a page is rendered for the terminal.
+type Options struct {
+	// Hyperlinks renders links as OSC 8 terminal hyperlinks to the page files.
+	Hyperlinks bool
+	// HideProperties drops "key:: value" lines and drawers such as :LOGBOOK:.
+	HideProperties bool
+	// Children includes the children of plain block references.
+	Children bool
+	// Bullets are used for each level of the outline, repeating when exhausted.
+	Bullets []string
+}
+
+// Terminal renders a page as an indented outline for display in a terminal.
+// Block references and page embeds are expanded using the index.
+func Terminal(page *outline.Page, idx *Index, opts Options) string {
+	if len(opts.Bullets) == 0 {
+		opts.Bullets = DefaultBullets
+	}
+
+	ResolveRefs(page, idx, opts.Children)
+	page = embedPages(page, idx)
+
+	var sb strings.Builder
+	for _, line := range page.Preamble {
+		if opts.HideProperties && (outline.IsProperty(line) || strings.TrimSpace(line) == "") {
+			continue
+		}
+		sb.WriteString(renderLinks(line, idx, opts) + "\n")
+	}
+
+	page.Walk(func(b *outline.Block) bool {
+		indent := strings.Repeat("  ", b.Level())
+		bullet := opts.Bullets[b.Level()%len(opts.Bullets)]
+
+		sb.WriteString(indent + bullet + " " + renderLinks(b.Content(), idx, opts) + "\n")
+
+		pad := indent + strings.Repeat(" ", len([]rune(bullet))+1)
+		drawer := false
+		for _, line := range b.Body() {
+			trimmed := strings.TrimSpace(line)
+			if opts.HideProperties {
+				if drawer || isDrawerStart(trimmed) {
+					drawer = trimmed != ":END:"
+					continue
+				}
+				if outline.IsProperty(line) {
+					continue
+				}
+			}
+			sb.WriteString(pad + renderLinks(line, idx, opts) + "\n")
+		}
+		return true
+	})
+
+	return sb.String()
+}
+
+// embedPages replaces "{{embed [[page]]}}" with the blocks of the embedded page,
+// written in the format of the host page so they become children of the block.
+func embedPages(page *outline.Page, idx *Index) *outline.Page {
+	org := len(page.Blocks) > 0 && page.Blocks[0].Org()
+	for depth := 0; depth < maxDepth && pageEmbedRe.MatchString(page.String()); depth++ {
+		page.Walk(func(b *outline.Block) bool {
+			var extra []string
+			for i, line := range b.Lines {
+				b.Lines[i] = pageEmbedRe.ReplaceAllStringFunc(line, func(match string) string {
+					name := pageEmbedRe.FindStringSubmatch(match)[1]
+					if embedded := embeddedPage(idx, name); embedded != nil {
+						for _, e := range embedded.Blocks {
+							extra = append(extra, embedLines(org, b.Level()+1, e)...)
+						}
+					}
+					return name
+				})
+			}
+			b.Lines = append(b.Lines, extra...)
+			return true
+		})
+
+		// Parse again so the embedded blocks become children in the tree.
+		if org {
+			page = outline.ParseOrg(page.String())
+		} else {
+			page = outline.Parse(page.String())
+		}
+	}
+	return page
+}
+
+// embedLines returns the lines of b and its children as blocks at level of
+// a Markdown or Org page.
+func embedLines(org bool, level int, b *outline.Block) []string {
+	lines := []string{outline.Bullet(org, level, b.Content())}
+
+	indent := ""
+	if !org {
+		indent = strings.Repeat("\t", level) + "  "
+	}
+	for _, line := range b.Body() {
+		lines = append(lines, indent+line)
+	}
+
+	for _, child := range b.Children {
+		lines = append(lines, embedLines(org, level+1, child)...)
+	}
+	return lines
+}
+
+func embeddedPage(idx *Index, name string) *outline.Page {
+	f, ok := idx.File(name)
+	if !ok {
+		return nil
+	}
+	page, ok := idx.Page(f.Path)
+	if !ok {
+		return nil
+	}
+	return page
+}
+
+func isDrawerStart(line string) bool {
+	return len(line) > 2 && strings.HasPrefix(line, ":") && strings.HasSuffix(line, ":") &&
+		strings.ToUpper(line) == line && line != ":END:"
+}
+
+// renderLinks replaces Logseq link syntax with plain text or terminal hyperlinks.
+func renderLinks(text string, idx *Index, opts Options) string {
+	link := func(label, name string) string {
+		if !opts.Hyperlinks {
+			return label
+		}
+		f, ok := idx.File(name)
+		if !ok {
+			return label
+		}
+		return Hyperlink(fileURL(f.Path), label)
+	}
+
+	text = labelLinkRe.ReplaceAllStringFunc(text, func(match string) string {
+		m := labelLinkRe.FindStringSubmatch(match)
+		return link(m[1], m[2])
+	})
+	text = tagLinkRe.ReplaceAllStringFunc(text, func(match string) string {
+		name := tagLinkRe.FindStringSubmatch(match)[1]
+		return link("#"+name, name)
+	})
+	return pageLinkRe.ReplaceAllStringFunc(text, func(match string) string {
+		name := pageLinkRe.FindStringSubmatch(match)[1]
+		return link(name, name)
+	})
+}
+
+// Hyperlink wraps text in an OSC 8 escape sequence pointing at target.
+func Hyperlink(target, text string) string {
+	return "\x1b]8;;" + target + "\x1b\\" + text + "\x1b]8;;\x1b\\"
+}
+
+func fileURL(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		abs = path
+	}
+	u := url.URL{Scheme: "file", Path: filepath.ToSlash(abs)}
+	return u.String()
+}
@@ -0,0 +1,94 @@
+package render_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/jrswab/lsq/outline"
+	"github.com/jrswab/lsq/render"
+)
+
+func TestTerminal(t *testing.T) {
+	files := writeFiles(t, map[string]string{
+		"Handbook.md": "alias:: hb\n\n- Welcome to [[Team]]\n\t- read [the rules]([[Rules]])\n",
+		"Team.md":     "- members\n",
+		"Notes.org":   "* org block\n:PROPERTIES:\n:id: 1\n:END:\n** org child\n",
+	})
+
+	idx, err := render.NewIndex(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	team, _ := idx.File("team")
+
+	tests := map[string]struct {
+		// name is the file the input is parsed as, a Markdown page when empty.
+		name     string
+		input    string
+		opts     render.Options
+		expected string
+	}{
+		"bullets and indentation": {
+			input:    "- one\n\t- two\n\t\t- three\n\t\t\t- four\n",
+			expected: "• one\n  ◦ two\n    ▪ three\n      • four\n",
+		},
+		"custom bullets": {
+			input:    "- one\n\t- two\n",
+			opts:     render.Options{Bullets: []string{"*", "-"}},
+			expected: "* one\n  - two\n",
+		},
+		"plain links": {
+			input:    "- see [[Team]], #[[big tag]] and #small\n",
+			expected: "• see Team, #big tag and #small\n",
+		},
+		"continuation lines are aligned": {
+			input:    "- TODO task\n  id:: 1\n  more text\n",
+			expected: "• TODO task\n  id:: 1\n  more text\n",
+		},
+		"hide properties and drawers": {
+			input:    "title:: x\n\n- TODO task\n  id:: 1\n  :LOGBOOK:\n  CLOCK: [2025-01-01 Wed 10:00:00]\n  :END:\n  more text\n",
+			opts:     render.Options{HideProperties: true},
+			expected: "• TODO task\n  more text\n",
+		},
+		"page embed by alias": {
+			input:    "- {{embed [[hb]]}}\n- after\n",
+			expected: "• hb\n  ◦ Welcome to Team\n    ▪ read the rules\n• after\n",
+		},
+		"page embed in an Org page": {
+			name:     "host.org",
+			input:    "* {{embed [[hb]]}}\n* after\n",
+			expected: "• hb\n  ◦ Welcome to Team\n    ▪ read the rules\n• after\n",
+		},
+		"embed of an Org page": {
+			input:    "- {{embed [[Notes]]}}\n",
+			opts:     render.Options{HideProperties: true},
+			expected: "• Notes\n  ◦ org block\n    ▪ org child\n",
+		},
+		"hyperlinks to existing pages": {
+			input:    "- [[Team]] [[Missing]]\n",
+			opts:     render.Options{Hyperlinks: true},
+			expected: "• \x1b]8;;file://" + team.Path + "\x1b\\Team\x1b]8;;\x1b\\ Missing\n",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			name := tt.name
+			if name == "" {
+				name = "page.md"
+			}
+			got := render.Terminal(outline.ParseFile(name, tt.input), idx, tt.opts)
+			if got != tt.expected {
+				t.Errorf("Terminal() =\n%q\nwant\n%q", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestHyperlink(t *testing.T) {
+	got := render.Hyperlink("file:///tmp/a.md", "a")
+	if !strings.HasPrefix(got, "\x1b]8;;file:///tmp/a.md\x1b\\a") {
+		t.Errorf("Hyperlink() = %q", got)
+	}
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/jrswab/lsq/search"
+)
+
+func runSearch(args []string) error {
+	fs, dir := newFlagSet("search")
+	after := fs.Int("A", 0, "Print this many lines of context after each match.")
+	before := fs.Int("B", 0, "Print this many lines of context before each match.")
+	around := fs.Int("C", 0, "Print this many lines of context around each match.")
+	ignoreCase := fs.Bool("i", false, "Match regardless of case.")
+	word := fs.Bool("w", false, "Only match whole words.")
+	count := fs.Bool("c", false, "Print the number of matching lines of each file instead of the lines.")
+	filesOnly := fs.Bool("l", false, "Print only the paths of the files with a match.")
+	journals := fs.Bool("journals", false, "Only search the journals.")
+	pages := fs.Bool("pages", false, "Only search the pages.")
+	from := fs.String("from", "", "Only search the journals from this day on. Use yyyy-MM-dd.")
+	to := fs.String("to", "", "Only search the journals up to this day. Use yyyy-MM-dd.")
+	workers := fs.Int("j", 0, "Number of files to search at once. Defaults to the number of CPUs.")
+	openFirst := fs.Bool("o", false, "Open the first match in the editor at its line.")
+	editorType := fs.String("e", "", "The editor command to open the match with. Will use :editor/command or $EDITOR when blank or omitted.")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: lsq search [flags] pattern")
+	}
+
+	opts := search.Options{
+		Journals: *journals,
+		Pages:    *pages,
+		Before:   max(*before, *around),
+		After:    max(*after, *around),
+		Workers:  *workers,
+	}
+	var err error
+	if opts.From, err = parseDay("-from", *from); err != nil {
+		return err
+	}
+	if opts.To, err = parseDay("-to", *to); err != nil {
+		return err
+	}
+	// Only journals have a date, so a date range leaves no pages to search.
+	if opts.Pages && (!opts.From.IsZero() || !opts.To.IsZero()) {
+		return fmt.Errorf("-pages cannot be combined with -from or -to, which only select journals")
+	}
+
+	re, err := search.Compile(fs.Arg(0), *ignoreCase, *word)
+	if err != nil {
+		return fmt.Errorf("error compiling regex pattern: %v", err)
+	}
+
+	cfg, err := loadConfig(*dir)
+	if err != nil {
+		return err
+	}
+
+	files, err := search.Files(cfg, opts)
+	if err != nil {
+		return fmt.Errorf("error listing graph files: %v", err)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	if *openFirst {
+		var path string
+		var line int
+		err := search.Run(ctx, files, re, opts, func(r search.Result) error {
+			for _, l := range r.Lines {
+				if l.Match {
+					path, line = r.Path, l.Number
+					return errFound
+				}
+			}
+			return nil
+		})
+		if err != nil && err != errFound {
+			return fmt.Errorf("error searching graph: %v", err)
+		}
+		if path == "" {
+			fmt.Println("No results found")
+			return nil
+		}
+		return editFile(cfg, *editorType, path, line)
+	}
+
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+
+	separate := false
+	err = search.Run(ctx, files, re, opts, func(r search.Result) error {
+		switch {
+		case *filesOnly:
+			fmt.Fprintln(out, r.Path)
+		case *count:
+			fmt.Fprintf(out, "%s: %d\n", r.Path, r.Matches)
+		default:
+			// Context groups are told apart by a "--" line like grep does.
+			if separate && opts.Before+opts.After > 0 {
+				fmt.Fprintln(out, "--")
+			}
+			printResult(out, r, opts.Before+opts.After > 0)
+			separate = true
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("error searching graph: %v", err)
+	}
+	return nil
+}
+
+// printResult prints the lines of a result as "path#line: text" for matches
+// and "path#line- text" for context, separating lines that do not follow
+// each other with "--" when there is context.
+func printResult(w io.Writer, r search.Result, context bool) {
+	for i, line := range r.Lines {
+		if context && i > 0 && line.Number != r.Lines[i-1].Number+1 {
+			fmt.Fprintln(w, "--")
+		}
+		sep := ":"
+		if !line.Match {
+			sep = "-"
+		}
+		fmt.Fprintf(w, "%s#%d%s %s\n", r.Path, line.Number, sep, line.Text)
+	}
+}
+
+// parseDay parses a yyyy-MM-dd flag value, leaving the time zero when empty.
+func parseDay(flagName, value string) (time.Time, error) {
+	if strings.TrimSpace(value) == "" {
+		return time.Time{}, nil
+	}
+	day, err := time.ParseInLocation("2006-01-02", value, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("error parsing %s date: %v", flagName, err)
+	}
+	return day, nil
+}
@@ -0,0 +1,300 @@
+// Package search finds the lines of the journals and pages of a graph that
+// match a regular expression, reading the files in parallel.
+package search
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/jrswab/lsq/config"
+	"github.com/jrswab/lsq/graph"
+)
+
+// Options select the files to search and the lines to report around matches.
+type Options struct {
+	// Journals and Pages limit the search to one directory. When neither is
+	// set both are searched.
+	Journals bool
+	Pages    bool
+	// From and To limit the search to the journals of these days, when set.
+	// Pages are still searched when Pages is set too.
+	From time.Time
+	To   time.Time
+	// Before and After are the number of context lines around each match.
+	Before int
+	After  int
+	// Workers is the number of files read at once, the number of CPUs when 0.
+	Workers int
+}
+
+// File is a journal or page to search.
+type File struct {
+	Path    string
+	Journal bool
+}
+
+// Line is a line of a result, either a match or context around one.
+type Line struct {
+	Number int
+	Text   string
+	Match  bool
+}
+
+// Result holds the matching lines of a file with their context, in order.
+type Result struct {
+	Path    string
+	Lines   []Line
+	Matches int
+}
+
+// Compile compiles pattern, matching regardless of case or only whole
+// words when asked.
+func Compile(pattern string, ignoreCase, word bool) (*regexp.Regexp, error) {
+	if word {
+		pattern = `\b(?:` + pattern + `)\b`
+	}
+	if ignoreCase {
+		pattern = `(?i)` + pattern
+	}
+	return regexp.Compile(pattern)
+}
+
+// Files lists the journals and then the pages selected by opts, each in file
+// name order. Subdirectories are searched too, except hidden ones and the
+// backups Logseq keeps in logseq/bak and .recycle.
+func Files(cfg *config.Config, opts Options) ([]File, error) {
+	dated := !opts.From.IsZero() || !opts.To.IsZero()
+	journals := opts.Journals || !opts.Pages || dated
+	pages := opts.Pages || !opts.Journals && !dated
+
+	var files []File
+	if journals {
+		layout := config.ConvertDateFormat(cfg.FileFmt)
+		found, err := walk(cfg.JournalsDir, func(path string) bool {
+			if !dated {
+				return true
+			}
+			name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+			date, err := time.ParseInLocation(layout, name, time.Local)
+			if err != nil {
+				return false
+			}
+			return (opts.From.IsZero() || !date.Before(opts.From)) && (opts.To.IsZero() || !date.After(opts.To))
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range found {
+			files = append(files, File{Path: path, Journal: true})
+		}
+	}
+
+	if pages {
+		found, err := walk(cfg.PagesDir, func(string) bool { return true })
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range found {
+			files = append(files, File{Path: path})
+		}
+	}
+	return files, nil
+}
+
+// Kind reports whether path is a journal, and whether it is a journal or
+// page Files lists at all: a page file below the journals or pages
+// directory that is not hidden or inside a directory SkipDir leaves out.
+func Kind(cfg *config.Config, path string) (journal, ok bool) {
+	if strings.HasPrefix(filepath.Base(path), ".") || !graph.IsPageFile(path) {
+		return false, false
+	}
+
+	for _, root := range []struct {
+		dir     string
+		journal bool
+	}{{cfg.JournalsDir, true}, {cfg.PagesDir, false}} {
+		dir := filepath.Clean(root.dir)
+		rel, err := filepath.Rel(dir, path)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		for parent := filepath.Dir(path); parent != dir; parent = filepath.Dir(parent) {
+			if SkipDir(parent) {
+				return false, false
+			}
+		}
+		return root.journal, true
+	}
+	return false, false
+}
+
+// walk returns the page files below dir that keep accepts, in lexical order.
+// A graph without the directory has nothing to search in it.
+func walk(dir string, keep func(path string) bool) ([]string, error) {
+	var paths []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if path == dir && errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path != dir && SkipDir(path) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if strings.HasPrefix(d.Name(), ".") || !graph.IsPageFile(d.Name()) || !keep(path) {
+			return nil
+		}
+		paths = append(paths, path)
+		return nil
+	})
+	return paths, err
+}
+
+// SkipDir reports whether the directory at path holds no journals or pages:
+// hidden directories, .recycle and the logseq/bak backups.
+func SkipDir(path string) bool {
+	name := filepath.Base(path)
+	if strings.HasPrefix(name, ".") {
+		return true
+	}
+	return name == "bak" && filepath.Base(filepath.Dir(path)) == "logseq"
+}
+
+// Run searches the files for re with opts.Workers files read at once and
+// calls emit with the result of every file with a match, in the order of
+// files. It stops at the first error, including one returned by emit.
+func Run(ctx context.Context, files []File, re *regexp.Regexp, opts Options, emit func(Result) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	type outcome struct {
+		result Result
+		err    error
+	}
+
+	// Each file has its own channel so results are emitted in order while
+	// later files are still being read. The window keeps the number of
+	// results waiting to be emitted bounded.
+	outcomes := make([]chan outcome, len(files))
+	for i := range outcomes {
+		outcomes[i] = make(chan outcome, 1)
+	}
+	window := make(chan struct{}, workers*4)
+	jobs := make(chan int)
+
+	go func() {
+		defer close(jobs)
+		for i := range files {
+			select {
+			case window <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			select {
+			case jobs <- i:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for w := 0; w < workers; w++ {
+		go func() {
+			for i := range jobs {
+				result, err := Scan(files[i], re, opts.Before, opts.After)
+				outcomes[i] <- outcome{result, err}
+			}
+		}()
+	}
+
+	for i := range files {
+		var o outcome
+		select {
+		case o = <-outcomes[i]:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		<-window
+
+		if o.err != nil {
+			return o.err
+		}
+		if o.result.Matches == 0 {
+			continue
+		}
+		if err := emit(o.result); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Scan searches one file for re, keeping before and after lines of context
+// around each match. Lines are read whole, however long they are.
+func Scan(f File, re *regexp.Regexp, before, after int) (Result, error) {
+	result := Result{Path: f.Path}
+
+	file, err := os.Open(f.Path)
+	if err != nil {
+		return result, err
+	}
+	defer file.Close()
+
+	var (
+		reader  = bufio.NewReader(file)
+		pending []Line // lines that may be context for the next match
+		trail   int    // lines still to keep after the last match
+		number  int
+	)
+	for {
+		text, err := reader.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return result, err
+		}
+		if text == "" && err != nil {
+			break
+		}
+		number++
+		line := Line{Number: number, Text: strings.TrimRight(text, "\r\n")}
+
+		switch {
+		case re.MatchString(line.Text):
+			line.Match = true
+			result.Matches++
+			result.Lines = append(result.Lines, pending...)
+			result.Lines = append(result.Lines, line)
+			pending, trail = pending[:0], after
+		case trail > 0:
+			result.Lines = append(result.Lines, line)
+			trail--
+		case before > 0:
+			if len(pending) == before {
+				pending = append(pending[:0], pending[1:]...)
+			}
+			pending = append(pending, line)
+		}
+
+		if err != nil {
+			break
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,244 @@
+package search_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jrswab/lsq/config"
+	"github.com/jrswab/lsq/search"
+)
+
+func newGraph(t *testing.T, files map[string]string) *config.Config {
+	t.Helper()
+	dir := t.TempDir()
+	cfg := &config.Config{
+		FileType:    "Markdown",
+		FileFmt:     "yyyy_MM_dd",
+		DirPath:     dir,
+		JournalsDir: filepath.Join(dir, "journals"),
+		PagesDir:    filepath.Join(dir, "pages"),
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return cfg
+}
+
+func day(s string) time.Time {
+	d, _ := time.ParseInLocation("2006-01-02", s, time.Local)
+	return d
+}
+
+func TestFiles(t *testing.T) {
+	cfg := newGraph(t, map[string]string{
+		"journals/2024_01_01.md":           "",
+		"journals/2024_02_01.md":           "",
+		"journals/notes.md":                "",
+		"pages/b.md":                       "",
+		"pages/a.org":                      "",
+		"pages/image.png":                  "",
+		"pages/.hidden.md":                 "",
+		"pages/sub/c.md":                   "",
+		"pages/.git/d.md":                  "",
+		"pages/.recycle/e.md":              "",
+		"pages/logseq/bak/pages/f.md":      "",
+		"pages/bak/g.md":                   "",
+		"logseq/bak/journals/2024_01_1.md": "",
+	})
+
+	testCases := map[string]struct {
+		opts search.Options
+		want []string
+	}{
+		"everything": {
+			opts: search.Options{},
+			want: []string{"journals/2024_01_01.md", "journals/2024_02_01.md", "journals/notes.md", "pages/a.org", "pages/b.md", "pages/bak/g.md", "pages/sub/c.md"},
+		},
+		"journals only": {
+			opts: search.Options{Journals: true},
+			want: []string{"journals/2024_01_01.md", "journals/2024_02_01.md", "journals/notes.md"},
+		},
+		"pages only": {
+			opts: search.Options{Pages: true},
+			want: []string{"pages/a.org", "pages/b.md", "pages/bak/g.md", "pages/sub/c.md"},
+		},
+		"date range": {
+			opts: search.Options{From: day("2024-01-15"), To: day("2024-02-01")},
+			want: []string{"journals/2024_02_01.md"},
+		},
+		"open ended range": {
+			opts: search.Options{To: day("2024-01-31")},
+			want: []string{"journals/2024_01_01.md"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			files, err := search.Files(cfg, tc.opts)
+			if err != nil {
+				t.Fatalf("Files() error = %v", err)
+			}
+			var got []string
+			for _, f := range files {
+				rel, _ := filepath.Rel(cfg.DirPath, f.Path)
+				got = append(got, filepath.ToSlash(rel))
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("Files() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestScan(t *testing.T) {
+	content := "one\ntwo match\nthree\nfour\nfive\nsix match\nseven\r\n" + strings.Repeat("x", 200000) + " match\n"
+
+	testCases := map[string]struct {
+		pattern    string
+		ignoreCase bool
+		word       bool
+		before     int
+		after      int
+		want       []string
+	}{
+		"matches only": {
+			pattern: "match",
+			want:    []string{"2:", "6:", "8:"},
+		},
+		"context": {
+			pattern: "match",
+			before:  1,
+			after:   1,
+			want:    []string{"1-", "2:", "3-", "5-", "6:", "7-", "8:"},
+		},
+		"overlapping context": {
+			pattern: "match",
+			before:  3,
+			want:    []string{"1-", "2:", "3-", "4-", "5-", "6:", "7-", "8:"},
+		},
+		"case": {
+			pattern: "MATCH",
+			want:    nil,
+		},
+		"ignore case": {
+			pattern:    "MATCH",
+			ignoreCase: true,
+			want:       []string{"2:", "6:", "8:"},
+		},
+		"whole word": {
+			pattern: "mat|two",
+			word:    true,
+			want:    []string{"2:"},
+		},
+		"carriage return": {
+			pattern: "seven$",
+			want:    []string{"7:"},
+		},
+	}
+
+	path := filepath.Join(t.TempDir(), "page.md")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			re, err := search.Compile(tc.pattern, tc.ignoreCase, tc.word)
+			if err != nil {
+				t.Fatal(err)
+			}
+			result, err := search.Scan(search.File{Path: path}, re, tc.before, tc.after)
+			if err != nil {
+				t.Fatalf("Scan() error = %v", err)
+			}
+
+			var got []string
+			matches := 0
+			for _, line := range result.Lines {
+				sep := "-"
+				if line.Match {
+					sep = ":"
+					matches++
+				}
+				got = append(got, fmt.Sprintf("%d%s", line.Number, sep))
+			}
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("Scan() lines = %v, want %v", got, tc.want)
+			}
+			if result.Matches != matches {
+				t.Errorf("Scan() Matches = %d, want %d", result.Matches, matches)
+			}
+		})
+	}
+}
+
+func TestRun(t *testing.T) {
+	files := make(map[string]string)
+	for i := 0; i < 200; i++ {
+		content := "- nothing"
+		if i%3 == 0 {
+			content = "- needle"
+		}
+		files[fmt.Sprintf("pages/page %03d.md", i)] = content
+	}
+	cfg := newGraph(t, files)
+
+	list, err := search.Files(cfg, search.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	re, err := search.Compile("needle", false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := map[string]struct {
+		workers int
+		stopAt  int
+		want    int
+	}{
+		"one worker":    {workers: 1, want: 67},
+		"many workers":  {workers: 16, want: 67},
+		"default":       {workers: 0, want: 67},
+		"stop on error": {workers: 8, stopAt: 5, want: 5},
+	}
+
+	stop := errors.New("stop")
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			var got []string
+			err := search.Run(context.Background(), list, re, search.Options{Workers: tc.workers}, func(r search.Result) error {
+				got = append(got, r.Path)
+				if len(got) == tc.stopAt {
+					return stop
+				}
+				return nil
+			})
+			if tc.stopAt > 0 && !errors.Is(err, stop) {
+				t.Errorf("Run() error = %v, want %v", err, stop)
+			}
+			if tc.stopAt == 0 && err != nil {
+				t.Errorf("Run() error = %v", err)
+			}
+			if len(got) != tc.want {
+				t.Errorf("Run() emitted %d results, want %d", len(got), tc.want)
+			}
+			if !slices.IsSorted(got) {
+				t.Errorf("Run() emitted results out of order: %v", got)
+			}
+		})
+	}
+}
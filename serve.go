@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/jrswab/lsq/server"
+)
+
+func runServe(args []string) error {
+	fs, dir := newFlagSet("serve")
+	addr := fs.String("addr", "127.0.0.1:8765", "Loopback address and port to listen on.")
+	socket := fs.String("socket", "", "Listen on this Unix socket instead of a TCP port.")
+	fs.Parse(args)
+
+	cfg, err := loadConfig(*dir)
+	if err != nil {
+		return err
+	}
+
+	srv := server.New(cfg)
+	srv.Append = func(path, text string, indent int) error {
+		return appendText(cfg, path, text, indent)
+	}
+	srv.Changed = func(op string) {
+		operation = op
+		afterChanges()
+	}
+
+	var ln net.Listener
+	if *socket != "" {
+		srv.AnyHost = true
+		if ln, err = listenSocket(*socket); err != nil {
+			return err
+		}
+	} else {
+		host, _, err := net.SplitHostPort(*addr)
+		if err != nil {
+			return fmt.Errorf("invalid -addr: %v", err)
+		}
+		if !server.IsLoopback(host) {
+			return fmt.Errorf("-addr must be a loopback address such as 127.0.0.1, not %s", host)
+		}
+		if ln, err = net.Listen("tcp", *addr); err != nil {
+			return err
+		}
+	}
+
+	httpServer := &http.Server{Handler: srv.Handler()}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		httpServer.Shutdown(context.Background())
+	}()
+
+	if *socket != "" {
+		fmt.Printf("Serving %s on %s\n", cfg.DirPath, *socket)
+		defer os.Remove(*socket)
+	} else {
+		fmt.Printf("Serving %s at http://%s\n", cfg.DirPath, ln.Addr())
+	}
+
+	if err := httpServer.Serve(ln); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// listenSocket listens on the Unix socket at path, replacing a socket left
+// behind by a server that did not shut down. Only the user can connect.
+func listenSocket(path string) (net.Listener, error) {
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		if conn, err := net.Dial("unix", path); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("another server is listening on %s", path)
+		}
+		os.Remove(path)
+	}
+
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		ln.Close()
+		return nil, err
+	}
+	return ln, nil
+}
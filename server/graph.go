@@ -0,0 +1,424 @@
+// Package server serves a local JSON API over the graph for browser
+// extensions, launchers and scripts. The operations of the API are also
+// used by the MCP server of lsq.
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jrswab/lsq/config"
+	"github.com/jrswab/lsq/graph"
+	"github.com/jrswab/lsq/outline"
+	"github.com/jrswab/lsq/store"
+	"github.com/jrswab/lsq/system"
+	"github.com/jrswab/lsq/todo"
+	"github.com/jrswab/lsq/trie"
+)
+
+var (
+	// ErrNotFound is returned for a journal, page or block that does not exist.
+	ErrNotFound = errors.New("not found")
+	// ErrInvalid is returned for a request with missing or malformed values.
+	ErrInvalid = errors.New("invalid request")
+)
+
+// Server answers the API requests for the graph of cfg.
+type Server struct {
+	cfg *config.Config
+
+	// Append appends text to a journal or page. It defaults to
+	// system.AppendToFile; lsq sets it to run the pre-write hooks.
+	Append func(path, text string, indent int) error
+	// Changed is called after a request changed the graph, with the
+	// operation that changed it, before the next request can write.
+	Changed func(operation string)
+	// Now returns the time used for today's journal and to cycle tasks.
+	Now func() time.Time
+	// AnyHost accepts HTTP requests for any host name. It is set when
+	// serving on a Unix socket; over TCP only loopback names are accepted
+	// so web pages cannot reach the API through DNS rebinding.
+	AnyHost bool
+
+	// mu lets one request write at a time, so each one is recorded as its
+	// own operation for "lsq undo".
+	mu sync.Mutex
+
+	// pages keeps the trie of page names, reading only the pages whose
+	// size or modification time changed since the last search.
+	pages *trie.Cache
+}
+
+// New returns a server for the graph of cfg.
+func New(cfg *config.Config) *Server {
+	return &Server{cfg: cfg, Append: system.AppendToFile, Now: time.Now, pages: trie.NewCache(cfg.PagesDir, "")}
+}
+
+// File is a journal or page.
+type File struct {
+	Name    string `json:"name"`
+	Path    string `json:"path"`
+	Journal bool   `json:"journal,omitempty"`
+	Content string `json:"content,omitempty"`
+}
+
+// Results are the pages found by name and the lines found by regex.
+type Results struct {
+	Pages []File `json:"pages,omitempty"`
+	Hits  []Hit  `json:"hits,omitempty"`
+}
+
+// Hit is a line matching a regex search.
+type Hit struct {
+	Path string `json:"path"`
+	Line int    `json:"line"`
+	Text string `json:"text"`
+}
+
+// Task is a block starting with a task marker such as TODO.
+type Task struct {
+	Path    string `json:"path"`
+	Page    string `json:"page"`
+	Journal bool   `json:"journal,omitempty"`
+	Line    int    `json:"line"`
+	State   string `json:"state"`
+	Content string `json:"content"`
+}
+
+// Journal reads the journal of date, which is yyyy-MM-dd or "today".
+func (s *Server) Journal(date string) (File, error) {
+	path, err := s.journalPath(date)
+	if err != nil {
+		return File{}, err
+	}
+	return s.read(path, true)
+}
+
+// AppendJournal appends text as a block to the journal of date, creating
+// the journal when needed. command names the change for "lsq history".
+func (s *Server) AppendJournal(date, text string, indent int, command string) (File, error) {
+	path, err := s.journalPath(date)
+	if err != nil {
+		return File{}, err
+	}
+	return s.appendTo(path, true, text, indent, command)
+}
+
+// Page reads the page called name. Page names are not case sensitive.
+func (s *Server) Page(name string) (File, error) {
+	path, exists, err := s.pagePath(name)
+	switch {
+	case err != nil:
+		return File{}, err
+	case !exists:
+		return File{}, fmt.Errorf("page %q %w", name, ErrNotFound)
+	}
+	return s.read(path, false)
+}
+
+// Pages lists the pages of the graph without their content.
+func (s *Server) Pages() ([]File, error) {
+	pages, err := graph.Pages(s.cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]File, len(pages))
+	for i, p := range pages {
+		files[i] = File{Name: p.Name, Path: p.Path}
+	}
+	return files, nil
+}
+
+// CreatePage creates the page called name with content. An existing page
+// is left alone and reported with fs.ErrExist.
+func (s *Server) CreatePage(name, content, command string) (File, error) {
+	path, exists, err := s.pagePath(name)
+	switch {
+	case err != nil:
+		return File{}, err
+	case exists:
+		return File{}, fmt.Errorf("page %q: %w", name, fs.ErrExist)
+	}
+
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	if err := s.write(command, "create", func() error { return store.Create(path, []byte(content)) }); err != nil {
+		return File{}, err
+	}
+	return s.read(path, false)
+}
+
+// AppendPage appends text as a block to the page called name, creating the
+// page when needed.
+func (s *Server) AppendPage(name, text string, indent int, command string) (File, error) {
+	path, _, err := s.pagePath(name)
+	if err != nil {
+		return File{}, err
+	}
+	return s.appendTo(path, false, text, indent, command)
+}
+
+func (s *Server) appendTo(path string, journal bool, text string, indent int, command string) (File, error) {
+	if strings.TrimSpace(text) == "" {
+		return File{}, fmt.Errorf("%w: text is required", ErrInvalid)
+	}
+	if indent < 0 {
+		return File{}, fmt.Errorf("%w: indent must be zero or greater", ErrInvalid)
+	}
+
+	if err := s.write(command, "append", func() error { return s.Append(path, text, indent) }); err != nil {
+		return File{}, err
+	}
+	return s.read(path, journal)
+}
+
+// Search finds the pages and aliases starting with query like "lsq -f"
+// and the lines matching pattern like "lsq -r". Either may be blank.
+func (s *Server) Search(query, pattern string) (Results, error) {
+	var results Results
+	if query == "" && pattern == "" {
+		return results, fmt.Errorf("%w: a query or regex is required", ErrInvalid)
+	}
+
+	if query != "" {
+		t, err := s.pages.Trie()
+		if err != nil {
+			return results, err
+		}
+		for _, name := range t.Search(query) {
+			results.Pages = append(results.Pages, File{Name: graph.PageName(name), Path: filepath.Join(s.cfg.PagesDir, name)})
+		}
+	}
+
+	if pattern != "" {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return results, fmt.Errorf("%w: error compiling regex pattern: %v", ErrInvalid, err)
+		}
+		if results.Hits, err = s.grep(re); err != nil {
+			return results, err
+		}
+	}
+	return results, nil
+}
+
+// grep returns the lines of the journals and pages matching re.
+func (s *Server) grep(re *regexp.Regexp) ([]Hit, error) {
+	files, err := graph.All(s.cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	hits := []Hit{}
+	for _, f := range files {
+		data, err := os.ReadFile(f.Path)
+		if err != nil {
+			return nil, err
+		}
+		for n, line := range strings.Split(string(data), "\n") {
+			if re.MatchString(line) {
+				hits = append(hits, Hit{Path: f.Path, Line: n + 1, Text: line})
+			}
+		}
+	}
+	return hits, nil
+}
+
+// Tasks lists the tasks of the graph in the given states, or in the open
+// states when there are none. The state "all" lists every task.
+func (s *Server) Tasks(states []string) ([]Task, error) {
+	switch {
+	case len(states) == 0:
+		states = todo.OpenStates
+	case len(states) == 1 && strings.EqualFold(states[0], "all"):
+		states = todo.Markers
+	}
+
+	files, err := graph.All(s.cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := []Task{}
+	for _, f := range files {
+		data, err := os.ReadFile(f.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		outline.ParseFile(f.Path, string(data)).Walk(func(b *outline.Block) bool {
+			state := todo.Marker(b.Content())
+			for _, want := range states {
+				if state != "" && strings.EqualFold(state, strings.TrimSpace(want)) {
+					tasks = append(tasks, s.task(f.Path, b))
+					break
+				}
+			}
+			return true
+		})
+	}
+	return tasks, nil
+}
+
+// ToggleTask cycles the TODO state of the block at the one-based line of
+// the journal or page at path, which may be relative to the graph.
+func (s *Server) ToggleTask(path string, line int, command string) (Task, error) {
+	path, err := s.graphPath(path)
+	if err != nil {
+		return Task{}, err
+	}
+	if line < 1 {
+		return Task{}, fmt.Errorf("%w: line must be a positive line number", ErrInvalid)
+	}
+
+	if _, err := s.blockAt(path, line); err != nil {
+		return Task{}, err
+	}
+	if err := s.write(command, "task", func() error { return system.CycleTask(path, line, s.Now()) }); err != nil {
+		return Task{}, err
+	}
+
+	b, err := s.blockAt(path, line)
+	if err != nil {
+		return Task{}, err
+	}
+	return s.task(path, b), nil
+}
+
+func (s *Server) task(path string, b *outline.Block) Task {
+	journal := filepath.Dir(path) == filepath.Clean(s.cfg.JournalsDir)
+	page := graph.PageName(filepath.Base(path))
+	if journal {
+		page = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return Task{
+		Path: path, Page: page, Journal: journal,
+		Line: b.Line + 1, State: todo.Marker(b.Content()), Content: b.Content(),
+	}
+}
+
+// blockAt returns the block at the one-based line of the file at path.
+func (s *Server) blockAt(path string, line int) (*outline.Block, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%s %w", filepath.Base(path), ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	b := outline.ParseFile(path, string(data)).BlockAt(line - 1)
+	if b == nil {
+		return nil, fmt.Errorf("block at line %d %w", line, ErrNotFound)
+	}
+	return b, nil
+}
+
+// journalPath returns the journal of date, which is yyyy-MM-dd or "today".
+func (s *Server) journalPath(date string) (string, error) {
+	day := s.Now()
+	if date != "today" {
+		parsed, err := time.Parse("2006-01-02", date)
+		if err != nil {
+			return "", fmt.Errorf("%w: date must be yyyy-MM-dd or today, not %q", ErrInvalid, date)
+		}
+		day = parsed
+	}
+
+	name := day.Format(config.ConvertDateFormat(s.cfg.FileFmt))
+	return system.CreateFilePath(s.cfg, s.cfg.JournalsDir, name), nil
+}
+
+// pagePath finds the file of the page called name. It returns the path a
+// new page would get when there is none.
+func (s *Server) pagePath(name string) (string, bool, error) {
+	if strings.TrimSpace(name) == "" {
+		return "", false, fmt.Errorf("%w: page name is required", ErrInvalid)
+	}
+
+	base := graph.FileName(name)
+	for _, ext := range []string{".md", ".org"} {
+		path := filepath.Join(s.cfg.PagesDir, base+ext)
+		if _, err := os.Stat(path); err == nil {
+			return path, true, nil
+		}
+	}
+
+	// Logseq page names are not case sensitive.
+	pages, err := graph.Pages(s.cfg)
+	if err != nil {
+		return "", false, err
+	}
+	for _, p := range pages {
+		if strings.EqualFold(p.Name, name) {
+			return p.Path, true, nil
+		}
+	}
+
+	ext := ".md"
+	if strings.EqualFold(s.cfg.FileType, "Org") {
+		ext = ".org"
+	}
+	return filepath.Join(s.cfg.PagesDir, base+ext), false, nil
+}
+
+// graphPath resolves a path relative to the graph and makes sure it is a
+// journal or page of the graph.
+func (s *Server) graphPath(path string) (string, error) {
+	if path == "" {
+		return "", fmt.Errorf("%w: path is required", ErrInvalid)
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(s.cfg.DirPath, path)
+	}
+	path = filepath.Clean(path)
+
+	dir := filepath.Dir(path)
+	if dir != filepath.Clean(s.cfg.JournalsDir) && dir != filepath.Clean(s.cfg.PagesDir) || !graph.IsPageFile(path) {
+		return "", fmt.Errorf("%w: %s is not a journal or page of the graph", ErrInvalid, path)
+	}
+	return path, nil
+}
+
+// write runs fn as one operation for "lsq undo" and tells Changed about it.
+func (s *Server) write(command, operation string, fn func() error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	store.Record(s.cfg.DirPath, command)
+	// Stop recording so requests that only read are not part of it.
+	defer store.Record("", "")
+
+	err := fn()
+	// Changes made before a failure are reported too.
+	if s.Changed != nil {
+		s.Changed(operation)
+	}
+	return err
+}
+
+// read returns the journal or page at path.
+func (s *Server) read(path string, journal bool) (File, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return File{}, fmt.Errorf("%s %w", filepath.Base(path), ErrNotFound)
+	}
+	if err != nil {
+		return File{}, err
+	}
+
+	name := graph.PageName(filepath.Base(path))
+	if journal {
+		name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	return File{Name: name, Path: path, Journal: journal, Content: string(data)}, nil
+}
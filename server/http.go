@@ -0,0 +1,182 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"mime"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/jrswab/lsq/hooks"
+	"github.com/jrswab/lsq/store"
+)
+
+// Handler returns the routes of the HTTP API.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /journals/{date}", s.getJournal)
+	mux.HandleFunc("POST /journals/{date}", s.appendJournal)
+	mux.HandleFunc("GET /pages/{name...}", s.getPage)
+	mux.HandleFunc("POST /pages/{name...}", s.createPage)
+	mux.HandleFunc("GET /search", s.search)
+	mux.HandleFunc("GET /tasks", s.tasks)
+	mux.HandleFunc("POST /tasks/toggle", s.toggleTask)
+	return s.guard(mux)
+}
+
+// guard rejects requests for other hosts and writes that are not JSON.
+// Browsers cannot send JSON to another origin without a CORS preflight,
+// which the API never allows.
+func (s *Server) guard(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.AnyHost && !IsLoopback(r.Host) {
+			writeError(w, http.StatusForbidden, fmt.Errorf("host %q is not a loopback address", r.Host))
+			return
+		}
+
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+			if mediaType != "application/json" {
+				writeError(w, http.StatusUnsupportedMediaType, errors.New("request body must be application/json"))
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// IsLoopback reports whether the host, with or without a port, names the
+// local machine.
+func IsLoopback(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.Trim(host, "[]")
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// command names a change made through the HTTP API for "lsq history".
+func command(r *http.Request) string {
+	return "lsq serve: " + r.Method + " " + r.URL.Path
+}
+
+func (s *Server) getJournal(w http.ResponseWriter, r *http.Request) {
+	f, err := s.Journal(r.PathValue("date"))
+	respond(w, http.StatusOK, f, err)
+}
+
+func (s *Server) appendJournal(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Text   string `json:"text"`
+		Indent int    `json:"indent"`
+	}
+	if err := readJSON(w, r, &body); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	f, err := s.AppendJournal(r.PathValue("date"), body.Text, body.Indent, command(r))
+	respond(w, http.StatusOK, f, err)
+}
+
+func (s *Server) getPage(w http.ResponseWriter, r *http.Request) {
+	f, err := s.Page(r.PathValue("name"))
+	respond(w, http.StatusOK, f, err)
+}
+
+func (s *Server) createPage(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Content string `json:"content"`
+	}
+	if err := readJSON(w, r, &body); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	f, err := s.CreatePage(r.PathValue("name"), body.Content, command(r))
+	if err == nil {
+		w.Header().Set("Location", "/pages/"+r.PathValue("name"))
+	}
+	respond(w, http.StatusCreated, f, err)
+}
+
+func (s *Server) search(w http.ResponseWriter, r *http.Request) {
+	results, err := s.Search(r.URL.Query().Get("q"), r.URL.Query().Get("regex"))
+	respond(w, http.StatusOK, results, err)
+}
+
+// tasks lists the tasks in the states of the "state" query, a comma
+// separated list.
+func (s *Server) tasks(w http.ResponseWriter, r *http.Request) {
+	var states []string
+	if q := r.URL.Query().Get("state"); q != "" {
+		states = strings.Split(q, ",")
+	}
+
+	tasks, err := s.Tasks(states)
+	respond(w, http.StatusOK, tasks, err)
+}
+
+func (s *Server) toggleTask(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Path string `json:"path"`
+		Line int    `json:"line"`
+	}
+	if err := readJSON(w, r, &body); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	task, err := s.ToggleTask(body.Path, body.Line, command(r))
+	respond(w, http.StatusOK, task, err)
+}
+
+// respond writes v with status, or the error with the status it calls for.
+func respond(w http.ResponseWriter, status int, v any, err error) {
+	if err != nil {
+		writeError(w, errorStatus(err), err)
+		return
+	}
+	writeJSON(w, status, v)
+}
+
+// errorStatus returns the HTTP status of an error of the API.
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrInvalid):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, store.ErrChanged), errors.Is(err, fs.ErrExist):
+		return http.StatusConflict
+	case errors.Is(err, hooks.ErrRejected):
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusInternalServerError
+}
+
+func readJSON(w http.ResponseWriter, r *http.Request, v any) error {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("error decoding request body: %v", err)
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
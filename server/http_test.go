@@ -0,0 +1,248 @@
+package server_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jrswab/lsq/config"
+	"github.com/jrswab/lsq/server"
+)
+
+func newServer(t *testing.T) (*server.Server, *config.Config) {
+	t.Helper()
+	dir := t.TempDir()
+	cfg := &config.Config{
+		FileType:    "Markdown",
+		FileFmt:     "yyyy_MM_dd",
+		DirPath:     dir,
+		JournalsDir: filepath.Join(dir, "journals"),
+		PagesDir:    filepath.Join(dir, "pages"),
+	}
+
+	files := map[string]string{
+		"journals/2025_01_02.md": "- TODO call mom\n- DONE laundry\n",
+		"pages/meeting notes.md": "alias:: standup\n\n- agenda\n\t- LATER book room\n",
+		"pages/projects.md":      "- DOING write docs\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	srv := server.New(cfg)
+	srv.Now = func() time.Time { return time.Date(2025, 1, 2, 9, 0, 0, 0, time.Local) }
+	return srv, cfg
+}
+
+func do(t *testing.T, h http.Handler, method, target, body string) (int, string) {
+	t.Helper()
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Host = "127.0.0.1:8765"
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec.Code, rec.Body.String()
+}
+
+func TestJournals(t *testing.T) {
+	srv, cfg := newServer(t)
+	var changed []string
+	srv.Changed = func(op string) { changed = append(changed, op) }
+	h := srv.Handler()
+
+	code, body := do(t, h, "GET", "/journals/2025-01-02", "")
+	if code != http.StatusOK || !strings.Contains(body, `"content":"- TODO call mom\n- DONE laundry\n"`) {
+		t.Errorf("GET journal = %d %s", code, body)
+	}
+
+	if code, body := do(t, h, "GET", "/journals/2025-01-03", ""); code != http.StatusNotFound {
+		t.Errorf("GET missing journal = %d %s", code, body)
+	}
+	if code, body := do(t, h, "GET", "/journals/yesterday", ""); code != http.StatusBadRequest {
+		t.Errorf("GET bad date = %d %s", code, body)
+	}
+
+	code, body = do(t, h, "POST", "/journals/today", `{"text": "new idea", "indent": 1}`)
+	if code != http.StatusOK {
+		t.Fatalf("POST journal = %d %s", code, body)
+	}
+	data, err := os.ReadFile(filepath.Join(cfg.JournalsDir, "2025_01_02.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(data), "- TODO call mom\n- DONE laundry\n\t- new idea\n"; got != want {
+		t.Errorf("journal = %q, want %q", got, want)
+	}
+
+	if code, _ := do(t, h, "POST", "/journals/2025-01-05", `{"text": "first"}`); code != http.StatusOK {
+		t.Errorf("POST new journal = %d", code)
+	}
+	if _, err := os.Stat(filepath.Join(cfg.JournalsDir, "2025_01_05.md")); err != nil {
+		t.Errorf("new journal not created: %v", err)
+	}
+
+	if !reflect.DeepEqual(changed, []string{"append", "append"}) {
+		t.Errorf("Changed called with %q", changed)
+	}
+}
+
+func TestPages(t *testing.T) {
+	srv, cfg := newServer(t)
+	h := srv.Handler()
+
+	code, body := do(t, h, "GET", "/pages/Meeting%20Notes", "")
+	if code != http.StatusOK || !strings.Contains(body, `"name":"meeting notes"`) {
+		t.Errorf("GET page = %d %s", code, body)
+	}
+	if code, _ := do(t, h, "GET", "/pages/nothing", ""); code != http.StatusNotFound {
+		t.Errorf("GET missing page = %d", code)
+	}
+
+	code, body = do(t, h, "POST", "/pages/work/ideas", `{"content": "- first"}`)
+	if code != http.StatusCreated {
+		t.Fatalf("POST page = %d %s", code, body)
+	}
+	data, err := os.ReadFile(filepath.Join(cfg.PagesDir, "work___ideas.md"))
+	if err != nil || string(data) != "- first\n" {
+		t.Errorf("created page = %q, %v", data, err)
+	}
+
+	if code, _ := do(t, h, "POST", "/pages/projects", `{"content": "- again"}`); code != http.StatusConflict {
+		t.Errorf("POST existing page = %d, want %d", code, http.StatusConflict)
+	}
+
+	// The new page is found without restarting the server.
+	code, body = do(t, h, "GET", "/search?q=work", "")
+	if code != http.StatusOK || !strings.Contains(body, `"name":"work/ideas"`) {
+		t.Errorf("search new page = %d %s", code, body)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	srv, cfg := newServer(t)
+	h := srv.Handler()
+
+	var result struct {
+		Pages []server.File
+		Hits  []server.Hit
+	}
+	code, body := do(t, h, "GET", "/search?q=stand&regex=TODO|DOING", "")
+	if code != http.StatusOK {
+		t.Fatalf("search = %d %s", code, body)
+	}
+	if err := json.Unmarshal([]byte(body), &result); err != nil {
+		t.Fatal(err)
+	}
+
+	wantPages := []server.File{{Name: "meeting notes", Path: filepath.Join(cfg.PagesDir, "meeting notes.md")}}
+	if !reflect.DeepEqual(result.Pages, wantPages) {
+		t.Errorf("pages = %+v, want %+v", result.Pages, wantPages)
+	}
+	wantHits := []server.Hit{
+		{Path: filepath.Join(cfg.JournalsDir, "2025_01_02.md"), Line: 1, Text: "- TODO call mom"},
+		{Path: filepath.Join(cfg.PagesDir, "projects.md"), Line: 1, Text: "- DOING write docs"},
+	}
+	if !reflect.DeepEqual(result.Hits, wantHits) {
+		t.Errorf("hits = %+v, want %+v", result.Hits, wantHits)
+	}
+
+	if code, _ := do(t, h, "GET", "/search?regex=(", ""); code != http.StatusBadRequest {
+		t.Errorf("bad regex = %d", code)
+	}
+
+	// A page edited in place, which leaves the directory as it was, is
+	// searched with its new aliases.
+	if err := os.WriteFile(filepath.Join(cfg.PagesDir, "meeting notes.md"), []byte("alias:: daily sync\n\n- agenda\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	code, body = do(t, h, "GET", "/search?q=daily", "")
+	if code != http.StatusOK || !strings.Contains(body, `"name":"meeting notes"`) {
+		t.Errorf("search edited alias = %d %s", code, body)
+	}
+}
+
+func TestTasks(t *testing.T) {
+	srv, cfg := newServer(t)
+	h := srv.Handler()
+
+	tests := map[string]struct {
+		query string
+		want  []string
+	}{
+		"open":  {query: "", want: []string{"TODO call mom", "LATER book room", "DOING write docs"}},
+		"state": {query: "?state=done", want: []string{"DONE laundry"}},
+		"all":   {query: "?state=all", want: []string{"TODO call mom", "DONE laundry", "LATER book room", "DOING write docs"}},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			code, body := do(t, h, "GET", "/tasks"+tt.query, "")
+			if code != http.StatusOK {
+				t.Fatalf("tasks = %d %s", code, body)
+			}
+			var tasks []server.Task
+			if err := json.Unmarshal([]byte(body), &tasks); err != nil {
+				t.Fatal(err)
+			}
+			got := []string{}
+			for _, task := range tasks {
+				got = append(got, task.Content)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("tasks = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	code, body := do(t, h, "POST", "/tasks/toggle", `{"path": "pages/projects.md", "line": 1}`)
+	if code != http.StatusOK || !strings.Contains(body, `"state":"DONE"`) {
+		t.Errorf("toggle = %d %s", code, body)
+	}
+	data, _ := os.ReadFile(filepath.Join(cfg.PagesDir, "projects.md"))
+	if !strings.HasPrefix(string(data), "- DONE write docs\n") {
+		t.Errorf("page after toggle = %q", data)
+	}
+
+	if code, _ := do(t, h, "POST", "/tasks/toggle", `{"path": "pages/projects.md", "line": 9}`); code != http.StatusNotFound {
+		t.Errorf("toggle missing line = %d", code)
+	}
+	if code, _ := do(t, h, "POST", "/tasks/toggle", `{"path": "../secret.md", "line": 1}`); code != http.StatusBadRequest {
+		t.Errorf("toggle outside graph = %d", code)
+	}
+}
+
+func TestGuard(t *testing.T) {
+	srv, _ := newServer(t)
+	h := srv.Handler()
+
+	req := httptest.NewRequest("GET", "/journals/today", nil)
+	req.Host = "evil.example.com"
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("foreign host = %d, want %d", rec.Code, http.StatusForbidden)
+	}
+
+	req = httptest.NewRequest("POST", "/journals/today", strings.NewReader(`{"text": "x"}`))
+	req.Host = "localhost:8765"
+	req.Header.Set("Content-Type", "text/plain")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnsupportedMediaType {
+		t.Errorf("text/plain write = %d, want %d", rec.Code, http.StatusUnsupportedMediaType)
+	}
+}
@@ -0,0 +1,480 @@
+package store
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// BackupDir is where the backups and the operation log are kept, relative
+// to the graph. Logseq keeps its own backups next to it in logseq/bak.
+var BackupDir = filepath.Join("logseq", "bak", "lsq")
+
+// keep is the number of operations whose backups are kept.
+const keep = 100
+
+const logName = "history.jsonl"
+
+// Operation is a single run of lsq and the files it changed.
+type Operation struct {
+	ID      string
+	Time    time.Time
+	Command string
+	Changes []Change
+}
+
+// Change is a file changed by an operation.
+type Change struct {
+	// Path is relative to the graph.
+	Path string
+	// Backup is the copy of the file taken before the operation, relative
+	// to BackupDir. It is empty when the operation created the file.
+	Backup string
+	// Hash is the SHA-256 of the file after the operation. It is empty
+	// when the operation removed the file.
+	Hash string
+}
+
+// Action describes the change as "created", "modified" or "deleted".
+func (c Change) Action() string {
+	switch {
+	case c.Backup == "":
+		return "created"
+	case c.Hash == "":
+		return "deleted"
+	}
+	return "modified"
+}
+
+// entry is a line of the operation log. Each write of an operation adds one.
+type entry struct {
+	Op      string    `json:"op"`
+	Time    time.Time `json:"time"`
+	Command string    `json:"command"`
+	Path    string    `json:"path"`
+	Backup  string    `json:"backup,omitempty"`
+	Hash    string    `json:"hash,omitempty"`
+}
+
+// recorder backs up the files changed by the running operation.
+type recorder struct {
+	root    string
+	id      string
+	time    time.Time
+	command string
+	// backups maps the files changed so far to their backups.
+	backups map[string]string
+	changes []Change
+}
+
+var (
+	mu      sync.Mutex
+	current *recorder
+)
+
+// Record starts backing up every file of the graph at root before it is
+// changed, logging the changes as one operation run by command so it can
+// be undone. An empty root stops recording.
+func Record(root, command string) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if root == "" {
+		current = nil
+		return
+	}
+
+	now := time.Now()
+	current = &recorder{
+		root:    root,
+		id:      fmt.Sprintf("%s-%d", now.UTC().Format("20060102T150405.000000000"), os.Getpid()),
+		time:    now,
+		command: command,
+		backups: make(map[string]string),
+	}
+}
+
+// Command describes the command line args of an lsq run for the history
+// and commit messages: the subcommand and the names of the flags, without
+// their values or other arguments, which may hold the text of notes.
+func Command(args []string) string {
+	words := []string{"lsq"}
+	for i, arg := range args {
+		name, isFlag := strings.CutPrefix(arg, "-")
+		name = strings.TrimPrefix(name, "-")
+		switch {
+		case isFlag && name != "" && !unicode.IsDigit(rune(name[0])):
+			name, _, _ = strings.Cut(name, "=")
+			words = append(words, "-"+name)
+		case i == 0 && !isFlag:
+			words = append(words, arg)
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// recording returns the running operation and the path relative to its
+// graph, or nil when path is not recorded.
+func recording(path string) (*recorder, string) {
+	mu.Lock()
+	r := current
+	mu.Unlock()
+	if r == nil {
+		return nil, ""
+	}
+
+	rel, err := relPath(r.root, path)
+	if err != nil || rel == "" {
+		return nil, ""
+	}
+	return r, rel
+}
+
+// relPath returns path relative to the graph at root, or nothing for paths
+// outside the graph and within the logseq directory.
+func relPath(root, path string) (string, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return "", err
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	rel, err := filepath.Rel(absRoot, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", err
+	}
+	if rel == "logseq" || strings.HasPrefix(rel, "logseq"+string(filepath.Separator)) {
+		return "", nil
+	}
+	return rel, nil
+}
+
+// snapshot backs up old, the content of path before it is first changed by
+// the running operation. Callers hold the lock of path.
+func snapshot(path string, old []byte, existed bool) error {
+	r, rel := recording(path)
+	if r == nil {
+		return nil
+	}
+
+	mu.Lock()
+	_, done := r.backups[rel]
+	first := len(r.backups) == 0
+	mu.Unlock()
+	if done {
+		return nil
+	}
+
+	if first {
+		if err := prune(r.root); err != nil {
+			return fmt.Errorf("error pruning backups: %w", err)
+		}
+	}
+
+	var backup string
+	if existed {
+		backup = filepath.Join(r.id, rel)
+		name := filepath.Join(r.root, BackupDir, backup)
+		if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+			return fmt.Errorf("error backing up %s: %w", path, err)
+		}
+		if err := os.WriteFile(name, old, 0644); err != nil {
+			return fmt.Errorf("error backing up %s: %w", path, err)
+		}
+	}
+
+	mu.Lock()
+	r.backups[rel] = backup
+	mu.Unlock()
+	return nil
+}
+
+// logChange adds the new state of path to the operation log. data is
+// ignored when the file no longer exists.
+func logChange(path string, data []byte, exists bool) error {
+	r, rel := recording(path)
+	if r == nil {
+		return nil
+	}
+
+	mu.Lock()
+	e := entry{Op: r.id, Time: r.time, Command: r.command, Path: rel, Backup: r.backups[rel]}
+	if exists {
+		e.Hash = hash(data)
+	}
+	r.changes = addChange(r.changes, Change{Path: rel, Backup: e.Backup, Hash: e.Hash})
+	mu.Unlock()
+
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	name := filepath.Join(r.root, BackupDir, logName)
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return err
+	}
+
+	unlock, err := lock(name)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Recorded returns the graph and command of the running operation and the
+// files it changed so far.
+func Recorded() (root, command string, changes []Change) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if current == nil {
+		return "", "", nil
+	}
+	return current.root, current.command, append([]Change(nil), current.changes...)
+}
+
+// History returns the operations recorded for the graph at root, oldest first.
+func History(root string) ([]Operation, error) {
+	entries, err := readLog(root)
+	if err != nil {
+		return nil, err
+	}
+	return operations(entries), nil
+}
+
+// Undo restores the files changed by the last n operations to their state
+// before each operation, newest first, and forgets the operations. An
+// operation whose files changed since is left alone and reported with
+// ErrChanged. The undone operations are returned.
+func Undo(root string, n int) ([]Operation, error) {
+	ops, err := History(root)
+	if err != nil {
+		return nil, err
+	}
+
+	var undone []Operation
+	for i := len(ops) - 1; i >= 0 && len(undone) < n; i-- {
+		op := ops[i]
+		if err := revert(root, op); err != nil {
+			return undone, fmt.Errorf("error undoing %q: %w", op.Command, err)
+		}
+		if err := forget(root, func(e entry) bool { return e.Op == op.ID }); err != nil {
+			return undone, err
+		}
+		undone = append(undone, op)
+	}
+	return undone, nil
+}
+
+// revert restores the files of op, provided none changed since.
+func revert(root string, op Operation) error {
+	for _, c := range op.Changes {
+		if err := unchanged(filepath.Join(root, c.Path), c.Hash); err != nil {
+			return err
+		}
+	}
+
+	for i := len(op.Changes) - 1; i >= 0; i-- {
+		if err := restore(root, op.Changes[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unchanged checks that path still holds the content with the given hash,
+// where an empty hash stands for a missing file.
+func unchanged(path, want string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		if want == "" {
+			return nil
+		}
+		return fmt.Errorf("%s: %w", path, ErrChanged)
+	}
+	if err != nil {
+		return err
+	}
+	if want == "" || hash(data) != want {
+		return fmt.Errorf("%s: %w", path, ErrChanged)
+	}
+	return nil
+}
+
+// restore puts the backup of a change back in place, removing files the
+// operation created.
+func restore(root string, c Change) error {
+	path := filepath.Join(root, c.Path)
+	unlock, err := lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := unchanged(path, c.Hash); err != nil {
+		return err
+	}
+
+	if c.Backup == "" {
+		return os.Remove(path)
+	}
+
+	data, err := os.ReadFile(filepath.Join(root, BackupDir, c.Backup))
+	if err != nil {
+		return fmt.Errorf("error reading backup: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return writeAtomic(path, data)
+}
+
+// prune forgets the oldest operations so that a new one can be recorded
+// without keeping more than keep.
+func prune(root string) error {
+	entries, err := readLog(root)
+	if err != nil {
+		return err
+	}
+
+	ops := operations(entries)
+	if len(ops) < keep {
+		return nil
+	}
+
+	old := make(map[string]bool)
+	for _, op := range ops[:len(ops)-keep+1] {
+		old[op.ID] = true
+	}
+	return forget(root, func(e entry) bool { return old[e.Op] })
+}
+
+// forget removes the entries matching drop from the operation log along
+// with the backups of their operations.
+func forget(root string, drop func(entry) bool) error {
+	name := filepath.Join(root, BackupDir, logName)
+	unlock, err := lock(name)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	entries, err := readLog(root)
+	if err != nil {
+		return err
+	}
+
+	var (
+		buf     bytes.Buffer
+		removed = make(map[string]bool)
+	)
+	for _, e := range entries {
+		if drop(e) {
+			removed[e.Op] = true
+			continue
+		}
+		line, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		buf.Write(append(line, '\n'))
+	}
+
+	if err := writeAtomic(name, buf.Bytes()); err != nil {
+		return err
+	}
+	for id := range removed {
+		if err := os.RemoveAll(filepath.Join(root, BackupDir, id)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readLog parses the operation log of the graph at root.
+func readLog(root string) ([]entry, error) {
+	f, err := os.Open(filepath.Join(root, BackupDir, logName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var e entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("error reading operation log: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
+
+// operations groups the log entries by operation.
+func operations(entries []entry) []Operation {
+	var (
+		ops   []Operation
+		index = make(map[string]int)
+	)
+	for _, e := range entries {
+		i, ok := index[e.Op]
+		if !ok {
+			i = len(ops)
+			index[e.Op] = i
+			ops = append(ops, Operation{ID: e.Op, Time: e.Time, Command: e.Command})
+		}
+
+		ops[i].Changes = addChange(ops[i].Changes, Change{Path: e.Path, Backup: e.Backup, Hash: e.Hash})
+	}
+	return ops
+}
+
+// addChange adds c to the changes of an operation. A file changed several
+// times keeps its first backup and its last hash.
+func addChange(changes []Change, c Change) []Change {
+	for i := range changes {
+		if changes[i].Path == c.Path {
+			changes[i].Hash = c.Hash
+			return changes
+		}
+	}
+	return append(changes, c)
+}
+
+func hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
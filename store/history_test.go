@@ -0,0 +1,176 @@
+package store_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jrswab/lsq/store"
+)
+
+func TestUndo(t *testing.T) {
+	root := t.TempDir()
+	page := filepath.Join(root, "pages", "a.md")
+	created := filepath.Join(root, "pages", "b.md")
+	removed := filepath.Join(root, "pages", "c.md")
+	if err := os.MkdirAll(filepath.Dir(page), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for path, content := range map[string]string{page: "- a\n", removed: "- c\n"} {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Cleanup(func() { store.Record("", "") })
+
+	store.Record(root, "lsq first")
+	if err := store.Replace(page, []byte("- a\n"), []byte("- b\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Update(page, func(content []byte) ([]byte, error) {
+		return append(content, "- c\n"...), nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Create(created, []byte("- new\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Remove(removed, []byte("- c\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	store.Record(root, "lsq second")
+	if err := store.Append(page, func(byte) []byte { return []byte("- d\n") }); err != nil {
+		t.Fatal(err)
+	}
+	store.Record("", "")
+
+	ops, err := store.History(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ops) != 2 || ops[0].Command != "lsq first" || len(ops[0].Changes) != 3 || len(ops[1].Changes) != 1 {
+		t.Fatalf("History() = %+v, want two operations with 3 and 1 changes", ops)
+	}
+
+	undone, err := store.Undo(root, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(undone) != 2 || undone[0].Command != "lsq second" {
+		t.Errorf("Undo() = %+v, want the second operation first", undone)
+	}
+
+	tests := map[string]struct {
+		path string
+		want string
+	}{
+		"modified page is restored": {path: page, want: "- a\n"},
+		"removed page is restored":  {path: removed, want: "- c\n"},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := os.ReadFile(tc.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tc.want {
+				t.Errorf("content = %q, want %q", got, tc.want)
+			}
+		})
+	}
+
+	if _, err := os.Stat(created); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("created page still exists: %v", err)
+	}
+
+	ops, err = store.History(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ops) != 0 {
+		t.Errorf("History() after Undo() = %+v, want none", ops)
+	}
+}
+
+func TestUndoChanged(t *testing.T) {
+	root := t.TempDir()
+	page := filepath.Join(root, "a.md")
+	if err := os.WriteFile(page, []byte("- a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Record("", "") })
+
+	store.Record(root, "lsq -a b")
+	if err := store.Append(page, func(byte) []byte { return []byte("- b\n") }); err != nil {
+		t.Fatal(err)
+	}
+	store.Record("", "")
+
+	// Another program edits the page after lsq.
+	if err := os.WriteFile(page, []byte("- a\n- b\n- c\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	undone, err := store.Undo(root, 1)
+	if !errors.Is(err, store.ErrChanged) || len(undone) != 0 {
+		t.Fatalf("Undo() = %v, %v, want ErrChanged", undone, err)
+	}
+
+	got, err := os.ReadFile(page)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "- a\n- b\n- c\n" {
+		t.Errorf("content = %q, want the edit kept", got)
+	}
+
+	ops, err := store.History(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ops) != 1 {
+		t.Errorf("History() = %+v, want the operation kept", ops)
+	}
+}
+
+func TestRecordOutsideGraph(t *testing.T) {
+	root := t.TempDir()
+	outside := filepath.Join(t.TempDir(), "a.md")
+	t.Cleanup(func() { store.Record("", "") })
+
+	store.Record(root, "lsq export")
+	if err := store.Create(outside, []byte("- a\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	ops, err := store.History(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ops) != 0 {
+		t.Errorf("History() = %+v, want files outside the graph ignored", ops)
+	}
+}
+
+func TestCommand(t *testing.T) {
+	tests := map[string]struct {
+		args []string
+		want string
+	}{
+		"append text":     {args: []string{"-a", "call the bank: pin 1234"}, want: "lsq -a"},
+		"flag with value": {args: []string{"-p=secret.md", "-i", "2", "-a", "note"}, want: "lsq -p -i -a"},
+		"subcommand":      {args: []string{"carry", "-days", "3", "-refs"}, want: "lsq carry -days -refs"},
+		"positional text": {args: []string{"undo", "--n", "-1", "extra"}, want: "lsq undo -n"},
+		"nothing":         {args: nil, want: "lsq"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := store.Command(tt.args); got != tt.want {
+				t.Errorf("Command(%q) = %q, want %q", tt.args, got, tt.want)
+			}
+		})
+	}
+}
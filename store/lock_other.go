@@ -0,0 +1,9 @@
+//go:build !unix
+
+package store
+
+// lock is a no-op where flock is not available. Writes are still atomic
+// and checked for changes made on disk.
+func lock(path string) (func(), error) {
+	return func() {}, nil
+}
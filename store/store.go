@@ -0,0 +1,296 @@
+// Package store writes the files of a graph safely. Every change takes an
+// advisory lock so concurrent lsq processes take turns, rewrites go to a
+// temporary file that is renamed over the original so readers never see a
+// half written file, and a rewrite is refused when the file changed on disk
+// since it was read, e.g. because the Logseq app saved it in the meantime.
+package store
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// ErrChanged is returned when a file no longer holds the content it was read with.
+var ErrChanged = errors.New("file changed on disk since it was read")
+
+// retries is how often Update re-reads a file that changed while it was being updated.
+const retries = 3
+
+// Read returns the content of the file, or nothing when it does not exist yet.
+func Read(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return data, err
+}
+
+// Update reads the file, passes its content to fn and replaces the file with
+// the result, leaving it untouched when fn returns the content unchanged.
+// A missing file is passed as empty content. The lock is held
+// throughout; when another program changes the file while fn runs it is read
+// again and fn is called with the new content.
+func Update(path string, fn func(content []byte) ([]byte, error)) error {
+	unlock, err := lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	for i := 0; ; i++ {
+		old, err := Read(path)
+		if err != nil {
+			return err
+		}
+
+		data, err := fn(old)
+		if err != nil || bytes.Equal(data, old) {
+			return err
+		}
+
+		err = replace(path, old, data)
+		if !errors.Is(err, ErrChanged) || i == retries {
+			return err
+		}
+	}
+}
+
+// Replace atomically replaces the file with data provided it still holds old.
+// A missing file matches empty old content. It returns ErrChanged otherwise.
+func Replace(path string, old, data []byte) error {
+	unlock, err := lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	return replace(path, old, data)
+}
+
+// replace is Replace for callers holding the lock.
+func replace(path string, old, data []byte) error {
+	current, err := Read(path)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(current, old) {
+		return fmt.Errorf("%s: %w", path, ErrChanged)
+	}
+
+	if err := snapshot(path, current, current != nil); err != nil {
+		return err
+	}
+	if err := writeAtomic(path, data); err != nil {
+		return err
+	}
+	return logChange(path, data, true)
+}
+
+// Create writes a new file, failing with fs.ErrExist when it already exists.
+func Create(path string, data []byte) error {
+	unlock, err := lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := snapshot(path, nil, false); err != nil {
+		return err
+	}
+
+	tmp, err := writeTemp(path, data, 0644)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+
+	// Unlike a rename, a link never replaces an existing file.
+	err = os.Link(tmp, path)
+	if err == nil {
+		return logChange(path, data, true)
+	}
+
+	exists := errors.Is(err, fs.ErrExist)
+	if !exists {
+		// Some file systems have no hard links; the lock still keeps lsq processes apart.
+		_, statErr := os.Stat(path)
+		exists = statErr == nil
+	}
+	if exists {
+		return &fs.PathError{Op: "create", Path: path, Err: fs.ErrExist}
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+	return logChange(path, data, true)
+}
+
+// CreateEmpty creates an empty file unless it already exists.
+func CreateEmpty(path string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if errors.Is(err, fs.ErrExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	if err := snapshot(path, nil, false); err != nil {
+		return err
+	}
+	return logChange(path, nil, true)
+}
+
+// Remove deletes the file provided it still holds old.
+func Remove(path string, old []byte) error {
+	unlock, err := lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	current, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(current, old) {
+		return fmt.Errorf("%s: %w", path, ErrChanged)
+	}
+
+	if err := snapshot(path, current, true); err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil {
+		return err
+	}
+	return logChange(path, nil, false)
+}
+
+// Append calls fn with the last byte of the file, or zero when the file is
+// empty or missing, and appends what it returns. The file is never rewritten
+// so content added by other programs in the meantime is kept.
+func Append(path string, fn func(last byte) []byte) error {
+	unlock, err := lock(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	_, err = os.Stat(path)
+	existed := err == nil
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("error getting file stats: %w", err)
+	}
+
+	var last byte
+	if stat.Size() > 0 {
+		buf := make([]byte, 1)
+		if _, err := file.ReadAt(buf, stat.Size()-1); err != nil && err != io.EOF {
+			return fmt.Errorf("error reading last byte: %w", err)
+		}
+		last = buf[0]
+	}
+
+	// The whole file is only read when it has to be backed up.
+	var old []byte
+	if r, _ := recording(path); r != nil {
+		if old, err = io.ReadAll(io.NewSectionReader(file, 0, stat.Size())); err != nil {
+			return err
+		}
+		if err := snapshot(path, old, existed); err != nil {
+			return err
+		}
+	}
+
+	// A single write with O_APPEND lands at the end even if the file grew.
+	data := fn(last)
+	if _, err := file.Write(data); err != nil {
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		return err
+	}
+	return logChange(path, append(old, data...), true)
+}
+
+// writeAtomic replaces path with data, keeping the permissions of an existing file.
+func writeAtomic(path string, data []byte) error {
+	mode := fs.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	tmp, err := writeTemp(path, data, mode)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// writeTemp writes data to a hidden temporary file next to path. The file
+// does not end in .md or .org so Logseq ignores it.
+func writeTemp(path string, data []byte, mode fs.FileMode) (string, error) {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return "", err
+	}
+
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if err == nil {
+		err = f.Chmod(mode)
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// lockPath returns the lock file used for path. Lock files live outside the
+// graph so they don't show up in Logseq or in version control, in a
+// directory of the user so others sharing the temporary directory don't
+// get in the way.
+func lockPath(path string) (string, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	dir := filepath.Join(os.TempDir(), "lsq-locks-"+strconv.Itoa(os.Getuid()))
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256([]byte(abs))
+	return filepath.Join(dir, hex.EncodeToString(sum[:12])+".lock"), nil
+}
@@ -0,0 +1,171 @@
+package store_test
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jrswab/lsq/store"
+)
+
+func TestReplace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "page.md")
+	if err := os.WriteFile(path, []byte("- a\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Replace(path, []byte("- a\n"), []byte("- b\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	// The file no longer holds "- a".
+	err := store.Replace(path, []byte("- a\n"), []byte("- c\n"))
+	if !errors.Is(err, store.ErrChanged) {
+		t.Errorf("Replace() error = %v, want ErrChanged", err)
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "- b\n" {
+		t.Errorf("content = %q, want %q", got, "- b\n")
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("temporary files left behind: %v", entries)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "page.md")
+
+	calls := 0
+	err := store.Update(path, func(content []byte) ([]byte, error) {
+		calls++
+		if calls == 1 {
+			// Simulate another program saving the file while we work on it.
+			if err := os.WriteFile(path, []byte("- saved elsewhere\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return append(content, "- ours\n"...), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "- saved elsewhere\n- ours\n"; string(got) != want {
+		t.Errorf("content = %q, want %q", got, want)
+	}
+	if calls != 2 {
+		t.Errorf("fn called %d times, want 2", calls)
+	}
+}
+
+func TestCreate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "page.md")
+
+	if err := store.Create(path, []byte("- new\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Create(path, []byte("- other\n")); !errors.Is(err, fs.ErrExist) {
+		t.Errorf("Create() error = %v, want fs.ErrExist", err)
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "- new\n" {
+		t.Errorf("content = %q, want %q", got, "- new\n")
+	}
+}
+
+func TestRemove(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "page.md")
+	if err := os.WriteFile(path, []byte("- a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Remove(path, []byte("- b\n")); !errors.Is(err, store.ErrChanged) {
+		t.Errorf("Remove() error = %v, want ErrChanged", err)
+	}
+	if err := store.Remove(path, []byte("- a\n")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("file still exists: %v", err)
+	}
+}
+
+func TestSharedTempDir(t *testing.T) {
+	// Another user's lock directory must not stop writes, which is what a
+	// file in its place stands in for.
+	tmp := t.TempDir()
+	t.Setenv("TMPDIR", tmp)
+	if err := os.WriteFile(filepath.Join(tmp, "lsq-locks"), nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "page.md")
+	if err := store.Create(path, []byte("- a\n")); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+}
+
+func TestConcurrentUpdates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "journal.md")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			line := fmt.Sprintf("- line %d\n", i)
+			var err error
+			if i%2 == 0 {
+				err = store.Append(path, func(byte) []byte { return []byte(line) })
+			} else {
+				err = store.Update(path, func(content []byte) ([]byte, error) {
+					return append(content, line...), nil
+				})
+			}
+			if err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 20; i++ {
+		if !strings.Contains(string(got), fmt.Sprintf("- line %d\n", i)) {
+			t.Errorf("line %d lost:\n%s", i, got)
+		}
+	}
+}
@@ -4,29 +4,231 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/jrswab/lsq/config"
+	"github.com/jrswab/lsq/editor"
 )
 
-func LoadEditor(editor, path string) {
+// LineArgs are the arguments that open a file at a line in common editors.
+// "{file}" and "{line}" are replaced in each argument.
+var LineArgs = map[string][]string{
+	"vi":            {"+{line}", "{file}"},
+	"vim":           {"+{line}", "{file}"},
+	"nvim":          {"+{line}", "{file}"},
+	"gvim":          {"+{line}", "{file}"},
+	"mvim":          {"+{line}", "{file}"},
+	"nano":          {"+{line}", "{file}"},
+	"micro":         {"+{line}", "{file}"},
+	"emacs":         {"+{line}", "{file}"},
+	"emacsclient":   {"+{line}", "{file}"},
+	"kak":           {"+{line}", "{file}"},
+	"gedit":         {"+{line}", "{file}"},
+	"code":          {"-g", "{file}:{line}"},
+	"codium":        {"-g", "{file}:{line}"},
+	"code-insiders": {"-g", "{file}:{line}"},
+	"subl":          {"{file}:{line}"},
+	"zed":           {"{file}:{line}"},
+	"hx":            {"{file}:{line}"},
+	"helix":         {"{file}:{line}"},
+	"kate":          {"--line", "{line}", "{file}"},
+}
+
+// waitFlags are the flags that make GUI editors wait until the file is
+// closed, by editor name. The first flag is added when none is given, so lsq
+// can commit the change or clean up after the editor exits.
+var waitFlags = map[string][]string{
+	"code":          {"--wait", "-w"},
+	"code-insiders": {"--wait", "-w"},
+	"codium":        {"--wait", "-w"},
+	"subl":          {"--wait", "-w"},
+	"zed":           {"--wait", "-w"},
+	"atom":          {"--wait", "-w"},
+	"mate":          {"--wait", "-w"},
+	"gedit":         {"--wait"},
+	"kate":          {"--block", "-b"},
+	"gvim":          {"--nofork", "-f"},
+	"mvim":          {"--nofork", "-f"},
+}
+
+// LoadEditor opens path in the editor at the one-based line, or at the top
+// when line is zero. The editor is editorName, else the :editor/command of
+// cfg, else $EDITOR, else Vim.
+func LoadEditor(cfg *config.Config, editorName, path string, line int) error {
+	if editorName == "" && cfg != nil {
+		editorName = cfg.Editor
+	}
+
 	// Get editor from environment
-	if editor == "" {
-		editor = os.Getenv("EDITOR")
+	if editorName == "" {
+		editorName = os.Getenv("EDITOR")
 	}
 
 	// if still blank, use nano
-	if editor == "" {
+	if strings.TrimSpace(editorName) == "" {
 		fmt.Println("$EDITOR is blank, using Vim.")
-		editor = "vim"
+		editorName = "vim"
+	}
+
+	// The built-in outliner needs no external program.
+	if strings.TrimSpace(editorName) == editor.Builtin {
+		if err := editor.Run(path, line); err != nil {
+			return fmt.Errorf("error running outliner: %v", err)
+		}
+		return nil
+	}
+
+	command, err := EditorCommand(cfg, editorName, path, line)
+	if err != nil {
+		return err
 	}
 
 	// Open file in editor
-	cmd := exec.Command(editor, path)
+	cmd := exec.Command(command[0], command[1:]...)
 	cmd.Stdin = os.Stdin
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 
-	err := cmd.Run()
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("error opening editor: %v", err)
+	}
+	return nil
+}
+
+// EditorCommand returns the program and arguments that open path at line.
+// editorName is split into words like a shell would, so it may hold the
+// arguments of the editor, as in "code --wait" or "emacsclient -c".
+func EditorCommand(cfg *config.Config, editorName, path string, line int) ([]string, error) {
+	words, err := SplitWords(editorName)
 	if err != nil {
-		fmt.Printf("Error opening editor: %v\n", err)
-		os.Exit(1)
+		return nil, fmt.Errorf("error parsing editor %q: %v", editorName, err)
+	}
+	if len(words) == 0 {
+		return nil, fmt.Errorf("no editor given")
+	}
+
+	var fileArgs []string
+	switch {
+	case cfg != nil && len(cfg.EditorArgs) > 0:
+		fileArgs = expandArgs(cfg.EditorArgs, path, max(line, 1))
+	case cfg != nil:
+		fileArgs = EditorArgs(words[0], path, line, cfg.EditorLineArgs)
+	default:
+		fileArgs = EditorArgs(words[0], path, line, nil)
+	}
+
+	command := words[:1:1]
+	if flags, ok := waitFlags[filepath.Base(words[0])]; ok && !hasAny(append(words[1:], fileArgs...), flags) {
+		command = append(command, flags[0])
+	}
+	command = append(command, words[1:]...)
+	return append(command, fileArgs...), nil
+}
+
+func hasAny(args, flags []string) bool {
+	for _, arg := range args {
+		for _, flag := range flags {
+			if arg == flag {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// EditorArgs returns the arguments to open path at line in the editor. Unknown
+// editors, and any editor when line is zero, are just given the path.
+func EditorArgs(editorName, path string, line int, overrides map[string][]string) []string {
+	name := filepath.Base(editorName)
+	template, ok := overrides[name]
+	if !ok {
+		template, ok = LineArgs[name]
+	}
+	if !ok || line <= 0 {
+		return []string{path}
+	}
+
+	return expandArgs(template, path, line)
+}
+
+// expandArgs replaces "{file}" and "{line}" in the template, adding the path
+// at the end when the template does not place it.
+func expandArgs(template []string, path string, line int) []string {
+	args := make([]string, 0, len(template)+1)
+	hasFile := false
+	for _, arg := range template {
+		if strings.Contains(arg, "{file}") {
+			hasFile = true
+		}
+		arg = strings.ReplaceAll(arg, "{file}", path)
+		args = append(args, strings.ReplaceAll(arg, "{line}", strconv.Itoa(line)))
+	}
+	if !hasFile {
+		args = append(args, path)
+	}
+	return args
+}
+
+// SplitWords splits s into words the way a POSIX shell does, without
+// expanding variables. Single quotes keep everything literal, double quotes
+// keep spaces and a backslash escapes the next character.
+func SplitWords(s string) ([]string, error) {
+	var (
+		words []string
+		word  strings.Builder
+		// inWord is set once the current word has started, so "" is a word.
+		inWord bool
+		quote  rune
+		escape bool
+	)
+
+	for _, r := range s {
+		switch {
+		case escape:
+			// Inside double quotes a backslash only escapes a few characters.
+			if quote == '"' && !strings.ContainsRune(`"\$`+"`", r) {
+				word.WriteRune('\\')
+			}
+			word.WriteRune(r)
+			escape = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\\':
+			escape, inWord = true, true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inWord = r, true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+
+	switch {
+	case escape:
+		return nil, fmt.Errorf("trailing backslash")
+	case quote != 0:
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if inWord {
+		words = append(words, word.String())
 	}
+	return words, nil
 }
@@ -0,0 +1,160 @@
+package system_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/jrswab/lsq/config"
+	"github.com/jrswab/lsq/system"
+)
+
+func TestEditorArgs(t *testing.T) {
+	tests := map[string]struct {
+		editor    string
+		line      int
+		overrides map[string][]string
+		want      []string
+	}{
+		"vim": {
+			editor: "vim",
+			line:   12,
+			want:   []string{"+12", "page.md"},
+		},
+		"editor path": {
+			editor: "/usr/bin/nano",
+			line:   3,
+			want:   []string{"+3", "page.md"},
+		},
+		"vs code": {
+			editor: "code",
+			line:   7,
+			want:   []string{"-g", "page.md:7"},
+		},
+		"helix": {
+			editor: "hx",
+			line:   7,
+			want:   []string{"page.md:7"},
+		},
+		"no line": {
+			editor: "vim",
+			want:   []string{"page.md"},
+		},
+		"unknown editor": {
+			editor: "ed",
+			line:   4,
+			want:   []string{"page.md"},
+		},
+		"override": {
+			editor:    "vim",
+			line:      4,
+			overrides: map[string][]string{"vim": {"-c", "{line}"}},
+			want:      []string{"-c", "4", "page.md"},
+		},
+		"new editor": {
+			editor:    "ed",
+			line:      4,
+			overrides: map[string][]string{"ed": {"{file}", "--goto={line}"}},
+			want:      []string{"page.md", "--goto=4"},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := system.EditorArgs(tt.editor, "page.md", tt.line, tt.overrides)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("EditorArgs() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEditorCommand(t *testing.T) {
+	tests := map[string]struct {
+		cfg         *config.Config
+		editor      string
+		line        int
+		want        []string
+		expectError bool
+	}{
+		"arguments in editor": {
+			editor: "emacsclient -c",
+			want:   []string{"emacsclient", "-c", "page.md"},
+		},
+		"quoted path": {
+			editor: `"/opt/My Editor/bin/edit" -n`,
+			want:   []string{"/opt/My Editor/bin/edit", "-n", "page.md"},
+		},
+		"wait flag added": {
+			editor: "code",
+			line:   3,
+			want:   []string{"code", "--wait", "-g", "page.md:3"},
+		},
+		"wait flag given": {
+			editor: "code -w",
+			want:   []string{"code", "-w", "page.md"},
+		},
+		"gvim": {
+			editor: "/usr/bin/gvim",
+			line:   2,
+			want:   []string{"/usr/bin/gvim", "--nofork", "+2", "page.md"},
+		},
+		"argument template": {
+			cfg:    &config.Config{EditorArgs: []string{"--file", "{file}", "--line", "{line}"}},
+			editor: "myedit",
+			want:   []string{"myedit", "--file", "page.md", "--line", "1"},
+		},
+		"line arguments from config": {
+			cfg:    &config.Config{EditorLineArgs: map[string][]string{"myedit": {"{file}@{line}"}}},
+			editor: "myedit",
+			line:   9,
+			want:   []string{"myedit", "page.md@9"},
+		},
+		"unterminated quote": {
+			editor:      `"code --wait`,
+			expectError: true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := system.EditorCommand(tt.cfg, tt.editor, "page.md", tt.line)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("EditorCommand() error = %v, expectError %v", err, tt.expectError)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("EditorCommand() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitWords(t *testing.T) {
+	tests := map[string]struct {
+		input       string
+		want        []string
+		expectError bool
+	}{
+		"plain":          {input: "code --wait", want: []string{"code", "--wait"}},
+		"extra spaces":   {input: "  vim \t -u  NONE ", want: []string{"vim", "-u", "NONE"}},
+		"single quotes":  {input: `sh -c 'vim "$1"'`, want: []string{"sh", "-c", `vim "$1"`}},
+		"double quotes":  {input: `"my editor" "a \"b\" \c"`, want: []string{"my editor", `a "b" \c`}},
+		"escaped space":  {input: `my\ editor`, want: []string{"my editor"}},
+		"empty quotes":   {input: `edit ""`, want: []string{"edit", ""}},
+		"joined quotes":  {input: `a"b c"'d'`, want: []string{"ab cd"}},
+		"empty":          {input: "", want: nil},
+		"open quote":     {input: `'vim`, expectError: true},
+		"trailing slash": {input: `vim \`, expectError: true},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := system.SplitWords(tt.input)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("SplitWords() error = %v, expectError %v", err, tt.expectError)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SplitWords() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,16 +1,15 @@
 package system
 
 import (
-	"errors"
 	"fmt"
-	"io"
-	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
 	"time"
 
 	"github.com/jrswab/lsq/config"
+	"github.com/jrswab/lsq/outline"
+	"github.com/jrswab/lsq/store"
 )
 
 func CreateFilePath(cfg *config.Config, journalsDir, date string) string {
@@ -40,13 +39,8 @@ func GetJournal(cfg *config.Config, journalsDir, specDate string) (string, error
 	path := CreateFilePath(cfg, journalsDir, date)
 
 	// Create file if it doesn't exist
-	_, err := os.Stat(path)
-
-	if errors.Is(err, fs.ErrNotExist) {
-		err := os.WriteFile(path, []byte(""), 0644)
-		if err != nil {
-			return path, fmt.Errorf("error creating journal file: %s", err)
-		}
+	if err := store.CreateEmpty(path); err != nil {
+		return path, fmt.Errorf("error creating journal file: %s", err)
 	}
 
 	return path, nil
@@ -68,42 +62,15 @@ func AppendToFile(path, content string, indent int) error {
 		return fmt.Errorf("invalid indent: %d", indent)
 	}
 
-	prefix := strings.Repeat("\t", indent)
-	bc := fmt.Sprintf("%s- %s\n", prefix, content)
-
-	file, err := os.OpenFile(path, os.O_RDWR|os.O_APPEND|os.O_CREATE, 0644)
-	if err != nil {
-		panic(err)
-	}
-	defer file.Close()
-
-	stat, err := file.Stat()
-	if err != nil {
-		return fmt.Errorf("error getting file stats: %w", err)
-	}
-
-	if stat.Size() == 0 { // Can't check for a new line if the file size is 0
-		_, err = file.WriteString(bc)
-		return err
-	}
+	// Org pages use heading stars instead of tab indented bullets.
+	bullet := outline.Bullet(outline.IsOrg(path), indent, content)
+	bc := bullet + "\n"
 
-	_, err = file.Seek(-1, io.SeekEnd) // -1 to read the byte before io.SeekEnd
-	if err != nil {
-		return fmt.Errorf("error seeking to end of file: %w", err)
-	}
-
-	buf := make([]byte, 1) // Only need to store the last byte
-	_, err = file.Read(buf)
-	if err != nil {
-		return fmt.Errorf("error reading last byte: %w", err)
-	}
-
-	// When the last byte is not a new line add it to the bulleted content
-	if buf[0] != '\n' {
-		bc = fmt.Sprintf("\n%s- %s\n", prefix, content)
-	}
-
-	// Write data to the file
-	_, err = file.WriteString(bc)
-	return err
+	return store.Append(path, func(last byte) []byte {
+		// When the last byte is not a new line add it to the bulleted content
+		if last != 0 && last != '\n' {
+			return []byte("\n" + bc)
+		}
+		return []byte(bc)
+	})
 }
@@ -394,3 +394,52 @@ func TestAppendToFile(t *testing.T) {
 		})
 	}
 }
+
+func TestAppendToOrgFile(t *testing.T) {
+	tests := map[string]struct {
+		initialContent string
+		appendContent  string
+		indent         int
+		expectedResult string
+	}{
+		"new empty file": {
+			appendContent:  "new content",
+			expectedResult: "* new content\n",
+		},
+		"append after heading": {
+			initialContent: "#+title: Notes\n* existing\n",
+			appendContent:  "TODO new task",
+			expectedResult: "#+title: Notes\n* existing\n* TODO new task\n",
+		},
+		"indent becomes heading level": {
+			initialContent: "* existing",
+			appendContent:  "child",
+			indent:         2,
+			expectedResult: "* existing\n*** child\n",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			testFile := filepath.Join(t.TempDir(), "journal.org")
+			if tt.initialContent != "" {
+				if err := os.WriteFile(testFile, []byte(tt.initialContent), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := system.AppendToFile(testFile, tt.appendContent, tt.indent); err != nil {
+				t.Fatal(err)
+			}
+
+			content, err := os.ReadFile(testFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(content) != tt.expectedResult {
+				t.Errorf("Expected %q, got %q", tt.expectedResult, string(content))
+			}
+		})
+	}
+}
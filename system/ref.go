@@ -0,0 +1,31 @@
+package system
+
+import (
+	"fmt"
+
+	"github.com/jrswab/lsq/outline"
+	"github.com/jrswab/lsq/store"
+)
+
+// BlockID returns the id of the block found at the one-based line number
+// of the file at path. A new "id::" property is written when the block has none.
+func BlockID(path string, line int) (string, error) {
+	var id string
+	err := store.Update(path, func(data []byte) ([]byte, error) {
+		page := outline.ParseFile(path, string(data))
+
+		block := page.BlockAt(line - 1)
+		if block == nil {
+			return nil, fmt.Errorf("no block found at line %d", line)
+		}
+
+		if existing, ok := block.Property("id"); ok && existing != "" {
+			id = existing
+			return data, nil
+		}
+
+		id = block.EnsureID()
+		return []byte(page.String()), nil
+	})
+	return id, err
+}
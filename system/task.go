@@ -0,0 +1,26 @@
+package system
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jrswab/lsq/outline"
+	"github.com/jrswab/lsq/store"
+	"github.com/jrswab/lsq/todo"
+)
+
+// CycleTask cycles the TODO state of the block found at the
+// one-based line number of the file at path.
+func CycleTask(path string, line int, now time.Time) error {
+	return store.Update(path, func(data []byte) ([]byte, error) {
+		page := outline.ParseFile(path, string(data))
+
+		block := page.BlockAt(line - 1)
+		if block == nil {
+			return nil, fmt.Errorf("no block found at line %d", line)
+		}
+
+		block.Lines = todo.CycleBlock(block.Lines, now)
+		return []byte(page.String()), nil
+	})
+}
@@ -0,0 +1,80 @@
+package system_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/jrswab/lsq/system"
+)
+
+func TestCycleTask(t *testing.T) {
+	now := time.Date(2025, 1, 15, 10, 30, 0, 0, time.Local)
+
+	tests := map[string]struct {
+		file        string
+		content     string
+		line        int
+		expected    string
+		expectError bool
+	}{
+		"cycle top level block": {
+			content:  "- first\n- second\n",
+			line:     2,
+			expected: "- first\n- TODO second\n",
+		},
+		"cycle from continuation line": {
+			content:  "- DOING chore\n  SCHEDULED: <2025-01-14 Tue +1d>\n\t- child\n",
+			line:     2,
+			expected: "- TODO chore\n  SCHEDULED: <2025-01-15 Wed +1d>\n  :LOGBOOK:\n  * State \"DONE\" from \"DOING\" [2025-01-15 Wed 10:30]\n  :END:\n\t- child\n",
+		},
+		"org heading": {
+			file:     "page.org",
+			content:  "* TODO write\n:PROPERTIES:\n:id: 1234\n:END:\nnotes\n** child\n",
+			line:     5,
+			expected: "* DOING write\n:PROPERTIES:\n:id: 1234\n:END:\n:LOGBOOK:\nCLOCK: [2025-01-15 Wed 10:30:00]\n:END:\nnotes\n** child\n",
+		},
+		"line in preamble": {
+			content:     "alias:: test\n- block\n",
+			line:        1,
+			expectError: true,
+		},
+		"line out of range": {
+			content:     "- block\n",
+			line:        5,
+			expectError: true,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if tt.file == "" {
+				tt.file = "page.md"
+			}
+
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			err := system.CycleTask(path, tt.line, now)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("CycleTask() error = %v, expectError %v", err, tt.expectError)
+			}
+
+			if tt.expectError {
+				return
+			}
+
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if string(got) != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, string(got))
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package todo
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jrswab/lsq/outline"
+)
+
+// logLayout is the timestamp format used for LOGBOOK entries.
+const logLayout = "2006-01-02 Mon 15:04"
+
+// CycleBlock takes the lines of a block, the bullet line first, and returns
+// them with the next TODO state. Moving a task to DOING starts a CLOCK entry
+// in its LOGBOOK drawer and moving it on closes the entry again.
+//
+// When a repeating task would move to DONE it is reset to TODO instead, its
+// SCHEDULED and DEADLINE timestamps are advanced by their repeaters and the
+// completion is recorded in the LOGBOOK drawer.
+func CycleBlock(lines []string, now time.Time) []string {
+	if len(lines) == 0 {
+		return lines
+	}
+
+	out := make([]string, len(lines))
+	copy(out, lines)
+
+	from := StateOf(out[0])
+	out[0] = CycleState(out[0])
+	to := StateOf(out[0])
+
+	if from == States[1] && to != States[1] { // Leaving DOING
+		out = StopClock(out, now)
+	}
+
+	switch to {
+	case States[1]: // DOING
+		return StartClock(out, now)
+	case States[2]: // DONE
+		return repeat(out, from, now)
+	default:
+		return out
+	}
+}
+
+// repeat resets a completed repeating task back to TODO.
+// Blocks without a repeater are returned unchanged.
+func repeat(lines []string, from string, now time.Time) []string {
+	repeated := false
+	for i := 1; i < len(lines); i++ {
+		if !isPlanning(lines[i]) {
+			continue
+		}
+
+		ts, ok := ParseTimestamp(lines[i])
+		if !ok || ts.Repeat == "" {
+			continue
+		}
+
+		lines[i] = replaceTimestamp(lines[i], ts.Next(now))
+		repeated = true
+	}
+
+	if !repeated {
+		return lines
+	}
+
+	lines[0] = setState(lines[0], States[0]) // TODO
+	entry := fmt.Sprintf("* State %q from %q [%s]", States[2], from, now.Format(logLayout))
+	return AddLogbookEntry(lines, entry)
+}
+
+// StateOf returns the TODO state of a bullet line or blank when it has none.
+func StateOf(line string) string {
+	_, content, found := splitBullet(line)
+	if !found {
+		return ""
+	}
+
+	for _, state := range States {
+		if strings.HasPrefix(content, state) {
+			return state
+		}
+	}
+	return ""
+}
+
+// IsOpen reports whether the content of a block starts with one of the OpenStates.
+func IsOpen(content string) bool {
+	for _, state := range OpenStates {
+		if content == state || strings.HasPrefix(content, state+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+// setState replaces the TODO state of a bullet line.
+func setState(line, state string) string {
+	marker, content, found := splitBullet(line)
+	if !found {
+		return line
+	}
+
+	content = strings.TrimPrefix(content, StateOf(line))
+	return fmt.Sprintf("%s%s%s", marker, state, content)
+}
+
+// isPlanning reports whether line holds a SCHEDULED or DEADLINE timestamp.
+func isPlanning(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return strings.HasPrefix(trimmed, "SCHEDULED:") || strings.HasPrefix(trimmed, "DEADLINE:")
+}
+
+// AddLogbookEntry appends entry to the LOGBOOK drawer of the block,
+// creating the drawer below the planning and property lines if needed.
+func AddLogbookEntry(lines []string, entry string) []string {
+	start, end := logbook(lines)
+	if start >= 0 && end >= 0 {
+		indent := leadingSpace(lines[start])
+		return insert(lines, end, indent+entry)
+	}
+
+	// Org sections are not indented below their heading.
+	marker, _, _ := splitBullet(lines[0])
+	indent := ""
+	if !strings.HasPrefix(marker, "*") {
+		indent = strings.TrimSuffix(marker, "- ") + "  "
+	}
+
+	at := 1
+	for at < len(lines) && (isPlanning(lines[at]) || outline.IsProperty(lines[at])) {
+		at++
+	}
+
+	// Skip the Org property drawer.
+	if at < len(lines) && strings.TrimSpace(lines[at]) == ":PROPERTIES:" {
+		for at < len(lines) && strings.TrimSpace(lines[at-1]) != ":END:" {
+			at++
+		}
+	}
+
+	drawer := []string{indent + ":LOGBOOK:", indent + entry, indent + ":END:"}
+	out := make([]string, 0, len(lines)+len(drawer))
+	out = append(out, lines[:at]...)
+	out = append(out, drawer...)
+	return append(out, lines[at:]...)
+}
+
+// logbook returns the line indexes of the ":LOGBOOK:" and ":END:"
+// lines of a block or -1 when the drawer does not exist.
+func logbook(lines []string) (start, end int) {
+	start, end = -1, -1
+	for i, line := range lines {
+		switch strings.TrimSpace(line) {
+		case ":LOGBOOK:":
+			if start < 0 {
+				start = i
+			}
+		case ":END:":
+			if start >= 0 {
+				return start, i
+			}
+		}
+	}
+	return start, end
+}
+
+func leadingSpace(line string) string {
+	return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+}
+
+func insert(lines []string, at int, line string) []string {
+	out := make([]string, 0, len(lines)+1)
+	out = append(out, lines[:at]...)
+	out = append(out, line)
+	return append(out, lines[at:]...)
+}
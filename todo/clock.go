@@ -0,0 +1,117 @@
+package todo
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// clockLayout is the timestamp format Logseq uses for CLOCK entries.
+const clockLayout = "2006-01-02 Mon 15:04:05"
+
+var clockRe = regexp.MustCompile(`^CLOCK: \[([^\]]+)\](?:--\[([^\]]+)\])?`)
+
+// Clock is a single CLOCK entry of a LOGBOOK drawer.
+// End is zero while the clock is still running.
+type Clock struct {
+	Start time.Time
+	End   time.Time
+}
+
+// Running reports whether the clock has not been closed yet.
+func (c Clock) Running() bool {
+	return c.End.IsZero()
+}
+
+// Duration returns the time tracked by a closed clock.
+func (c Clock) Duration() time.Duration {
+	if c.Running() {
+		return 0
+	}
+	return c.End.Sub(c.Start)
+}
+
+// String formats the clock as a LOGBOOK line.
+func (c Clock) String() string {
+	line := fmt.Sprintf("CLOCK: [%s]", c.Start.Format(clockLayout))
+	if c.Running() {
+		return line
+	}
+	return fmt.Sprintf("%s--[%s] =>  %s", line, c.End.Format(clockLayout), FormatDuration(c.Duration()))
+}
+
+// ParseClock parses a "CLOCK: [start]--[end] => duration" line.
+func ParseClock(line string) (Clock, bool) {
+	m := clockRe.FindStringSubmatch(strings.TrimSpace(line))
+	if m == nil {
+		return Clock{}, false
+	}
+
+	start, ok := parseClockTime(m[1])
+	if !ok {
+		return Clock{}, false
+	}
+
+	c := Clock{Start: start}
+	if m[2] != "" {
+		if c.End, ok = parseClockTime(m[2]); !ok {
+			return Clock{}, false
+		}
+	}
+
+	return c, true
+}
+
+func parseClockTime(s string) (time.Time, bool) {
+	for _, layout := range []string{clockLayout, logLayout} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// FormatDuration formats d as hh:mm:ss the way Logseq does.
+func FormatDuration(d time.Duration) string {
+	d = d.Round(time.Second)
+	h := d / time.Hour
+	d -= h * time.Hour
+	m := d / time.Minute
+	d -= m * time.Minute
+	return fmt.Sprintf("%02d:%02d:%02d", h, m, d/time.Second)
+}
+
+// Clocks returns every CLOCK entry found in the lines of a block.
+func Clocks(lines []string) []Clock {
+	var clocks []Clock
+	for _, line := range lines {
+		if c, ok := ParseClock(line); ok {
+			clocks = append(clocks, c)
+		}
+	}
+	return clocks
+}
+
+// StartClock opens a new CLOCK entry in the LOGBOOK drawer of the block.
+func StartClock(lines []string, now time.Time) []string {
+	return AddLogbookEntry(lines, Clock{Start: now.Truncate(time.Second)}.String())
+}
+
+// StopClock closes every running CLOCK entry of the block.
+func StopClock(lines []string, now time.Time) []string {
+	out := make([]string, len(lines))
+	copy(out, lines)
+
+	for i, line := range out {
+		c, ok := ParseClock(line)
+		if !ok || !c.Running() {
+			continue
+		}
+
+		c.End = now.Truncate(time.Second)
+		out[i] = leadingSpace(line) + c.String()
+	}
+
+	return out
+}
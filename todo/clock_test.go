@@ -0,0 +1,106 @@
+package todo
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCycleBlockClock(t *testing.T) {
+	start := time.Date(2025, 1, 15, 9, 0, 0, 0, time.Local)
+	end := start.Add(90*time.Minute + 5*time.Second)
+
+	lines := []string{"\t- TODO write report", "\t  id:: 1234"}
+
+	lines = CycleBlock(lines, start)
+	want := []string{
+		"\t- DOING write report",
+		"\t  id:: 1234",
+		"\t  :LOGBOOK:",
+		"\t  CLOCK: [2025-01-15 Wed 09:00:00]",
+		"\t  :END:",
+	}
+	if !reflect.DeepEqual(lines, want) {
+		t.Fatalf("starting clock =\n%q\nwant\n%q", lines, want)
+	}
+
+	lines = CycleBlock(lines, end)
+	want = []string{
+		"\t- DONE write report",
+		"\t  id:: 1234",
+		"\t  :LOGBOOK:",
+		"\t  CLOCK: [2025-01-15 Wed 09:00:00]--[2025-01-15 Wed 10:30:05] =>  01:30:05",
+		"\t  :END:",
+	}
+	if !reflect.DeepEqual(lines, want) {
+		t.Fatalf("stopping clock =\n%q\nwant\n%q", lines, want)
+	}
+
+	clocks := Clocks(lines)
+	if len(clocks) != 1 || clocks[0].Duration() != end.Sub(start) {
+		t.Errorf("Clocks() = %v, want one clock of %v", clocks, end.Sub(start))
+	}
+}
+
+func TestParseClock(t *testing.T) {
+	tests := map[string]struct {
+		input    string
+		ok       bool
+		running  bool
+		duration time.Duration
+	}{
+		"closed clock": {
+			input:    "CLOCK: [2022-08-09 Tue 21:37:41]--[2022-08-09 Tue 21:37:45] =>  00:00:04",
+			ok:       true,
+			duration: 4 * time.Second,
+		},
+		"running clock": {
+			input:   "  CLOCK: [2022-08-09 Tue 21:37:41]",
+			ok:      true,
+			running: true,
+		},
+		"org clock without seconds": {
+			input:    "CLOCK: [2022-08-09 Tue 21:37]--[2022-08-09 Tue 22:37] =>  1:00",
+			ok:       true,
+			duration: time.Hour,
+		},
+		"not a clock": {
+			input: "- CLOCK: in the text",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			c, ok := ParseClock(tt.input)
+			if ok != tt.ok {
+				t.Fatalf("ParseClock(%q) ok = %v, want %v", tt.input, ok, tt.ok)
+			}
+			if !ok {
+				return
+			}
+			if c.Running() != tt.running || c.Duration() != tt.duration {
+				t.Errorf("ParseClock(%q) = running %v duration %v", tt.input, c.Running(), c.Duration())
+			}
+		})
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := map[string]struct {
+		input    time.Duration
+		expected string
+	}{
+		"zero":          {input: 0, expected: "00:00:00"},
+		"seconds":       {input: 6 * time.Second, expected: "00:00:06"},
+		"over a day":    {input: 26*time.Hour + 3*time.Minute, expected: "26:03:00"},
+		"rounds millis": {input: 1500 * time.Millisecond, expected: "00:00:02"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got := FormatDuration(tt.input); got != tt.expected {
+				t.Errorf("FormatDuration(%v) = %q, want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package todo
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// Repeater kinds supported by Logseq and Org mode.
+const (
+	// RepeatCumulate shifts the date by the interval once: "+1w".
+	RepeatCumulate = "+"
+	// RepeatCatchUp shifts the date by the interval until it is in the future: "++1w".
+	RepeatCatchUp = "++"
+	// RepeatRestart shifts the date by the interval from today: ".+1w".
+	RepeatRestart = ".+"
+)
+
+var timestampRe = regexp.MustCompile(`<(\d{4}-\d{2}-\d{2})(?: [A-Za-z]{2,3})?(?: (\d{1,2}:\d{2}))?(?: (\.\+|\+\+|\+)(\d+)([hdwmy]))?>`)
+
+// Timestamp is a SCHEDULED or DEADLINE timestamp such as "<2025-01-06 Mon 09:00 .+1w>".
+type Timestamp struct {
+	Time    time.Time
+	HasTime bool
+
+	// Repeat is one of the Repeat* kinds or blank when the timestamp does not repeat.
+	Repeat string
+	Count  int
+	Unit   byte
+}
+
+// ParseTimestamp finds the first timestamp within s.
+func ParseTimestamp(s string) (Timestamp, bool) {
+	m := timestampRe.FindStringSubmatch(s)
+	if m == nil {
+		return Timestamp{}, false
+	}
+
+	layout, value := "2006-01-02", m[1]
+	if m[2] != "" {
+		layout, value = "2006-01-02 15:04", m[1]+" "+m[2]
+	}
+
+	t, err := time.ParseInLocation(layout, value, time.Local)
+	if err != nil {
+		return Timestamp{}, false
+	}
+
+	ts := Timestamp{Time: t, HasTime: m[2] != "", Repeat: m[3]}
+	if ts.Repeat != "" {
+		ts.Count, _ = strconv.Atoi(m[4])
+		ts.Unit = m[5][0]
+	}
+
+	return ts, true
+}
+
+// String formats the timestamp the way Logseq writes it.
+func (ts Timestamp) String() string {
+	out := ts.Time.Format("2006-01-02 Mon")
+	if ts.HasTime {
+		out += ts.Time.Format(" 15:04")
+	}
+	if ts.Repeat != "" {
+		out += fmt.Sprintf(" %s%d%c", ts.Repeat, ts.Count, ts.Unit)
+	}
+	return "<" + out + ">"
+}
+
+// Next returns the timestamp advanced by its repeater relative to now.
+// Timestamps without a repeater are returned unchanged.
+func (ts Timestamp) Next(now time.Time) Timestamp {
+	if ts.Repeat == "" || ts.Count <= 0 {
+		return ts
+	}
+
+	// Hourly repeaters need a time of day to be meaningful.
+	if ts.Unit == 'h' {
+		ts.HasTime = true
+	}
+
+	switch ts.Repeat {
+	case RepeatRestart:
+		base := time.Date(now.Year(), now.Month(), now.Day(),
+			ts.Time.Hour(), ts.Time.Minute(), 0, 0, ts.Time.Location())
+		if ts.Unit == 'h' {
+			base = now.Truncate(time.Minute)
+		}
+		ts.Time = ts.shift(base)
+	case RepeatCatchUp:
+		ts.Time = ts.shift(ts.Time)
+		for !ts.after(now) {
+			ts.Time = ts.shift(ts.Time)
+		}
+	default:
+		ts.Time = ts.shift(ts.Time)
+	}
+
+	return ts
+}
+
+// shift adds a single repeater interval to t.
+func (ts Timestamp) shift(t time.Time) time.Time {
+	switch ts.Unit {
+	case 'h':
+		return t.Add(time.Duration(ts.Count) * time.Hour)
+	case 'w':
+		return t.AddDate(0, 0, 7*ts.Count)
+	case 'm':
+		return t.AddDate(0, ts.Count, 0)
+	case 'y':
+		return t.AddDate(ts.Count, 0, 0)
+	default:
+		return t.AddDate(0, 0, ts.Count)
+	}
+}
+
+// after reports whether the timestamp lies in the future. Timestamps
+// without a time of day only count as future once they are past today.
+func (ts Timestamp) after(now time.Time) bool {
+	if ts.HasTime || ts.Unit == 'h' {
+		return ts.Time.After(now)
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, ts.Time.Location())
+	return ts.Time.After(today)
+}
+
+// replaceTimestamp swaps the first timestamp in line for ts.
+func replaceTimestamp(line string, ts Timestamp) string {
+	loc := timestampRe.FindStringIndex(line)
+	if loc == nil {
+		return line
+	}
+	return line[:loc[0]] + ts.String() + line[loc[1]:]
+}
@@ -0,0 +1,144 @@
+package todo
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTimestampNext(t *testing.T) {
+	now := time.Date(2025, 1, 15, 10, 30, 0, 0, time.Local)
+
+	tests := map[string]struct {
+		input    string
+		expected string
+	}{
+		"no repeater": {
+			input:    "<2025-01-06 Mon>",
+			expected: "<2025-01-06 Mon>",
+		},
+		"cumulate daily": {
+			input:    "<2025-01-06 Mon +1d>",
+			expected: "<2025-01-07 Tue +1d>",
+		},
+		"cumulate weekly stays in the past": {
+			input:    "<2025-01-01 Wed +1w>",
+			expected: "<2025-01-08 Wed +1w>",
+		},
+		"catch up weekly": {
+			input:    "<2025-01-01 Wed ++1w>",
+			expected: "<2025-01-22 Wed ++1w>",
+		},
+		"catch up keeps weekday": {
+			input:    "<2025-01-13 Mon ++1w>",
+			expected: "<2025-01-20 Mon ++1w>",
+		},
+		"restart from today": {
+			input:    "<2025-01-01 Wed .+1w>",
+			expected: "<2025-01-22 Wed .+1w>",
+		},
+		"restart monthly": {
+			input:    "<2024-11-03 Sun .+1m>",
+			expected: "<2025-02-15 Sat .+1m>",
+		},
+		"yearly with time": {
+			input:    "<2024-03-01 Fri 09:00 +1y>",
+			expected: "<2025-03-01 Sat 09:00 +1y>",
+		},
+		"catch up hourly": {
+			input:    "<2025-01-15 Wed 08:00 ++2h>",
+			expected: "<2025-01-15 Wed 12:00 ++2h>",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ts, ok := ParseTimestamp(tt.input)
+			if !ok {
+				t.Fatalf("ParseTimestamp(%q) failed", tt.input)
+			}
+
+			if got := ts.Next(now).String(); got != tt.expected {
+				t.Errorf("Next(%q) = %q, want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCycleBlock(t *testing.T) {
+	now := time.Date(2025, 1, 15, 10, 30, 0, 0, time.Local)
+
+	tests := map[string]struct {
+		input    []string
+		expected []string
+	}{
+		"non repeating task completes": {
+			input: []string{
+				"- DOING water plants",
+				"  SCHEDULED: <2025-01-06 Mon>",
+			},
+			expected: []string{
+				"- DONE water plants",
+				"  SCHEDULED: <2025-01-06 Mon>",
+			},
+		},
+		"repeating task starts": {
+			input: []string{
+				"- TODO water plants",
+				"  SCHEDULED: <2025-01-06 Mon .+1w>",
+			},
+			expected: []string{
+				"- DOING water plants",
+				"  SCHEDULED: <2025-01-06 Mon .+1w>",
+				"  :LOGBOOK:",
+				"  CLOCK: [2025-01-15 Wed 10:30:00]",
+				"  :END:",
+			},
+		},
+		"repeating task resets": {
+			input: []string{
+				"\t- DOING water plants",
+				"\t  SCHEDULED: <2025-01-06 Mon .+1w>",
+			},
+			expected: []string{
+				"\t- TODO water plants",
+				"\t  SCHEDULED: <2025-01-22 Wed .+1w>",
+				"\t  :LOGBOOK:",
+				"\t  * State \"DONE\" from \"DOING\" [2025-01-15 Wed 10:30]",
+				"\t  :END:",
+			},
+		},
+		"deadline and existing logbook": {
+			input: []string{
+				"- DOING pay rent",
+				"  DEADLINE: <2025-01-01 Wed +1m>",
+				"  :LOGBOOK:",
+				"  * State \"DONE\" from \"DOING\" [2024-12-02 Mon 09:00]",
+				"  :END:",
+				"  notes",
+			},
+			expected: []string{
+				"- TODO pay rent",
+				"  DEADLINE: <2025-02-01 Sat +1m>",
+				"  :LOGBOOK:",
+				"  * State \"DONE\" from \"DOING\" [2024-12-02 Mon 09:00]",
+				"  * State \"DONE\" from \"DOING\" [2025-01-15 Wed 10:30]",
+				"  :END:",
+				"  notes",
+			},
+		},
+		"block without task": {
+			input:    []string{"- plain", "  text"},
+			expected: []string{"- TODO plain", "  text"},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := CycleBlock(tt.input, now)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("CycleBlock() =\n%q\nwant\n%q", got, tt.expected)
+			}
+		})
+	}
+}
@@ -10,6 +10,12 @@ import (
 // States represents the valid TODO states in order of cycling
 var States = []string{"TODO", "DOING", "DONE"}
 
+// OpenStates are the Logseq task markers of work that has not been finished yet.
+var OpenStates = []string{"TODO", "DOING", "LATER", "NOW"}
+
+// Markers are every task keyword Logseq recognises at the start of a block.
+var Markers = []string{"TODO", "DOING", "DONE", "LATER", "NOW", "WAITING", "CANCELED", "CANCELLED"}
+
 // Priorities represents the valid priority levels in order of cycling
 var Priorities = []string{"[#A]", "[#B]", "[#C]"}
 
@@ -17,7 +23,7 @@ var Priorities = []string{"[#A]", "[#B]", "[#C]"}
 // If no state exists, it adds "TODO" at the start of the line.
 func CycleState(line string) string {
 	// Extract indentation and content:
-	var indent, content, found = strings.Cut(line, "- ")
+	var marker, content, found = splitBullet(line)
 
 	if !found {
 		return line
@@ -40,13 +46,13 @@ func CycleState(line string) string {
 
 	switch currentState {
 	case States[0]: // TODO
-		return fmt.Sprintf("%s- DOING%s", indent, content)
+		return fmt.Sprintf("%sDOING%s", marker, content)
 	case States[1]: // DOING
-		return fmt.Sprintf("%s- DONE%s", indent, content)
+		return fmt.Sprintf("%sDONE%s", marker, content)
 	case States[2]: // DONE
-		return fmt.Sprintf("%s-%s", indent, content)
+		return fmt.Sprintf("%s%s", strings.TrimSuffix(marker, " "), content)
 	default:
-		return fmt.Sprintf("%s- TODO %s", indent, content)
+		return fmt.Sprintf("%sTODO %s", marker, content)
 	}
 }
 
@@ -54,7 +60,7 @@ func CycleState(line string) string {
 // Only adds/cycles priority if the line starts with a TODO state.
 func CyclePriority(line string) string {
 	// Extract indentation and content:
-	var indent, content, _ = strings.Cut(line, "- ")
+	var marker, content, _ = splitBullet(line)
 
 	// Do nothing on an empty line:
 	if strings.EqualFold(content, "") {
@@ -95,14 +101,38 @@ func CyclePriority(line string) string {
 
 	switch currentPriority {
 	case Priorities[0]: // [#A]
-		return fmt.Sprintf("%s- %s [#B] %s", indent, statePrefix, content)
+		return fmt.Sprintf("%s%s [#B] %s", marker, statePrefix, content)
 	case Priorities[1]: // [#B]
-		return fmt.Sprintf("%s- %s [#C] %s", indent, statePrefix, content)
+		return fmt.Sprintf("%s%s [#C] %s", marker, statePrefix, content)
 	case Priorities[2]: // [#C]
-		return fmt.Sprintf("%s- %s %s", indent, statePrefix, content)
+		return fmt.Sprintf("%s%s %s", marker, statePrefix, content)
 	default:
 		// When no TODO state is found the function returns the original line
 		// before checking for a current priority.
-		return fmt.Sprintf("%s- %s [#A] %s", indent, statePrefix, content)
+		return fmt.Sprintf("%s%s [#A] %s", marker, statePrefix, content)
+	}
+}
+
+// splitBullet splits a bullet line into its marker, including the indentation
+// and the trailing space, and its content. Org headings use their stars as the
+// marker so tasks in Org pages cycle the same way as in Markdown pages.
+func splitBullet(line string) (marker, content string, found bool) {
+	stars := len(line) - len(strings.TrimLeft(line, "*"))
+	if stars > 0 && len(line) > stars && line[stars] == ' ' {
+		return line[:stars+1], line[stars+1:], true
+	}
+
+	indent, content, found := strings.Cut(line, "- ")
+	return indent + "- ", content, found
+}
+
+// Marker returns the task keyword the content of a block starts with, or
+// blank when the block is not a task.
+func Marker(content string) string {
+	for _, marker := range Markers {
+		if content == marker || strings.HasPrefix(content, marker+" ") {
+			return marker
+		}
 	}
+	return ""
 }
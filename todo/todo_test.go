@@ -71,6 +71,18 @@ func TestCycleState(t *testing.T) {
 			input:    "- TODO [#A] test line.",
 			expected: "- DOING [#A] test line.",
 		},
+		"org heading without state": {
+			input:    "** test line",
+			expected: "** TODO test line",
+		},
+		"org heading with DOING": {
+			input:    "* DOING test line",
+			expected: "* DONE test line",
+		},
+		"org heading with DONE": {
+			input:    "*** DONE test line",
+			expected: "*** test line",
+		},
 	}
 
 	for name, tt := range tests {
@@ -116,6 +128,10 @@ func TestCyclePriority(t *testing.T) {
 			input:    "- DOING test line",
 			expected: "- DOING [#A] test line",
 		},
+		"org heading without priority": {
+			input:    "** TODO test line",
+			expected: "** TODO [#A] test line",
+		},
 		"DONE line without priority": {
 			input:    "- DONE test line",
 			expected: "- DONE [#A] test line",
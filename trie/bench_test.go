@@ -0,0 +1,73 @@
+package trie_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jrswab/lsq/trie"
+)
+
+// benchPages writes a pages directory of n pages of a few kilobytes, every
+// tenth with aliases, as a stand-in for a large graph.
+func benchPages(b *testing.B, n int) string {
+	b.Helper()
+	dir := b.TempDir()
+	body := ""
+	for i := 0; i < 100; i++ {
+		body += fmt.Sprintf("- block %d with [[links]] and some words to read\n", i)
+	}
+	for i := 0; i < n; i++ {
+		content := body
+		if i%10 == 0 {
+			content = fmt.Sprintf("alias:: page alias %d, other %d\n\n", i, i) + body
+		}
+		if err := os.WriteFile(filepath.Join(dir, fmt.Sprintf("page %d.md", i)), []byte(content), 0644); err != nil {
+			b.Fatal(err)
+		}
+	}
+	return dir
+}
+
+// BenchmarkInit compares building the trie from every page to reading it
+// from a cache file that is up to date.
+func BenchmarkInit(b *testing.B) {
+	for _, n := range []int{1000, 10000} {
+		dir := benchPages(b, n)
+
+		b.Run(fmt.Sprintf("cold/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, err := trie.NewCache(dir, "").Trie(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+
+		b.Run(fmt.Sprintf("cached/%d", n), func(b *testing.B) {
+			cacheDir := b.TempDir()
+			if _, err := trie.NewCache(dir, cacheDir).Trie(); err != nil {
+				b.Fatal(err)
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				// A new cache each time reads the file like a new run does.
+				if _, err := trie.NewCache(dir, cacheDir).Trie(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkSearch(b *testing.B) {
+	b.Setenv("LSQ_CACHE_DIR", b.TempDir())
+	tr, err := trie.Init(benchPages(b, 1000))
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tr.Search("page 1")
+	}
+}
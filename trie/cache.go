@@ -0,0 +1,275 @@
+package trie
+
+import (
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// cacheVersion changes whenever the cache format or the way aliases are
+// parsed does, so older caches are read again from the pages.
+const cacheVersion = 2
+
+// Cache keeps the trie of a pages directory along with the size and
+// modification time of every page, so only the pages that changed since
+// are read again. It is saved to a file between runs when one is given.
+type Cache struct {
+	dir  string
+	file string
+
+	mu     sync.Mutex
+	loaded bool
+	stored cache
+	trie   *Trie
+}
+
+// cache is the part of a Cache saved to its file.
+type cache struct {
+	Version int
+	Pages   map[string]cachedPage
+	// Names are the file names the nodes point at.
+	Names []string
+	// Nodes are the trie in preorder.
+	Nodes []cachedNode
+}
+
+// cachedPage is one page of the cache, keyed by its file name.
+type cachedPage struct {
+	Size    int64
+	ModTime int64
+	Aliases []string
+}
+
+// cachedNode is a node of the trie. File is an index into the names, or
+// -1 for nodes that do not end a name or alias.
+type cachedNode struct {
+	Rune     rune
+	Children int32
+	File     int32
+	Alias    bool
+}
+
+// NewCache returns a cache of the trie of the pages directory dir, saved in
+// cacheDir. With an empty cacheDir the cache is only kept in memory.
+func NewCache(dir, cacheDir string) *Cache {
+	c := &Cache{dir: dir}
+	if cacheDir != "" {
+		if abs, err := filepath.Abs(dir); err == nil {
+			sum := sha256.Sum256([]byte(abs))
+			c.file = filepath.Join(cacheDir, "trie-"+hex.EncodeToString(sum[:8])+".gob")
+		}
+	}
+	return c
+}
+
+// CacheDir returns the directory lsq keeps its caches in: $LSQ_CACHE_DIR,
+// or lsq in the user's cache directory. It is empty when there is none.
+func CacheDir() string {
+	if dir := os.Getenv("LSQ_CACHE_DIR"); dir != "" {
+		return dir
+	}
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(base, "lsq")
+}
+
+// Trie returns the trie of the page names and aliases, reading only the
+// pages whose size or modification time changed since it was last built.
+func (c *Cache) Trie() (*Trie, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.loaded {
+		c.stored = c.load()
+		c.loaded = true
+	}
+
+	// get list of all files in ~/Logseq/Pages
+	fileList, err := os.ReadDir(c.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	pages := make(map[string]cachedPage, len(fileList))
+	changed := len(c.stored.Pages) == 0 && len(c.stored.Nodes) == 0
+	for i := range fileList {
+		if fileList[i].IsDir() {
+			continue
+		}
+		name := fileList[i].Name()
+
+		info, err := fileList[i].Info()
+		if err != nil {
+			return nil, err
+		}
+
+		page, ok := c.stored.Pages[name]
+		if !ok || page.Size != info.Size() || page.ModTime != info.ModTime().UnixNano() {
+			// Get file contents:
+			content, err := os.ReadFile(filepath.Join(c.dir, name))
+			if err != nil {
+				return nil, err
+			}
+			page = cachedPage{Size: info.Size(), ModTime: info.ModTime().UnixNano(), Aliases: Aliases(string(content))}
+			changed = true
+		}
+		pages[name] = page
+	}
+	// Pages were removed since.
+	changed = changed || len(pages) != len(c.stored.Pages)
+
+	switch {
+	case !changed && c.trie != nil:
+		return c.trie, nil
+	case !changed:
+		c.trie = decode(c.stored)
+		return c.trie, nil
+	}
+
+	c.trie = build(pages)
+	c.stored = encode(c.trie, pages)
+	// The cache only saves time, so failing to save it is not an error.
+	c.save()
+	return c.trie, nil
+}
+
+// build inserts the page names and then their aliases. An alias listed by
+// several pages points at the last one in name order.
+func build(pages map[string]cachedPage) *Trie {
+	tree := NewTrie()
+
+	names := make([]string, 0, len(pages))
+	for name := range pages {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// Allocate enough space in case every page has an alias:
+	aliases := make(map[string]string, len(names))
+
+	// First pass: Process all files and collect aliases
+	for _, name := range names {
+		tree.InsertFileName(name)
+		for _, alias := range pages[name].Aliases {
+			aliases[alias] = name
+		}
+	}
+
+	// Second pass: Insert all collected aliases
+	for alias, fileName := range aliases {
+		tree.InsertAlias(alias, fileName)
+	}
+	return tree
+}
+
+// encode flattens the trie into its nodes in preorder, children in rune
+// order, with the file names they point at stored once.
+func encode(t *Trie, pages map[string]cachedPage) cache {
+	c := cache{Version: cacheVersion, Pages: pages}
+	index := make(map[string]int32)
+
+	var walk func(n *Node, r rune)
+	walk = func(n *Node, r rune) {
+		node := cachedNode{Rune: r, Children: int32(len(n.Children)), File: -1, Alias: n.IsAlias}
+		if n.IsEndOfWord {
+			i, ok := index[n.FileName]
+			if !ok {
+				i = int32(len(c.Names))
+				index[n.FileName] = i
+				c.Names = append(c.Names, n.FileName)
+			}
+			node.File = i
+		}
+		c.Nodes = append(c.Nodes, node)
+
+		runes := make([]rune, 0, len(n.Children))
+		for r := range n.Children {
+			runes = append(runes, r)
+		}
+		sort.Slice(runes, func(i, j int) bool { return runes[i] < runes[j] })
+		for _, r := range runes {
+			walk(n.Children[r], r)
+		}
+	}
+	walk(t.RootNode, 0)
+	return c
+}
+
+// decode rebuilds the trie from its nodes.
+func decode(c cache) *Trie {
+	pos := 0
+	var read func() *Node
+	read = func() *Node {
+		cn := c.Nodes[pos]
+		pos++
+
+		n := NewNode(string(cn.Rune))
+		if cn.File >= 0 && int(cn.File) < len(c.Names) {
+			n.IsEndOfWord = true
+			n.FileName = c.Names[cn.File]
+			n.IsAlias = cn.Alias
+		}
+		for i := int32(0); i < cn.Children && pos < len(c.Nodes); i++ {
+			r := c.Nodes[pos].Rune
+			n.Children[r] = read()
+		}
+		return n
+	}
+
+	root := read()
+	root.Char = "\000"
+	return &Trie{RootNode: root}
+}
+
+// load reads the cache file. A missing, unreadable or outdated cache is
+// empty.
+func (c *Cache) load() cache {
+	empty := cache{Version: cacheVersion}
+	if c.file == "" {
+		return empty
+	}
+
+	f, err := os.Open(c.file)
+	if err != nil {
+		return empty
+	}
+	defer f.Close()
+
+	var stored cache
+	if err := gob.NewDecoder(f).Decode(&stored); err != nil || stored.Version != cacheVersion || len(stored.Nodes) == 0 {
+		return empty
+	}
+	return stored
+}
+
+// save writes the cache file through a temporary file, so a concurrent lsq
+// never reads half of it.
+func (c *Cache) save() error {
+	if c.file == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(c.file), 0700); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(c.file), filepath.Base(c.file)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := gob.NewEncoder(tmp).Encode(c.stored); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), c.file)
+}
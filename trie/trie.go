@@ -1,8 +1,6 @@
 package trie
 
 import (
-	"os"
-	"path/filepath"
 	"sort"
 	"strings"
 	"unicode"
@@ -46,61 +44,35 @@ func NewTrie() *Trie {
 	return &Trie{RootNode: NewNode("\000")}
 }
 
+// Init builds a trie of the page names and aliases of the pages directory
+// at path, cached in CacheDir so only the pages that changed since the last
+// run are read.
 func Init(path string) (*Trie, error) {
-	tree := NewTrie()
-
-	// get list of all files in ~/Logseq/Pages
-	fileList, err := os.ReadDir(path)
-	if err != nil {
-		return nil, err
-	}
-
-	// Allocate enough space in case every page has an alias:
-	aliases := make(map[string]string, len(fileList))
-
-	// First pass: Process all files and collect aliases
-	for i := range fileList {
-		if !fileList[i].IsDir() {
-			tree.InsertFileName(fileList[i].Name())
-
-			// Get file contents:
-			content, err := os.ReadFile(filepath.Join(path, fileList[i].Name()))
-			if err != nil {
-				return nil, err
-			}
-
-			// Split into a slice of lines:
-			lines := strings.Split(string(content), "\n")
-
-			// Find the line containing "alias::"
-			for _, line := range lines {
-				if strings.Contains(line, "alias::") {
+	return NewCache(path, CacheDir()).Trie()
+}
 
-					parts := strings.Split(line, "::")
-					if len(parts) != 2 {
-						continue
-					}
+// Aliases returns the aliases listed on the "alias::" lines of a page.
+func Aliases(content string) []string {
+	var aliases []string
 
-					aliasList := strings.Split(parts[1], ",")
+	// Find the line containing "alias::"
+	for _, line := range strings.Split(content, "\n") {
+		if !strings.Contains(line, "alias::") {
+			continue
+		}
 
-					for _, alias := range aliasList {
-						trimmedAlias := strings.TrimSpace(alias)
+		parts := strings.Split(line, "::")
+		if len(parts) != 2 {
+			continue
+		}
 
-						if trimmedAlias != "" {
-							aliases[trimmedAlias] = fileList[i].Name()
-						}
-					}
-				}
+		for _, alias := range strings.Split(parts[1], ",") {
+			if trimmedAlias := strings.TrimSpace(alias); trimmedAlias != "" {
+				aliases = append(aliases, trimmedAlias)
 			}
 		}
 	}
-
-	// Second pass: Insert all collected aliases
-	for alias, fileName := range aliases {
-		tree.InsertAlias(alias, fileName)
-	}
-
-	return tree, nil
+	return aliases
 }
 
 // Insert inserts a word to the trie.
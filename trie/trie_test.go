@@ -10,6 +10,9 @@ import (
 )
 
 func TestInit(t *testing.T) {
+	cacheDir := t.TempDir()
+	t.Setenv("LSQ_CACHE_DIR", cacheDir)
+
 	// Create temporary directory for test files
 	tempDir, err := os.MkdirTemp("", "logseq-test")
 	if err != nil {
@@ -169,6 +172,145 @@ func TestInit(t *testing.T) {
 			}
 		})
 	}
+
+	// Init keeps its cache in LSQ_CACHE_DIR.
+	if cached, _ := filepath.Glob(filepath.Join(cacheDir, "trie-*.gob")); len(cached) != 1 {
+		t.Errorf("Init() cache files = %v, want one in %s", cached, cacheDir)
+	}
+}
+
+func TestCache(t *testing.T) {
+	cacheDir := t.TempDir()
+	dir := t.TempDir()
+	page := filepath.Join(dir, "page.md")
+
+	write := func(t *testing.T, content string) {
+		t.Helper()
+		if err := os.WriteFile(page, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write page: %v", err)
+		}
+	}
+
+	// Steps run in order against the same pages directory and cache file.
+	// Each step is a new run of lsq unless it reuses the cache in memory.
+	var cache *trie.Cache
+	steps := []struct {
+		name   string
+		memory bool
+		change func(t *testing.T)
+		search string
+		want   []string
+	}{
+		{
+			name:   "first run reads the pages",
+			change: func(t *testing.T) { write(t, "alias:: first\n") },
+			search: "first",
+			want:   []string{"page.md"},
+		},
+		{
+			name: "unchanged pages come from the cache file",
+			change: func(t *testing.T) {
+				info, err := os.Stat(page)
+				if err != nil {
+					t.Fatal(err)
+				}
+				// Same size and modification time, so the cached trie is kept.
+				write(t, "alias:: other\n")
+				if err := os.Chtimes(page, info.ModTime(), info.ModTime()); err != nil {
+					t.Fatal(err)
+				}
+			},
+			search: "first",
+			want:   []string{"page.md"},
+		},
+		{
+			name:   "changed pages are read again",
+			change: func(t *testing.T) { write(t, "alias:: second one\n") },
+			search: "second",
+			want:   []string{"page.md"},
+		},
+		{
+			name:   "changed pages are read again by a cache in memory",
+			memory: true,
+			change: func(t *testing.T) { write(t, "alias:: third one\n") },
+			search: "third",
+			want:   []string{"page.md"},
+		},
+		{
+			name: "removed pages are dropped",
+			change: func(t *testing.T) {
+				if err := os.Remove(page); err != nil {
+					t.Fatal(err)
+				}
+			},
+			search: "page",
+			want:   nil,
+		},
+		{
+			name: "corrupt cache is ignored",
+			change: func(t *testing.T) {
+				write(t, "alias:: fourth\n")
+				caches, err := filepath.Glob(filepath.Join(cacheDir, "trie-*.gob"))
+				if err != nil || len(caches) != 1 {
+					t.Fatalf("Expected one cache file, got %v (%v)", caches, err)
+				}
+				if err := os.WriteFile(caches[0], []byte("garbage"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			},
+			search: "fourth",
+			want:   []string{"page.md"},
+		},
+	}
+
+	for _, step := range steps {
+		t.Run(step.name, func(t *testing.T) {
+			step.change(t)
+			if !step.memory {
+				cache = trie.NewCache(dir, cacheDir)
+			}
+			tr, err := cache.Trie()
+			if err != nil {
+				t.Fatalf("Trie() error = %v", err)
+			}
+			if got := tr.Search(step.search); !slices.Equal(got, step.want) {
+				t.Errorf("Search(%q) = %v, want %v", step.search, got, step.want)
+			}
+		})
+	}
+}
+
+// TestCacheTrie checks that a trie read back from the cache file finds the
+// same pages as the trie it was saved from.
+func TestCacheTrie(t *testing.T) {
+	cacheDir := t.TempDir()
+	dir := t.TempDir()
+	files := map[string]string{
+		"project alpha.md": "alias:: Apollo, ap\n",
+		"project beta.md":  "alias:: ap\n",
+		"résumé.md":        "- cv",
+		"日本語.md":           "alias:: Japanese",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	built, err := trie.NewCache(dir, cacheDir).Trie()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cached, err := trie.NewCache(dir, cacheDir).Trie()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, prefix := range []string{"", "project", "apo", "ap", "resume", "日本", "jap", "missing"} {
+		if got, want := cached.Search(prefix), built.Search(prefix); !slices.Equal(got, want) {
+			t.Errorf("Search(%q) from the cache = %v, want %v", prefix, got, want)
+		}
+	}
 }
 
 func TestTrieInsertFileName(t *testing.T) {